@@ -2,24 +2,129 @@ package main
 
 import (
 	"bybit-bot/internal/backtest"
-	"bybit-bot/internal/service/strategy"
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/engine"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/repository/migrations"
+	"context"
+	"flag"
+	"fmt"
 	"log"
-	_ "time"
+	"path/filepath"
+	"time"
 )
 
 func main() {
-	err := backtest.DownLoadKlinesFromBybit()
+	configPath := flag.String("config", "", "путь к файлу конфигурации (YAML или TOML)")
+	optimize := flag.Bool("optimize", false, "подбор параметров стратегии по backtest.optimize вместо одиночного прогона")
+	journal := flag.Bool("signals", false, "сохранять проверки сигналов в журнал signals хранилища database")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	bt := cfg.Backtest
+
+	start, end := bt.Range(time.Now())
+	downloader := backtest.NewDownloader(bt.BaseURL, bt.DataDir)
+	klines, gaps, err := downloader.Sync(context.Background(), bt.Category, bt.Symbol, bt.Interval, start, end)
 	if err != nil {
 		log.Fatalf("Ошибка при загрузке данных: %v", err)
 	}
-	klines, err := backtest.LoadKlinesFromCSV("bybit_klines.csv")
+	for _, g := range gaps {
+		log.Printf("Пропуск данных: %d свечей с %s по %s", g.Bars, g.From.Format(time.RFC3339), g.To.Format(time.RFC3339))
+	}
+	log.Printf("Загружено %d свечей %s %s за %s - %s", len(klines), bt.Symbol, bt.Interval,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+
+	execution := backtest.FillModelFromConfig(bt.Execution)
+	opts := backtest.Options{
+		Symbol:             bt.Symbol,
+		Category:           bt.Category,
+		Interval:           bt.Interval,
+		InitialBalance:     bt.InitialBalance,
+		MaxPositionPercent: cfg.Risk.MaxPositionPercent,
+		Sizing:             cfg.Risk.Sizing,
+		Strategy:           cfg.Strategy,
+		Execution:          &execution,
+	}
+	if execution.Intrabar == engine.IntrabarLowerTimeframe {
+		lower, _, err := downloader.Sync(context.Background(), bt.Category, bt.Symbol, bt.Execution.LowerInterval, start, end)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки свечей младшего интервала: %v", err)
+		}
+		opts.LowerInterval = bt.Execution.LowerInterval
+		opts.LowerTimeframe = lower
+	}
+
+	if *optimize {
+		runOptimization(cfg, klines, opts)
+		return
+	}
+
+	if *journal {
+		store, err := openSignalStore(cfg)
+		if err != nil {
+			log.Fatalf("Ошибка подключения к БД: %v", err)
+		}
+		defer store.Close()
+		opts.Signals = store.Signals
+	}
+
+	result, err := backtest.RunStrategy(cfg.Strategy.Name, klines, opts)
 	if err != nil {
-		log.Fatalf("Ошибка загрузки CSV: %v", err)
+		log.Fatalf("Ошибка бэктеста: %v", err)
+	}
+
+	rep := result.Report
+	log.Printf("Сделок: %d (лонг %d, шорт %d, закрыто по концу данных %d) | Побед: %d | Поражений: %d | WinRate: %.2f%%",
+		result.NumTrades, rep.Long.Trades, rep.Short.Trades, result.NumUnclosed, result.NumWins, result.NumLosses, result.WinRate)
+	log.Printf("Капитал: %.2f -> %.2f USDT (%.2f%%) | PnL: %.2f USDT | Комиссии: %.2f | Проскальзывание: %.2f",
+		rep.InitialCapital, rep.FinalEquity, rep.TotalReturn*100, result.TotalPnL, rep.TotalFees, rep.TotalSlippage)
+	log.Printf("Макс. просадка: %.2f%% (%.1f ч) | Sharpe: %.2f | Sortino: %.2f | Calmar: %.2f | Profit factor: %.2f | Ожидание: %.2f USDT",
+		rep.MaxDrawdown*100, rep.MaxDrawdownDurationHours, rep.Sharpe, rep.Sortino, rep.Calmar, rep.ProfitFactor, rep.Expectancy)
+	log.Printf("Среднее удержание: %.1f ч | Время в позиции: %.2f%%", rep.AvgHoldHours, rep.Exposure*100)
+	for _, m := range rep.Monthly {
+		log.Printf("  %s: %.2f%% (%.2f USDT)", m.Month, m.Return*100, m.PnL)
 	}
 
-	sd := &strategy.SignalDetector{}
-	result := backtest.RunBacktest(klines, sd.CheckLongSignal, sd.CheckShortSignal)
+	prefix := filepath.Join(bt.ReportDir, fmt.Sprintf("%s_%s_%s", cfg.Strategy.Name, bt.Symbol, bt.Interval))
+	if err := rep.WriteJSON(prefix + "_report.json"); err != nil {
+		log.Fatalf("Ошибка сохранения отчёта: %v", err)
+	}
+	if err := backtest.WriteTradesCSV(prefix+"_trades.csv", result.Trades); err != nil {
+		log.Fatalf("Ошибка сохранения сделок: %v", err)
+	}
+	if err := rep.WriteEquityCSV(prefix + "_equity.csv"); err != nil {
+		log.Fatalf("Ошибка сохранения кривой капитала: %v", err)
+	}
+	if err := rep.WriteMonthlyCSV(prefix + "_monthly.csv"); err != nil {
+		log.Fatalf("Ошибка сохранения помесячных доходностей: %v", err)
+	}
+	log.Printf("Отчёт сохранён: %s_*", prefix)
+}
 
-	log.Printf("Сделок: %d | Побед: %d | Поражений: %d | WinRate: %.2f%% | PnL: %.4f",
-		result.NumTrades, result.NumWins, result.NumLosses, result.WinRate, result.TotalPnL)
+// openSignalStore открывает хранилище для журнала сигналов и применяет миграции SQL-базы.
+func openSignalStore(cfg *config.Config) (*repository.Store, error) {
+	dsn, err := cfg.DatabaseDSN()
+	if err != nil {
+		return nil, err
+	}
+	store, err := repository.Open(cfg.Database.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if store.DB == nil {
+		return store, nil
+	}
+	migrator, err := migrations.New(store.DB, cfg.Database.Driver)
+	if err == nil {
+		_, err = migrator.Up()
+	}
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return store, nil
 }
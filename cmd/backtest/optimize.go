@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bybit-bot/internal/backtest"
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/model"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runOptimization подбирает параметры стратегии по backtest.optimize и сохраняет результаты в JSON.
+func runOptimization(cfg *config.Config, klines []model.KlineData, opts backtest.Options) {
+	oc := cfg.Backtest.Optimize
+	optOpts := backtest.OptimizeOptions{
+		Strategy:  cfg.Strategy.Name,
+		Method:    oc.Method,
+		Objective: oc.Objective,
+		Samples:   oc.Samples,
+		Workers:   oc.Workers,
+		MinTrades: oc.MinTrades,
+		Seed:      oc.Seed,
+		Ranges:    oc.Params,
+	}
+	prefix := filepath.Join(cfg.Backtest.ReportDir, fmt.Sprintf("%s_%s_%s", cfg.Strategy.Name, cfg.Backtest.Symbol, cfg.Backtest.Interval))
+
+	// Стратегии пишут в лог каждую свечу: на время прогонов вывод отключается.
+	out := log.Writer()
+	silence := func() { log.SetOutput(io.Discard) }
+	restore := func() { log.SetOutput(out) }
+
+	if oc.InSampleBars > 0 && oc.OutOfSampleBars > 0 {
+		wf := backtest.WalkForwardOptions{
+			InSampleBars:    oc.InSampleBars,
+			OutOfSampleBars: oc.OutOfSampleBars,
+			WarmupBars:      oc.WarmupBars,
+		}
+		log.Printf("Walk-forward: in-sample %d, out-of-sample %d свечей, цель %s", wf.InSampleBars, wf.OutOfSampleBars, oc.Objective)
+		silence()
+		folds, err := backtest.WalkForward(klines, opts, optOpts, wf)
+		restore()
+		if err != nil {
+			log.Fatalf("Ошибка walk-forward: %v", err)
+		}
+		for i, f := range folds {
+			log.Printf("Окно %d: %s - %s | in-sample %s=%.3f (%d сделок) | out-of-sample %s=%.3f (%d сделок, доходность %.2f%%) | %s",
+				i+1, f.OutStart.Format(time.DateOnly), f.OutEnd.Format(time.DateOnly),
+				oc.Objective, f.InSample.Score, f.InSample.Trades,
+				oc.Objective, f.OutOfSample.Score, f.OutOfSample.Trades, f.OutOfSample.Report.TotalReturn*100,
+				formatParams(f.Params))
+		}
+		writeJSON(prefix+"_walkforward.json", folds)
+		log.Printf("Результаты walk-forward сохранены: %s_walkforward.json", prefix)
+		return
+	}
+
+	log.Printf("Оптимизация: метод %s, цель %s, параметры %d", oc.Method, oc.Objective, len(oc.Params))
+	silence()
+	results, err := backtest.Optimize(klines, opts, optOpts)
+	restore()
+	if err != nil {
+		log.Fatalf("Ошибка оптимизации: %v", err)
+	}
+	for i, r := range results {
+		if i >= oc.Top {
+			break
+		}
+		if r.Err != "" {
+			log.Printf("%2d. ошибка: %s | %s", i+1, r.Err, formatParams(r.Params))
+			continue
+		}
+		log.Printf("%2d. %s=%.3f | сделок %d | доходность %.2f%% | просадка %.2f%% | %s",
+			i+1, oc.Objective, r.Score, r.Trades, r.Report.TotalReturn*100, r.Report.MaxDrawdown*100, formatParams(r.Params))
+	}
+	writeJSON(prefix+"_optimize.json", results)
+	log.Printf("Результаты оптимизации сохранены: %s_optimize.json", prefix)
+}
+
+func formatParams(p backtest.ParamSet) string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%g", name, p[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+func writeJSON(path string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Ошибка сериализации результатов: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Fatalf("Ошибка создания каталога отчётов: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Fatalf("Ошибка сохранения результатов: %v", err)
+	}
+}
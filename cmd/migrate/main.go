@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/repository/migrations"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const usage = `Использование: migrate [-config путь] <команда>
+
+Команды:
+  up          применить все новые миграции
+  down [N]    откатить N последних миграций (по умолчанию 1)
+  status      показать применённые и ожидающие миграции
+`
+
+func main() {
+	configPath := flag.String("config", "", "путь к файлу конфигурации (YAML или TOML)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	if cfg.Database.Driver == config.DatabaseMemory {
+		log.Fatalf("Хранилище %s не требует миграций", cfg.Database.Driver)
+	}
+	dsn, err := cfg.DatabaseDSN()
+	if err != nil {
+		log.Fatalf("Ошибка загрузки секретов: %v", err)
+	}
+	db, err := repository.OpenDB(cfg.Database.Driver, dsn)
+	if err != nil {
+		log.Fatalf("Ошибка подключения к БД: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db, cfg.Database.Driver)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки миграций: %v", err)
+	}
+
+	switch cmd := flag.Arg(0); cmd {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("up   %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Ошибка применения миграций: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Схема актуальна.")
+		}
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps <= 0 {
+				log.Fatalf("Число миграций для отката должно быть положительным, получено %q", flag.Arg(1))
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("down %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Ошибка отката миграций: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Ошибка чтения состояния миграций: %v", err)
+		}
+		for _, s := range statuses {
+			state := "ожидает"
+			if s.AppliedAt != nil {
+				state = "применена " + s.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Printf("%04d_%-24s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -6,9 +6,12 @@ require (
 	github.com/gorilla/websocket v1.5.3
 	github.com/lib/pq v1.10.9
 	github.com/markcheno/go-talib v0.0.0-20250114000313-ec55a20c902f
+	github.com/mattn/go-sqlite3 v1.14.27
 	github.com/mitchellh/mapstructure v1.1.2
+	github.com/pelletier/go-toml/v2 v2.2.3
 	github.com/thrasher-corp/gocryptotrader v0.0.0-20250415050802-3fc40292b758
 	github.com/wuhewuhe/bybit.go.api v1.0.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
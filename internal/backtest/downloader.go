@@ -0,0 +1,348 @@
+package backtest
+
+import (
+	"bybit-bot/internal/model"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultKlineBaseURL = "https://api.bybit.com"
+	maxKlinePageLimit   = 1000
+)
+
+// Gap — пропуск в ряду свечей: бары с From по To включительно отсутствуют.
+type Gap struct {
+	From time.Time
+	To   time.Time
+	Bars int
+}
+
+// Downloader загружает исторические свечи через /v5/market/kline и хранит их
+// в локальном CSV-хранилище: отдельный файл на символ и интервал.
+type Downloader struct {
+	BaseURL   string
+	Dir       string
+	Client    *http.Client
+	PageLimit int
+}
+
+func NewDownloader(baseURL, dir string) *Downloader {
+	if baseURL == "" {
+		baseURL = DefaultKlineBaseURL
+	}
+	return &Downloader{
+		BaseURL:   baseURL,
+		Dir:       dir,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		PageLimit: maxKlinePageLimit,
+	}
+}
+
+// StorePath возвращает путь к файлу хранилища для символа и интервала.
+func (d *Downloader) StorePath(category, symbol, interval string) string {
+	return filepath.Join(d.Dir, fmt.Sprintf("%s_%s_%s.csv", category, symbol, interval))
+}
+
+// Sync дополняет локальное хранилище недостающими свечами диапазона [start, end] и возвращает
+// свечи этого диапазона по возрастанию времени вместе с найденными пропусками.
+// Загружаются только участки до первой и после последней сохранённой свечи,
+// поэтому повторный запуск запрашивает лишь новые данные.
+func (d *Downloader) Sync(ctx context.Context, category, symbol, interval string, start, end time.Time) ([]model.KlineData, []Gap, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+	if !start.Before(end) {
+		return nil, nil, fmt.Errorf("empty range %s - %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	path := d.StorePath(category, symbol, interval)
+	stored, err := readStore(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fetched []model.KlineData
+	if len(stored) == 0 {
+		fetched, err = d.Fetch(ctx, category, symbol, interval, start, end)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		first := time.UnixMilli(stored[0].Timestamp)
+		last := time.UnixMilli(stored[len(stored)-1].Timestamp)
+		if start.Before(first) {
+			page, err := d.Fetch(ctx, category, symbol, interval, start, first.Add(-time.Millisecond))
+			if err != nil {
+				return nil, nil, err
+			}
+			fetched = append(fetched, page...)
+		}
+		if end.After(last.Add(step)) {
+			page, err := d.Fetch(ctx, category, symbol, interval, last.Add(step), end)
+			if err != nil {
+				return nil, nil, err
+			}
+			fetched = append(fetched, page...)
+		}
+	}
+
+	all := stored
+	if len(fetched) > 0 {
+		all = mergeKlines(stored, fetched)
+		if err := writeStore(path, all); err != nil {
+			return nil, nil, err
+		}
+		log.Printf("[Загрузка] %s %s: добавлено %d свечей, всего в хранилище %d", symbol, interval, len(all)-len(stored), len(all))
+	}
+
+	var result []model.KlineData
+	for _, k := range all {
+		t := time.UnixMilli(k.Timestamp)
+		if !t.Before(start) && !t.After(end) {
+			result = append(result, k)
+		}
+	}
+	return result, DetectGaps(result, step), nil
+}
+
+// Fetch загружает закрытые свечи диапазона [start, end], листая /v5/market/kline от конца к началу.
+// Результат отсортирован по возрастанию и не содержит дубликатов.
+func (d *Downloader) Fetch(ctx context.Context, category, symbol, interval string, start, end time.Time) ([]model.KlineData, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	limit := d.PageLimit
+	if limit <= 0 || limit > maxKlinePageLimit {
+		limit = maxKlinePageLimit
+	}
+
+	var all []model.KlineData
+	startMs := start.UnixMilli()
+	endMs := end.UnixMilli()
+	for endMs >= startMs {
+		page, err := d.fetchPage(ctx, category, symbol, interval, startMs, endMs, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		oldest := page[0].Timestamp
+		for _, k := range page {
+			if k.Timestamp < oldest {
+				oldest = k.Timestamp
+			}
+		}
+		all = append(all, page...)
+		// oldest > endMs означает, что сервер проигнорировал end: дальше листать бессмысленно.
+		if oldest <= startMs || len(page) < limit || oldest > endMs {
+			break
+		}
+		endMs = oldest - 1
+	}
+
+	// Последняя свеча ответа может быть ещё не закрыта: в хранилище попадают только закрытые.
+	closedBefore := time.Now().Add(-step).UnixMilli()
+	var closed []model.KlineData
+	for _, k := range all {
+		if k.Timestamp >= startMs && k.Timestamp <= closedBefore {
+			closed = append(closed, k)
+		}
+	}
+	return mergeKlines(nil, closed), nil
+}
+
+type klineResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List [][]string `json:"list"`
+	} `json:"result"`
+}
+
+func (d *Downloader) fetchPage(ctx context.Context, category, symbol, interval string, startMs, endMs int64, limit int) ([]model.KlineData, error) {
+	q := url.Values{}
+	q.Set("category", category)
+	q.Set("symbol", symbol)
+	q.Set("interval", interval)
+	q.Set("start", strconv.FormatInt(startMs, 10))
+	q.Set("end", strconv.FormatInt(endMs, 10))
+	q.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.BaseURL+"/v5/market/kline?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kline request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kline request: HTTP %d", resp.StatusCode)
+	}
+
+	var body klineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode kline response: %w", err)
+	}
+	if body.RetCode != 0 {
+		return nil, fmt.Errorf("kline request: retCode=%d retMsg=%s", body.RetCode, body.RetMsg)
+	}
+
+	klines := make([]model.KlineData, 0, len(body.Result.List))
+	for _, row := range body.Result.List {
+		k, err := parseKlineRow(row)
+		if err != nil {
+			return nil, err
+		}
+		k.Symbol = symbol
+		k.Interval = interval
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
+
+// parseKlineRow разбирает строку [startTime, open, high, low, close, volume, turnover].
+func parseKlineRow(row []string) (model.KlineData, error) {
+	if len(row) < 6 {
+		return model.KlineData{}, fmt.Errorf("kline row has %d fields, want at least 6", len(row))
+	}
+	ts, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return model.KlineData{}, fmt.Errorf("kline start %q: %w", row[0], err)
+	}
+	values := make([]float64, len(row)-1)
+	for i, raw := range row[1:] {
+		if values[i], err = strconv.ParseFloat(raw, 64); err != nil {
+			return model.KlineData{}, fmt.Errorf("kline field %d %q: %w", i+1, raw, err)
+		}
+	}
+	k := model.KlineData{
+		Timestamp: ts,
+		Start:     ts / 1000,
+		Open:      values[0],
+		High:      values[1],
+		Low:       values[2],
+		Close:     values[3],
+		Volume:    values[4],
+		Confirm:   true,
+	}
+	if len(values) > 5 {
+		k.Turnover = values[5]
+	}
+	return k, nil
+}
+
+// mergeKlines объединяет свечи, убирает дубликаты по времени начала (новые данные
+// заменяют старые) и сортирует по возрастанию.
+func mergeKlines(old, fresh []model.KlineData) []model.KlineData {
+	byTime := make(map[int64]model.KlineData, len(old)+len(fresh))
+	for _, k := range old {
+		byTime[k.Timestamp] = k
+	}
+	for _, k := range fresh {
+		byTime[k.Timestamp] = k
+	}
+	merged := make([]model.KlineData, 0, len(byTime))
+	for _, k := range byTime {
+		merged = append(merged, k)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Timestamp < merged[j].Timestamp })
+	return merged
+}
+
+// DetectGaps ищет пропущенные бары в отсортированном ряду свечей с шагом step.
+func DetectGaps(klines []model.KlineData, step time.Duration) []Gap {
+	if step <= 0 {
+		return nil
+	}
+	stepMs := step.Milliseconds()
+	var gaps []Gap
+	for i := 1; i < len(klines); i++ {
+		diff := klines[i].Timestamp - klines[i-1].Timestamp
+		if diff > stepMs {
+			gaps = append(gaps, Gap{
+				From: time.UnixMilli(klines[i-1].Timestamp + stepMs),
+				To:   time.UnixMilli(klines[i].Timestamp - stepMs),
+				Bars: int(diff/stepMs) - 1,
+			})
+		}
+	}
+	return gaps
+}
+
+// IntervalDuration возвращает длительность интервала свечей Bybit.
+// Месячный интервал "M" не имеет фиксированной длины и не поддерживается.
+func IntervalDuration(interval string) (time.Duration, error) {
+	step, ok := model.KlineIntervalDuration(interval)
+	if !ok {
+		return 0, fmt.Errorf("unsupported interval %q", interval)
+	}
+	return step, nil
+}
+
+func readStore(path string) ([]model.KlineData, error) {
+	klines, err := LoadKlinesFromCSV(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read kline store %s: %w", path, err)
+	}
+	return mergeKlines(nil, klines), nil
+}
+
+// writeStore записывает хранилище через временный файл, чтобы прерванная запись не портила данные.
+func writeStore(path string, klines []model.KlineData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"timestamp", "open", "high", "low", "close", "volume", "turnover"})
+	for _, k := range klines {
+		w.Write([]string{
+			strconv.FormatInt(k.Timestamp, 10),
+			strconv.FormatFloat(k.Open, 'f', -1, 64),
+			strconv.FormatFloat(k.High, 'f', -1, 64),
+			strconv.FormatFloat(k.Low, 'f', -1, 64),
+			strconv.FormatFloat(k.Close, 'f', -1, 64),
+			strconv.FormatFloat(k.Volume, 'f', -1, 64),
+			strconv.FormatFloat(k.Turnover, 'f', -1, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
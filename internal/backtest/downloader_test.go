@@ -0,0 +1,226 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testBase = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeKlineAPI — локальная замена /v5/market/kline: отдаёт свечи ряда times
+// от новых к старым, не больше limit за запрос, как Bybit.
+type fakeKlineAPI struct {
+	times   []int64 // начала свечей по возрастанию, мс
+	overlap int     // сколько свечей после end добавить в ответ, чтобы страницы перекрывались
+	retCode int
+
+	mu       sync.Mutex
+	requests [][2]int64 // start и end каждого запроса
+}
+
+func newFakeKlineAPI(t *testing.T, api *fakeKlineAPI) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v5/market/kline" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		api.mu.Lock()
+		api.requests = append(api.requests, [2]int64{start, end})
+		api.mu.Unlock()
+
+		resp := map[string]interface{}{"retCode": api.retCode, "retMsg": "OK"}
+		if api.retCode != 0 {
+			resp["retMsg"] = "params error"
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+		var list [][]string
+		for i := len(api.times) - 1; i >= 0 && len(list) < limit; i-- {
+			ts := api.times[i]
+			if ts < start {
+				break
+			}
+			if ts > end+int64(api.overlap)*time.Minute.Milliseconds() {
+				continue
+			}
+			price := strconv.FormatFloat(100+float64(i), 'f', -1, 64)
+			list = append(list, []string{strconv.FormatInt(ts, 10), price, price, price, price, "1", "100"})
+		}
+		resp["result"] = map[string]interface{}{"list": list}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (api *fakeKlineAPI) requestCount() int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return len(api.requests)
+}
+
+// minuteSeries возвращает n минутных свечей от testBase, пропуская индексы missing.
+func minuteSeries(n int, missing ...int) []int64 {
+	skip := make(map[int]bool, len(missing))
+	for _, i := range missing {
+		skip[i] = true
+	}
+	var times []int64
+	for i := 0; i < n; i++ {
+		if !skip[i] {
+			times = append(times, testBase.Add(time.Duration(i)*time.Minute).UnixMilli())
+		}
+	}
+	return times
+}
+
+func TestFetchPaginatesAcrossPages(t *testing.T) {
+	api := &fakeKlineAPI{times: minuteSeries(2500)}
+	srv := newFakeKlineAPI(t, api)
+	d := NewDownloader(srv.URL, t.TempDir())
+
+	end := testBase.Add(2499 * time.Minute)
+	klines, err := d.Fetch(context.Background(), "linear", "BTCUSDT", "1", testBase, end)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(klines) != 2500 {
+		t.Fatalf("got %d klines, want 2500", len(klines))
+	}
+	for i, k := range klines {
+		if want := api.times[i]; k.Timestamp != want {
+			t.Fatalf("kline %d: timestamp %d, want %d", i, k.Timestamp, want)
+		}
+		if k.Symbol != "BTCUSDT" || k.Interval != "1" || !k.Confirm {
+			t.Fatalf("kline %d: unexpected fields %+v", i, k)
+		}
+	}
+	if n := api.requestCount(); n != 3 {
+		t.Fatalf("made %d requests, want 3 pages of 1000", n)
+	}
+}
+
+func TestFetchDeduplicatesOverlappingPages(t *testing.T) {
+	api := &fakeKlineAPI{times: minuteSeries(250), overlap: 5}
+	srv := newFakeKlineAPI(t, api)
+	d := NewDownloader(srv.URL, t.TempDir())
+	d.PageLimit = 100
+
+	end := testBase.Add(249 * time.Minute)
+	klines, err := d.Fetch(context.Background(), "linear", "BTCUSDT", "1", testBase, end)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(klines) != 250 {
+		t.Fatalf("got %d klines, want 250 without duplicates", len(klines))
+	}
+	for i := 1; i < len(klines); i++ {
+		if klines[i].Timestamp <= klines[i-1].Timestamp {
+			t.Fatalf("klines not strictly ascending at %d: %d after %d", i, klines[i].Timestamp, klines[i-1].Timestamp)
+		}
+	}
+	if n := api.requestCount(); n < 3 {
+		t.Fatalf("made %d requests, want overlapping pagination", n)
+	}
+}
+
+func TestFetchReturnsRetCodeError(t *testing.T) {
+	api := &fakeKlineAPI{times: minuteSeries(10), retCode: 10001}
+	srv := newFakeKlineAPI(t, api)
+	d := NewDownloader(srv.URL, t.TempDir())
+
+	_, err := d.Fetch(context.Background(), "linear", "BTCUSDT", "1", testBase, testBase.Add(9*time.Minute))
+	if err == nil {
+		t.Fatal("Fetch succeeded despite non-zero retCode")
+	}
+	if !strings.Contains(err.Error(), "retCode=10001") || !strings.Contains(err.Error(), "params error") {
+		t.Fatalf("error %q does not describe retCode and retMsg", err)
+	}
+}
+
+func TestDetectGaps(t *testing.T) {
+	api := &fakeKlineAPI{times: minuteSeries(20, 3, 10, 11, 12)}
+	srv := newFakeKlineAPI(t, api)
+	d := NewDownloader(srv.URL, t.TempDir())
+
+	klines, err := d.Fetch(context.Background(), "linear", "BTCUSDT", "1", testBase, testBase.Add(19*time.Minute))
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	gaps := DetectGaps(klines, time.Minute)
+	want := []Gap{
+		{From: testBase.Add(3 * time.Minute), To: testBase.Add(3 * time.Minute), Bars: 1},
+		{From: testBase.Add(10 * time.Minute), To: testBase.Add(12 * time.Minute), Bars: 3},
+	}
+	if len(gaps) != len(want) {
+		t.Fatalf("got %d gaps %+v, want %d", len(gaps), gaps, len(want))
+	}
+	for i, g := range gaps {
+		if !g.From.Equal(want[i].From) || !g.To.Equal(want[i].To) || g.Bars != want[i].Bars {
+			t.Fatalf("gap %d = %+v, want %+v", i, g, want[i])
+		}
+	}
+	if gaps := DetectGaps(klines[:3], time.Minute); len(gaps) != 0 {
+		t.Fatalf("continuous series has gaps %+v", gaps)
+	}
+}
+
+func TestSyncFetchesOnlyMissingTail(t *testing.T) {
+	api := &fakeKlineAPI{times: minuteSeries(300)}
+	srv := newFakeKlineAPI(t, api)
+	d := NewDownloader(srv.URL, t.TempDir())
+	ctx := context.Background()
+
+	first, gaps, err := d.Sync(ctx, "linear", "BTCUSDT", "1", testBase, testBase.Add(199*time.Minute))
+	if err != nil {
+		t.Fatalf("first Sync: %v", err)
+	}
+	if len(first) != 200 || len(gaps) != 0 {
+		t.Fatalf("first Sync: %d klines, %d gaps, want 200 and 0", len(first), len(gaps))
+	}
+	before := api.requestCount()
+
+	all, gaps, err := d.Sync(ctx, "linear", "BTCUSDT", "1", testBase, testBase.Add(299*time.Minute))
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if len(all) != 300 || len(gaps) != 0 {
+		t.Fatalf("second Sync: %d klines, %d gaps, want 300 and 0", len(all), len(gaps))
+	}
+	api.mu.Lock()
+	tail := api.requests[before:]
+	api.mu.Unlock()
+	if len(tail) != 1 {
+		t.Fatalf("second Sync made %d requests, want 1", len(tail))
+	}
+	if wantStart := testBase.Add(200 * time.Minute).UnixMilli(); tail[0][0] != wantStart {
+		t.Fatalf("second Sync requested from %d, want %d (after the last stored kline)", tail[0][0], wantStart)
+	}
+
+	before = api.requestCount()
+	if _, _, err := d.Sync(ctx, "linear", "BTCUSDT", "1", testBase, testBase.Add(299*time.Minute)); err != nil {
+		t.Fatalf("third Sync: %v", err)
+	}
+	if n := api.requestCount() - before; n != 0 {
+		t.Fatalf("Sync of a fully stored range made %d requests", n)
+	}
+
+	stored, err := LoadKlinesFromCSV(d.StorePath("linear", "BTCUSDT", "1"))
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if len(stored) != 300 {
+		t.Fatalf("store has %d klines, want 300", len(stored))
+	}
+}
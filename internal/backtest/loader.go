@@ -3,31 +3,12 @@ package backtest
 import (
 	"bybit-bot/internal/model"
 	"encoding/csv"
-	"encoding/json"
-	"fmt"
-	"net/http"
 	"os"
 	"strconv"
-	_ "strconv"
-	_ "time"
 )
 
-type Candle struct {
-	Timestamp int64
-	Open      string
-	High      string
-	Low       string
-	Close     string
-	Volume    string
-}
-
-type Response struct {
-	RetCode int `json:"retCode"`
-	Result  struct {
-		List [][]interface{} `json:"list"`
-	} `json:"result"`
-}
-
+// LoadKlinesFromCSV читает свечи в формате хранилища Downloader:
+// timestamp (мс), open, high, low, close, volume и необязательный turnover.
 func LoadKlinesFromCSV(filePath string) ([]model.KlineData, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -36,6 +17,7 @@ func LoadKlinesFromCSV(filePath string) ([]model.KlineData, error) {
 	defer f.Close()
 
 	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
 	rows, err := reader.ReadAll()
 	if err != nil {
 		return nil, err
@@ -52,60 +34,23 @@ func LoadKlinesFromCSV(filePath string) ([]model.KlineData, error) {
 		low, _ := strconv.ParseFloat(row[3], 64)
 		closePrice, _ := strconv.ParseFloat(row[4], 64)
 		volume, _ := strconv.ParseFloat(row[5], 64)
+		var turnover float64
+		if len(row) > 6 {
+			turnover, _ = strconv.ParseFloat(row[6], 64)
+		}
 
 		klines = append(klines, model.KlineData{
 			Timestamp: ts,
+			Start:     ts / 1000,
 			Open:      open,
 			High:      high,
 			Low:       low,
 			Close:     closePrice,
 			Volume:    volume,
+			Turnover:  turnover,
+			Confirm:   true,
 		})
 	}
 
 	return klines, nil
 }
-
-func DownLoadKlinesFromBybit() error {
-	symbol := "BTCUSDT"
-	interval := "30" // 30m
-	limit := 1000
-
-	endpoint := fmt.Sprintf("https://api.bybit.com/v5/market/kline?category=linear&symbol=%s&interval=%s&limit=%d", symbol, interval, limit)
-	resp, err := http.Get(endpoint)
-	if err != nil {
-		return fmt.Errorf("ошибка HTTP-запроса: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var result Response
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("ошибка декодирования JSON: %v", err)
-	}
-
-	file, err := os.Create("bybit_klines.csv")
-	if err != nil {
-		return fmt.Errorf("ошибка создания файла: %v", err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	writer.Write([]string{"timestamp", "open", "high", "low", "close", "volume"})
-
-	for _, row := range result.Result.List {
-		timestamp := row[0].(string)
-		open := row[1].(string)
-		high := row[2].(string)
-		low := row[3].(string)
-		close := row[4].(string)
-		volume := row[5].(string)
-
-		writer.Write([]string{timestamp, open, high, low, close, volume})
-	}
-
-	fmt.Println("Сохранено в bybit_klines.csv")
-	fmt.Println("CSV сохранён:", "bybit_klines.csv")
-	return nil
-}
@@ -0,0 +1,324 @@
+package backtest
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/model"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Методы поиска параметров.
+const (
+	SearchGrid   = "grid"
+	SearchRandom = "random"
+)
+
+// Целевые функции оптимизации.
+const (
+	ObjectiveSharpe       = "sharpe"
+	ObjectiveSortino      = "sortino"
+	ObjectiveCalmar       = "calmar"
+	ObjectiveTotalReturn  = "total_return"
+	ObjectiveProfitFactor = "profit_factor"
+	ObjectiveExpectancy   = "expectancy"
+)
+
+// ParamRange — диапазон значений параметра стратегии. Имя — путь ключа
+// в конфигурации стратегии, например "signal.ema_fast" или "sltp.stop_loss_percent".
+type ParamRange = config.ParamRange
+
+// rangeValues возвращает значения сетки от Min до Max с шагом Step.
+func rangeValues(r ParamRange) []float64 {
+	if r.Step <= 0 || r.Max <= r.Min {
+		return []float64{r.Min}
+	}
+	var out []float64
+	for i := 0; ; i++ {
+		v := math.Round((r.Min+float64(i)*r.Step)*1e9) / 1e9 // убирает ошибку накопления 0.1+0.2
+		if v > r.Max+r.Step*1e-9 {
+			break
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// ParamSet — значения параметров одного прогона.
+type ParamSet map[string]float64
+
+// paramSetters связывает имена параметров с полями конфигурации стратегии.
+var paramSetters = map[string]func(*config.StrategyConfig, float64){
+	"signal.ema_fast":             func(c *config.StrategyConfig, v float64) { c.Signal.EMAFast = int(math.Round(v)) },
+	"signal.ema_slow":             func(c *config.StrategyConfig, v float64) { c.Signal.EMASlow = int(math.Round(v)) },
+	"signal.rsi_period":           func(c *config.StrategyConfig, v float64) { c.Signal.RSIPeriod = int(math.Round(v)) },
+	"signal.pullback_percent":     func(c *config.StrategyConfig, v float64) { c.Signal.PullbackPercent = v },
+	"signal.volume_window":        func(c *config.StrategyConfig, v float64) { c.Signal.VolumeWindow = int(math.Round(v)) },
+	"signal.volume_spike_factor":  func(c *config.StrategyConfig, v float64) { c.Signal.VolumeSpikeFactor = v },
+	"signal.long_lookback":        func(c *config.StrategyConfig, v float64) { c.Signal.LongLookback = int(math.Round(v)) },
+	"signal.short_lookback":       func(c *config.StrategyConfig, v float64) { c.Signal.ShortLookback = int(math.Round(v)) },
+	"signal.atr_period":           func(c *config.StrategyConfig, v float64) { c.Signal.ATRPeriod = int(math.Round(v)) },
+	"signal.min_atr":              func(c *config.StrategyConfig, v float64) { c.Signal.MinATR = v },
+	"signal.sma_fast":             func(c *config.StrategyConfig, v float64) { c.Signal.SMAFast = int(math.Round(v)) },
+	"signal.sma_slow":             func(c *config.StrategyConfig, v float64) { c.Signal.SMASlow = int(math.Round(v)) },
+	"sltp.stop_loss_percent":      func(c *config.StrategyConfig, v float64) { c.SLTP.StopLossPercent = v },
+	"sltp.take_profit_percent":    func(c *config.StrategyConfig, v float64) { c.SLTP.TakeProfitPercent = v },
+	"sltp.risk_reward":            func(c *config.StrategyConfig, v float64) { c.SLTP.RiskReward = v },
+	"sltp.atr_period":             func(c *config.StrategyConfig, v float64) { c.SLTP.ATRPeriod = int(math.Round(v)) },
+	"sltp.atr_multiplier":         func(c *config.StrategyConfig, v float64) { c.SLTP.ATRMultiplier = v },
+	"sltp.min_sl_percent":         func(c *config.StrategyConfig, v float64) { c.SLTP.MinSLPercent = v },
+	"sltp.max_sl_percent":         func(c *config.StrategyConfig, v float64) { c.SLTP.MaxSLPercent = v },
+	"sltp.swing_lookback":         func(c *config.StrategyConfig, v float64) { c.SLTP.SwingLookback = int(math.Round(v)) },
+	"sltp.swing_buffer":           func(c *config.StrategyConfig, v float64) { c.SLTP.SwingBuffer = v },
+	"manage.break_even_r":         func(c *config.StrategyConfig, v float64) { c.Manage.BreakEvenR = v },
+	"manage.trail_activation_r":   func(c *config.StrategyConfig, v float64) { c.Manage.TrailActivationR = v },
+	"manage.trail_atr_multiplier": func(c *config.StrategyConfig, v float64) { c.Manage.TrailATRMultiplier = v },
+	"manage.trail_percent":        func(c *config.StrategyConfig, v float64) { c.Manage.TrailPercent = v },
+}
+
+// OptimizableParams возвращает имена параметров, доступных оптимизатору.
+func OptimizableParams() []string {
+	names := make([]string, 0, len(paramSetters))
+	for name := range paramSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply возвращает копию конфигурации стратегии с подставленными параметрами.
+func (p ParamSet) Apply(base config.StrategyConfig) config.StrategyConfig {
+	for name, v := range p {
+		paramSetters[name](&base, v)
+	}
+	return base
+}
+
+// OptimizeOptions — настройки оптимизации.
+type OptimizeOptions struct {
+	Strategy  string
+	Method    string // SearchGrid или SearchRandom
+	Objective string
+	Samples   int // число случайных наборов для SearchRandom
+	Workers   int // 0 — runtime.NumCPU()
+	MinTrades int // прогоны с меньшим числом сделок ранжируются последними
+	Seed      int64
+	Ranges    []ParamRange
+}
+
+// OptimizeResult — результат прогона одного набора параметров.
+type OptimizeResult struct {
+	Params ParamSet `json:"params"`
+	Score  float64  `json:"score"`
+	Trades int      `json:"trades"`
+	Report Report   `json:"report"`
+	Err    string   `json:"error,omitempty"`
+}
+
+// Optimize прогоняет стратегию для каждого набора параметров параллельно
+// и возвращает результаты, отсортированные по убыванию целевой функции.
+func Optimize(klines []model.KlineData, base Options, opts OptimizeOptions) ([]OptimizeResult, error) {
+	sets, err := paramSets(opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := objectiveValue(opts.Objective, Report{}); err != nil {
+		return nil, err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]OptimizeResult, len(sets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = evaluate(klines, base, opts, sets[i])
+			}
+		}()
+	}
+	for i := range sets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	rank(results, opts.MinTrades)
+	return results, nil
+}
+
+func evaluate(klines []model.KlineData, base Options, opts OptimizeOptions, params ParamSet) OptimizeResult {
+	run := base
+	run.Strategy = params.Apply(base.Strategy)
+	run.Signals = nil // журнал сигналов ведётся только для одиночного прогона
+	res := OptimizeResult{Params: params, Score: -math.MaxFloat64}
+
+	result, err := RunStrategy(opts.Strategy, klines, run)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	res.Trades = result.NumTrades
+	res.Score, _ = objectiveValue(opts.Objective, result.Report)
+	res.Report = jsonReport(result.Report)
+	res.Report.Equity = nil
+	return res
+}
+
+// rank сортирует результаты: сначала прогоны с достаточным числом сделок, затем по Score.
+func rank(results []OptimizeResult, minTrades int) {
+	sort.SliceStable(results, func(i, j int) bool {
+		ai, aj := results[i].Trades >= minTrades, results[j].Trades >= minTrades
+		if ai != aj {
+			return ai
+		}
+		return results[i].Score > results[j].Score
+	})
+}
+
+func objectiveValue(objective string, r Report) (float64, error) {
+	var v float64
+	switch objective {
+	case ObjectiveSharpe:
+		v = r.Sharpe
+	case ObjectiveSortino:
+		v = r.Sortino
+	case ObjectiveCalmar:
+		v = r.Calmar
+	case ObjectiveTotalReturn:
+		v = r.TotalReturn
+	case ObjectiveProfitFactor:
+		v = r.ProfitFactor
+	case ObjectiveExpectancy:
+		v = r.Expectancy
+	default:
+		return 0, fmt.Errorf("unknown objective %q", objective)
+	}
+	// Бесконечности (profit factor без убыточных сделок) не сериализуются в JSON.
+	switch {
+	case math.IsNaN(v), math.IsInf(v, -1):
+		v = -math.MaxFloat64
+	case math.IsInf(v, 1):
+		v = math.MaxFloat64
+	}
+	return v, nil
+}
+
+func paramSets(opts OptimizeOptions) ([]ParamSet, error) {
+	if len(opts.Ranges) == 0 {
+		return nil, fmt.Errorf("no parameter ranges")
+	}
+	for _, r := range opts.Ranges {
+		if _, ok := paramSetters[r.Name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", r.Name)
+		}
+	}
+
+	switch opts.Method {
+	case SearchGrid:
+		sets := []ParamSet{{}}
+		for _, r := range opts.Ranges {
+			var next []ParamSet
+			for _, set := range sets {
+				for _, v := range rangeValues(r) {
+					cp := make(ParamSet, len(set)+1)
+					for k, val := range set {
+						cp[k] = val
+					}
+					cp[r.Name] = v
+					next = append(next, cp)
+				}
+			}
+			sets = next
+		}
+		return sets, nil
+	case SearchRandom:
+		if opts.Samples <= 0 {
+			return nil, fmt.Errorf("random search requires positive samples")
+		}
+		rnd := rand.New(rand.NewSource(opts.Seed))
+		sets := make([]ParamSet, opts.Samples)
+		for i := range sets {
+			set := make(ParamSet, len(opts.Ranges))
+			for _, r := range opts.Ranges {
+				values := rangeValues(r)
+				set[r.Name] = values[rnd.Intn(len(values))]
+			}
+			sets[i] = set
+		}
+		return sets, nil
+	}
+	return nil, fmt.Errorf("unknown search method %q", opts.Method)
+}
+
+// WalkForwardOptions задаёт окна walk-forward анализа в свечах.
+type WalkForwardOptions struct {
+	InSampleBars    int
+	OutOfSampleBars int
+	WarmupBars      int // история перед окном out-of-sample для прогрева индикаторов
+}
+
+// WalkForwardFold — результат одного окна: параметры, лучшие на in-sample,
+// и их результат на следующем за ним out-of-sample участке.
+type WalkForwardFold struct {
+	InStart     time.Time      `json:"in_start"`
+	InEnd       time.Time      `json:"in_end"`
+	OutStart    time.Time      `json:"out_start"`
+	OutEnd      time.Time      `json:"out_end"`
+	Params      ParamSet       `json:"params"`
+	InSample    OptimizeResult `json:"in_sample"`
+	OutOfSample OptimizeResult `json:"out_of_sample"`
+}
+
+// WalkForward сдвигает окно in-sample/out-of-sample на длину out-of-sample:
+// на каждом шаге параметры подбираются на in-sample и проверяются на out-of-sample.
+func WalkForward(klines []model.KlineData, base Options, opts OptimizeOptions, wf WalkForwardOptions) ([]WalkForwardFold, error) {
+	if wf.InSampleBars <= 0 || wf.OutOfSampleBars <= 0 {
+		return nil, fmt.Errorf("walk-forward windows must be positive")
+	}
+	if wf.WarmupBars > wf.InSampleBars {
+		return nil, fmt.Errorf("warm-up (%d) must not exceed in-sample window (%d)", wf.WarmupBars, wf.InSampleBars)
+	}
+	sorted := mergeKlines(nil, klines)
+
+	var folds []WalkForwardFold
+	for start := 0; start+wf.InSampleBars+wf.OutOfSampleBars <= len(sorted); start += wf.OutOfSampleBars {
+		inSample := sorted[start : start+wf.InSampleBars]
+		outFrom := start + wf.InSampleBars
+		outTo := outFrom + wf.OutOfSampleBars
+
+		results, err := Optimize(inSample, base, opts)
+		if err != nil {
+			return nil, err
+		}
+		best := results[0]
+
+		oosOpts := base
+		oosOpts.WarmupBars = wf.WarmupBars
+		oos := evaluate(sorted[outFrom-wf.WarmupBars:outTo], oosOpts, opts, best.Params)
+
+		folds = append(folds, WalkForwardFold{
+			InStart:     time.UnixMilli(inSample[0].Timestamp),
+			InEnd:       time.UnixMilli(inSample[len(inSample)-1].Timestamp),
+			OutStart:    time.UnixMilli(sorted[outFrom].Timestamp),
+			OutEnd:      time.UnixMilli(sorted[outTo-1].Timestamp),
+			Params:      best.Params,
+			InSample:    best,
+			OutOfSample: oos,
+		})
+	}
+	if len(folds) == 0 {
+		return nil, fmt.Errorf("not enough data for walk-forward: %d bars, need %d", len(sorted), wf.InSampleBars+wf.OutOfSampleBars)
+	}
+	return folds, nil
+}
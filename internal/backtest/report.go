@@ -0,0 +1,329 @@
+package backtest
+
+import (
+	"bybit-bot/internal/engine"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const hoursPerYear = 365 * 24
+
+// EquityPoint — точка кривой капитала.
+type EquityPoint struct {
+	Time       time.Time `json:"time"`
+	Equity     float64   `json:"equity"`
+	Drawdown   float64   `json:"drawdown"` // доля от предыдущего максимума
+	InPosition bool      `json:"in_position"`
+}
+
+// SideStats — статистика сделок одного направления.
+type SideStats struct {
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	WinRate      float64 `json:"win_rate"` // %
+	PnL          float64 `json:"pnl"`
+	ProfitFactor float64 `json:"profit_factor"`
+	Expectancy   float64 `json:"expectancy"` // средний чистый PnL сделки, USDT
+}
+
+// MonthlyReturn — доходность календарного месяца (UTC).
+type MonthlyReturn struct {
+	Month  string  `json:"month"` // 2006-01
+	Return float64 `json:"return"`
+	PnL    float64 `json:"pnl"`
+}
+
+// Report — показатели бэктеста. Доходности — доли, не проценты, кроме WinRate.
+type Report struct {
+	InitialCapital float64 `json:"initial_capital"`
+	FinalEquity    float64 `json:"final_equity"`
+	TotalReturn    float64 `json:"total_return"`
+	CAGR           float64 `json:"cagr"`
+
+	MaxDrawdown              float64 `json:"max_drawdown"`
+	MaxDrawdownDurationHours float64 `json:"max_drawdown_duration_hours"`
+
+	Sharpe  float64 `json:"sharpe"`
+	Sortino float64 `json:"sortino"`
+	Calmar  float64 `json:"calmar"`
+
+	ProfitFactor  float64 `json:"profit_factor"`
+	Expectancy    float64 `json:"expectancy"`
+	AvgHoldHours  float64 `json:"avg_hold_hours"`
+	Exposure      float64 `json:"exposure"` // доля времени в позиции
+	TotalFees     float64 `json:"total_fees"`
+	TotalSlippage float64 `json:"total_slippage"`
+
+	All   SideStats `json:"all"`
+	Long  SideStats `json:"long"`
+	Short SideStats `json:"short"`
+
+	Monthly []MonthlyReturn `json:"monthly"`
+	Equity  []EquityPoint   `json:"-"`
+}
+
+// NewReport строит отчёт по сделкам и кривой капитала симуляции.
+// Sharpe и Sortino рассчитываются по доходностям между соседними точками кривой
+// и приводятся к году по среднему шагу кривой.
+func NewReport(trades []Trade, samples []engine.EquitySample, initial float64) Report {
+	r := Report{InitialCapital: initial, FinalEquity: initial}
+	r.Equity = equityCurve(samples, initial)
+
+	if n := len(r.Equity); n > 0 {
+		r.FinalEquity = r.Equity[n-1].Equity
+	}
+	if initial > 0 {
+		r.TotalReturn = r.FinalEquity/initial - 1
+	}
+
+	var long, short []Trade
+	var hold time.Duration
+	for _, t := range trades {
+		r.TotalFees += t.Fees
+		r.TotalSlippage += t.Slippage
+		hold += time.Unix(t.ExitTime.End, 0).Sub(time.Unix(t.EntryTime.Start, 0))
+		if t.Side == "short" {
+			short = append(short, t)
+		} else {
+			long = append(long, t)
+		}
+	}
+	r.All = sideStats(trades)
+	r.Long = sideStats(long)
+	r.Short = sideStats(short)
+	r.ProfitFactor = r.All.ProfitFactor
+	r.Expectancy = r.All.Expectancy
+	if len(trades) > 0 {
+		r.AvgHoldHours = hold.Hours() / float64(len(trades))
+	}
+
+	if len(r.Equity) < 2 {
+		return r
+	}
+	first, last := r.Equity[0].Time, r.Equity[len(r.Equity)-1].Time
+	span := last.Sub(first)
+
+	var exposed int
+	peakTime := first
+	peak := initial
+	for _, p := range r.Equity {
+		if p.InPosition {
+			exposed++
+		}
+		if p.Equity >= peak {
+			peak = p.Equity
+			peakTime = p.Time
+		} else if d := p.Time.Sub(peakTime); d.Hours() > r.MaxDrawdownDurationHours {
+			r.MaxDrawdownDurationHours = d.Hours()
+		}
+		if p.Drawdown > r.MaxDrawdown {
+			r.MaxDrawdown = p.Drawdown
+		}
+	}
+	r.Exposure = float64(exposed) / float64(len(r.Equity))
+
+	years := span.Hours() / hoursPerYear
+	if years > 0 && r.FinalEquity > 0 && initial > 0 {
+		r.CAGR = math.Pow(r.FinalEquity/initial, 1/years) - 1
+	}
+	if r.MaxDrawdown > 0 {
+		r.Calmar = r.CAGR / r.MaxDrawdown
+	}
+
+	returns := make([]float64, 0, len(r.Equity)-1)
+	for i := 1; i < len(r.Equity); i++ {
+		if prev := r.Equity[i-1].Equity; prev > 0 {
+			returns = append(returns, r.Equity[i].Equity/prev-1)
+		}
+	}
+	periodsPerYear := float64(len(r.Equity)-1) / years
+	r.Sharpe, r.Sortino = ratios(returns, periodsPerYear)
+	r.Monthly = monthlyReturns(r.Equity, initial)
+	return r
+}
+
+func equityCurve(samples []engine.EquitySample, initial float64) []EquityPoint {
+	curve := make([]EquityPoint, len(samples))
+	peak := initial
+	for i, s := range samples {
+		peak = math.Max(peak, s.Equity)
+		var dd float64
+		if peak > 0 {
+			dd = (peak - s.Equity) / peak
+		}
+		curve[i] = EquityPoint{Time: s.Time, Equity: s.Equity, Drawdown: dd, InPosition: s.InPosition}
+	}
+	return curve
+}
+
+func sideStats(trades []Trade) SideStats {
+	st := SideStats{Trades: len(trades)}
+	var grossProfit, grossLoss float64
+	for _, t := range trades {
+		st.PnL += t.PnL
+		if t.PnL > 0 {
+			st.Wins++
+			grossProfit += t.PnL
+		} else {
+			st.Losses++
+			grossLoss -= t.PnL
+		}
+	}
+	if st.Trades > 0 {
+		st.WinRate = float64(st.Wins) / float64(st.Trades) * 100
+		st.Expectancy = st.PnL / float64(st.Trades)
+	}
+	switch {
+	case grossLoss > 0:
+		st.ProfitFactor = grossProfit / grossLoss
+	case grossProfit > 0:
+		st.ProfitFactor = math.Inf(1)
+	}
+	return st
+}
+
+// ratios возвращает годовые Sharpe и Sortino при нулевой безрисковой ставке.
+func ratios(returns []float64, periodsPerYear float64) (sharpe, sortino float64) {
+	if len(returns) < 2 || periodsPerYear <= 0 || math.IsInf(periodsPerYear, 0) {
+		return 0, 0
+	}
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	downDev := math.Sqrt(downside / float64(len(returns)))
+
+	annual := math.Sqrt(periodsPerYear)
+	if std > 0 {
+		sharpe = mean / std * annual
+	}
+	if downDev > 0 {
+		sortino = mean / downDev * annual
+	}
+	return sharpe, sortino
+}
+
+func monthlyReturns(curve []EquityPoint, initial float64) []MonthlyReturn {
+	var months []MonthlyReturn
+	start := initial
+	for i, p := range curve {
+		month := p.Time.UTC().Format("2006-01")
+		if i+1 < len(curve) && curve[i+1].Time.UTC().Format("2006-01") == month {
+			continue
+		}
+		m := MonthlyReturn{Month: month, PnL: p.Equity - start}
+		if start > 0 {
+			m.Return = p.Equity/start - 1
+		}
+		months = append(months, m)
+		start = p.Equity
+	}
+	return months
+}
+
+// WriteJSON сохраняет отчёт (без точек кривой капитала) в JSON.
+func (r Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(jsonReport(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// jsonReport заменяет бесконечный profit factor (нет убыточных сделок) на 0,
+// так как JSON не поддерживает Inf.
+func jsonReport(r Report) Report {
+	fix := func(v *float64) {
+		if math.IsInf(*v, 0) || math.IsNaN(*v) {
+			*v = 0
+		}
+	}
+	fix(&r.ProfitFactor)
+	fix(&r.All.ProfitFactor)
+	fix(&r.Long.ProfitFactor)
+	fix(&r.Short.ProfitFactor)
+	return r
+}
+
+// WriteEquityCSV сохраняет кривую капитала в CSV.
+func (r Report) WriteEquityCSV(path string) error {
+	rows := [][]string{{"time", "equity", "drawdown", "in_position"}}
+	for _, p := range r.Equity {
+		rows = append(rows, []string{
+			p.Time.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(p.Equity, 'f', 4, 64),
+			strconv.FormatFloat(p.Drawdown, 'f', 6, 64),
+			strconv.FormatBool(p.InPosition),
+		})
+	}
+	return writeCSV(path, rows)
+}
+
+// WriteMonthlyCSV сохраняет помесячные доходности в CSV.
+func (r Report) WriteMonthlyCSV(path string) error {
+	rows := [][]string{{"month", "return", "pnl"}}
+	for _, m := range r.Monthly {
+		rows = append(rows, []string{
+			m.Month,
+			strconv.FormatFloat(m.Return, 'f', 6, 64),
+			strconv.FormatFloat(m.PnL, 'f', 4, 64),
+		})
+	}
+	return writeCSV(path, rows)
+}
+
+// WriteTradesCSV сохраняет сделки в CSV.
+func WriteTradesCSV(path string, trades []Trade) error {
+	rows := [][]string{{"side", "entry_time", "exit_time", "entry_price", "exit_price", "qty", "result", "fees", "slippage", "pnl", "profit"}}
+	for _, t := range trades {
+		rows = append(rows, []string{
+			t.Side,
+			time.Unix(t.EntryTime.Start, 0).UTC().Format(time.RFC3339),
+			time.Unix(t.ExitTime.End, 0).UTC().Format(time.RFC3339),
+			strconv.FormatFloat(t.EntryPrice, 'f', -1, 64),
+			strconv.FormatFloat(t.ExitPrice, 'f', -1, 64),
+			strconv.FormatFloat(t.Qty, 'f', -1, 64),
+			t.Result,
+			strconv.FormatFloat(t.Fees, 'f', 6, 64),
+			strconv.FormatFloat(t.Slippage, 'f', 6, 64),
+			strconv.FormatFloat(t.PnL, 'f', 6, 64),
+			strconv.FormatFloat(t.Profit, 'f', 6, 64),
+		})
+	}
+	return writeCSV(path, rows)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
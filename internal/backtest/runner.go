@@ -1,133 +1,198 @@
 package backtest
 
 import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/engine"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/service/exchange"
+	"bybit-bot/internal/service/strategy"
+	"bybit-bot/internal/utils"
+	"context"
 )
 
 type Trade struct {
 	Side       string
 	EntryTime  model.KlineData
 	ExitTime   model.KlineData
-	EntryPrice float64
+	EntryPrice float64 // фактические цены с учётом проскальзывания
 	ExitPrice  float64
+	Qty        float64
 	Result     string
-	Profit     float64
+	Fees       float64 // комиссии входа и выхода, USDT
+	Slippage   float64 // стоимость проскальзывания, USDT
+	PnL        float64 // чистый результат, USDT
+	Profit     float64 // чистая доходность относительно стоимости входа
 }
 
+// BacktestResult — итог бэктеста. Сделки классифицируются по знаку чистого PnL,
+// поэтому закрытые в конце данных (Result "none") тоже попадают в победы или поражения.
 type BacktestResult struct {
-	Trades    []Trade
-	TotalPnL  float64
-	WinRate   float64
-	NumWins   int
-	NumLosses int
-	NumTrades int
+	Trades      []Trade
+	TotalPnL    float64 // чистый PnL, USDT
+	WinRate     float64
+	NumWins     int
+	NumLosses   int
+	NumTrades   int
+	NumUnclosed int // позиции, закрытые принудительно в конце данных
+	Report      Report
 }
 
-func RunBacktest(
+// Options — параметры симуляции.
+type Options struct {
+	Symbol             string
+	Category           string
+	Interval           string
+	InitialBalance     float64
+	MaxPositionPercent float64
+	Sizing             config.SizingConfig
+	Strategy           config.StrategyConfig
+
+	WarmupBars     int               // свечи прогрева в начале klines, на которых торговля запрещена
+	Execution      *engine.FillModel // nil — engine.DefaultFillModel
+	LowerInterval  string
+	LowerTimeframe []model.KlineData // свечи для engine.IntrabarLowerTimeframe
+
+	Signals strategy.SignalJournal // необязателен: журнал проверок сигналов стратегии
+}
+
+// FillModelFromConfig переводит настройки исполнения из конфигурации в engine.FillModel.
+func FillModelFromConfig(c config.ExecutionConfig) engine.FillModel {
+	return engine.FillModel{
+		MakerFee:        c.MakerFee,
+		TakerFee:        c.TakerFee,
+		Slippage:        engine.SlippageMode(c.Slippage),
+		SlippageRate:    c.SlippageRate,
+		FillProbability: c.FillProbability,
+		TradeThrough:    c.TradeThrough,
+		LatencyBars:     c.LatencyBars,
+		Intrabar:        engine.IntrabarPolicy(c.Intrabar),
+		Seed:            c.Seed,
+	}
+}
+
+func newSimExchange(klines []model.KlineData, opts Options) *engine.SimExchange {
+	sim := engine.NewSimExchange(opts.Symbol, opts.Interval, klines, opts.InitialBalance)
+	sim.WarmupBars = opts.WarmupBars
+	sim.Manage = opts.Strategy.Manage
+	if opts.Execution != nil {
+		sim.Model = *opts.Execution
+	}
+	if len(opts.LowerTimeframe) > 0 {
+		sim.SetLowerTimeframe(opts.LowerInterval, opts.LowerTimeframe)
+	}
+	return sim
+}
+
+// RunStrategy прогоняет зарегистрированную стратегию по историческим свечам через тот же
+// engine.Engine, что и реальная торговля; биржу заменяет engine.SimExchange.
+func RunStrategy(name string, klines []model.KlineData, opts Options) (BacktestResult, error) {
+	sim := newSimExchange(klines, opts)
+	s, err := strategy.New(name, simDependencies(sim, opts))
+	if err != nil {
+		return BacktestResult{}, err
+	}
+	return simulate(sim, s, opts)
+}
+
+// RunSignals проверяет пару функций сигналов: на каждой закрытой свече они получают
+// последние 120 свечей, а вход выполняется лимитным ордером по цене закрытия с параметрами
+// симуляции opts. Уровни SL/TP рассчитываются по политике opts.Strategy.SLTP, как в реальной торговле.
+func RunSignals(
 	klines []model.KlineData,
+	opts Options,
 	longSignal func([]model.KlineData) bool,
 	shortSignal func([]model.KlineData) bool,
-) BacktestResult {
-	const lookback = 120
+) (BacktestResult, error) {
+	sim := newSimExchange(klines, opts)
+	deps := simDependencies(sim, opts)
+	atrPeriod := opts.Strategy.Signal.ATRPeriod
+	if atrPeriod <= 0 {
+		atrPeriod = model.DefaultSignalParams().ATRPeriod
+	}
+	s := &signalStrategy{
+		lookback:    120,
+		longSignal:  longSignal,
+		shortSignal: shortSignal,
+		sltp:        opts.Strategy.SLTP,
+		atrPeriod:   atrPeriod,
+		trading:     deps.Trading,
+		orders:      deps.Orders,
+	}
+	return simulate(sim, s, opts)
+}
 
-	var trades []Trade
-	for i := lookback; i < len(klines)-1; i++ {
-		window := klines[i-lookback : i]
-		current := klines[i]
-
-		var side string
-		if longSignal(window) {
-			side = "long"
-		} else if shortSignal(window) {
-			side = "short"
-		} else {
-			continue
-		}
+func simDependencies(sim *engine.SimExchange, opts Options) strategy.Dependencies {
+	router := &engine.OrderRouter{
+		Limits:    sim,
+		Formatter: &utils.Formatter{},
+		Sizer: &exchange.PriceCalculator{
+			Balance:            sim,
+			MaxPositionPercent: opts.MaxPositionPercent,
+			Sizing:             opts.Sizing,
+		},
+		Balance:  sim,
+		Executor: sim,
+	}
+	return strategy.Dependencies{
+		MarketData: sim,
+		Orderbook:  sim,
+		Trading:    sim,
+		Orders:     router,
+		Params:     opts.Strategy,
+		Interval:   opts.Interval,
+
+		Signals:      opts.Signals,
+		SignalSource: model.SignalSourceBacktest,
+	}
+}
 
-		entryPrice := current.Close
-		sl, tp := exchange.CalculateSLTP(side, entryPrice, window)
-
-		exitPrice := entryPrice
-		exitIndex := i
-		result := "none"
-
-		for j := i + 1; j < len(klines); j++ {
-			c := klines[j]
-
-			if side == "long" {
-				if c.Low <= sl {
-					exitPrice = sl
-					result = "sl"
-					exitIndex = j
-					break
-				}
-				if c.High >= tp {
-					exitPrice = tp
-					result = "tp"
-					exitIndex = j
-					break
-				}
-			} else {
-				if c.High >= sl {
-					exitPrice = sl
-					result = "sl"
-					exitIndex = j
-					break
-				}
-				if c.Low <= tp {
-					exitPrice = tp
-					result = "tp"
-					exitIndex = j
-					break
-				}
-			}
-		}
+func simulate(sim *engine.SimExchange, s strategy.Strategy, opts Options) (BacktestResult, error) {
+	eng := engine.New(s, opts.Symbol, opts.Category, sim)
+	if err := eng.Run(context.Background()); err != nil {
+		return BacktestResult{}, err
+	}
+	if err := sim.Err(); err != nil {
+		return BacktestResult{}, err
+	}
+	sim.Finish()
 
+	var trades []Trade
+	for _, t := range sim.Trades {
 		var profit float64
-		if side == "long" {
-			profit = (exitPrice - entryPrice) / entryPrice
-		} else {
-			profit = (entryPrice - exitPrice) / entryPrice
+		if cost := t.EntryPrice * t.Qty; cost > 0 {
+			profit = t.PnL / cost
 		}
-
 		trades = append(trades, Trade{
-			Side:       side,
-			EntryTime:  current,
-			ExitTime:   klines[exitIndex],
-			EntryPrice: entryPrice,
-			ExitPrice:  exitPrice,
-			Result:     result,
+			Side:       t.Side,
+			EntryTime:  t.Entry,
+			ExitTime:   t.Exit,
+			EntryPrice: t.EntryPrice,
+			ExitPrice:  t.ExitPrice,
+			Qty:        t.Qty,
+			Result:     t.Result,
+			Fees:       t.EntryFee + t.ExitFee,
+			Slippage:   t.Slippage,
+			PnL:        t.PnL,
 			Profit:     profit,
 		})
-
-		i = exitIndex
 	}
 
-	var totalPnL float64
-	var wins, losses int
-
+	report := NewReport(trades, sim.Equity, sim.Initial)
+	var unclosed int
 	for _, t := range trades {
-		totalPnL += t.Profit
-		if t.Result == "tp" {
-			wins++
-		} else if t.Result == "sl" {
-			losses++
+		if t.Result == "none" {
+			unclosed++
 		}
 	}
 
-	winRate := 0.0
-	totalTrades := len(trades)
-	if totalTrades > 0 {
-		winRate = float64(wins) / float64(totalTrades) * 100
-	}
-
 	return BacktestResult{
-		Trades:    trades,
-		TotalPnL:  totalPnL,
-		WinRate:   winRate,
-		NumWins:   wins,
-		NumLosses: losses,
-		NumTrades: totalTrades,
-	}
+		Trades:      trades,
+		TotalPnL:    report.All.PnL,
+		WinRate:     report.All.WinRate,
+		NumWins:     report.All.Wins,
+		NumLosses:   report.All.Losses,
+		NumTrades:   report.All.Trades,
+		NumUnclosed: unclosed,
+		Report:      report,
+	}, nil
 }
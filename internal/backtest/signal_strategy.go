@@ -0,0 +1,82 @@
+package backtest
+
+import (
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/service/exchange"
+	"log"
+	"time"
+)
+
+// signalStrategy превращает пару функций сигналов в strategy.Strategy:
+// копит закрытые свечи из событий и при отсутствии открытых ордеров проверяет сигналы.
+type signalStrategy struct {
+	lookback    int
+	longSignal  func([]model.KlineData) bool
+	shortSignal func([]model.KlineData) bool
+	sltp        model.SLTPParams // политика SL/TP (strategy.sltp)
+	atrPeriod   int              // период ATR для расчёта объёма (strategy.signal.atr_period)
+	trading     interfaces.Executor
+	orders      interfaces.IntentHandler
+
+	symbol   string
+	category string
+	window   []model.KlineData
+}
+
+func (s *signalStrategy) Name() string {
+	return "signals"
+}
+
+func (s *signalStrategy) Init(symbol, category string) error {
+	s.symbol = symbol
+	s.category = category
+	return nil
+}
+
+func (s *signalStrategy) OnKline(kline model.KlineData) {
+	s.window = append(s.window, kline)
+	if len(s.window) > s.lookback {
+		s.window = s.window[len(s.window)-s.lookback:]
+	}
+}
+
+func (s *signalStrategy) OnOrderbook(*model.OrderbookData) {}
+
+func (s *signalStrategy) OnFill(model.ExecutionUpdate) {}
+
+func (s *signalStrategy) OnTick(now time.Time) {
+	if len(s.window) < s.lookback {
+		return
+	}
+	if open, err := s.trading.CountOpenOrders(s.category, s.symbol); err != nil || open > 0 {
+		return
+	}
+
+	var side string
+	if s.longSignal(s.window) {
+		side = "long"
+	} else if s.shortSignal(s.window) {
+		side = "short"
+	} else {
+		return
+	}
+
+	entryPrice := s.window[len(s.window)-1].Close
+	levels := exchange.PlanSLTP(side, entryPrice, s.window, s.sltp)
+	_, err := s.orders.Submit(model.OrderIntent{
+		Strategy:   s.Name(),
+		Symbol:     s.symbol,
+		Category:   s.category,
+		Side:       side,
+		EntryPrice: entryPrice,
+		StopLoss:   levels.StopLoss,
+		TakeProfit: levels.TakeProfit,
+		ATR:        exchange.LastATR(s.window, s.atrPeriod),
+		Reason:     levels.Rationale,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		log.Printf("[Бэктест] Ордер не размещён: %v", err)
+	}
+}
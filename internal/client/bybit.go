@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bybit-bot/internal/interfaces"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/service/exchange"
 	"context"
@@ -14,6 +15,7 @@ import (
 	"github.com/thrasher-corp/gocryptotrader/exchanges/order"
 	"github.com/thrasher-corp/gocryptotrader/types"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,23 +31,37 @@ type cachedTradeLimits struct {
 	timestamp time.Time
 }
 
+// Методы с суффиксом Context принимают ctx вызывающего; методы без него используют
+// context.Background(). Каждая попытка запроса ограничена Timeout, запросы проходят через
+// token bucket своей группы эндпоинтов, а временные ошибки повторяются по Retry.
 type ByBit struct {
 	APIKey           string
 	APISecret        string
+	Timeout          time.Duration // предел одной попытки; 0 — без ограничения
+	Retry            RetryPolicy
 	client           *bybit.Bybit
+	limiters         map[EndpointGroup]*tokenBucket
 	tradeLimitsCache map[string]cachedTradeLimits
 	cacheMu          sync.RWMutex
 }
 
+// DemoRestURL — REST-адрес демо-торговли Bybit, используемый по умолчанию.
+const DemoRestURL = "https://api-demo.bybit.com"
+
 func NewByBit(apiKey, apiSecret string) *ByBit {
+	return NewByBitWithEndpoint(apiKey, apiSecret, DemoRestURL)
+}
+
+// NewByBitWithEndpoint создаёт клиента для заданного REST-адреса (mainnet, testnet или demo).
+func NewByBitWithEndpoint(apiKey, apiSecret, restURL string) *ByBit {
 	client := &bybit.Bybit{}
 	client.SetDefaults()
 
 	client.API.Endpoints = client.NewEndpoints()
 	err := client.API.Endpoints.SetDefaultEndpoints(map[exchanges.URL]string{
-		exchanges.RestFutures:      "https://api-demo.bybit.com",
-		exchanges.RestUSDTMargined: "https://api-demo.bybit.com",
-		exchanges.RestSpot:         "https://api-demo.bybit.com",
+		exchanges.RestFutures:      restURL,
+		exchanges.RestUSDTMargined: restURL,
+		exchanges.RestSpot:         restURL,
 	})
 	if err != nil {
 		log.Printf("Ошибка установки конечных точек: %v", err)
@@ -54,61 +70,74 @@ func NewByBit(apiKey, apiSecret string) *ByBit {
 	}
 
 	client.SetCredentials(apiKey, apiSecret, "", "", "", "")
-	log.Printf("Bybit клиент инициализирован с API ключом: %s", apiKey)
+	log.Printf("Bybit клиент инициализирован для %s", restURL)
 
-	return &ByBit{
+	b := &ByBit{
 		APIKey:           apiKey,
 		APISecret:        apiSecret,
+		Timeout:          DefaultRequestTimeout,
+		Retry:            DefaultRetryPolicy(),
 		client:           client,
+		limiters:         make(map[EndpointGroup]*tokenBucket),
 		tradeLimitsCache: make(map[string]cachedTradeLimits),
 	}
+	for group, limit := range DefaultRateLimits {
+		b.SetRateLimit(group, limit)
+	}
+	return b
+}
+
+// klineIntervals сопоставляет интервалы Bybit v5 с kline.Interval GoCryptoTrader.
+// Библиотека переводит в "720" значение kline.SevenHour, а не TwelveHour.
+var klineIntervals = map[string]kline.Interval{
+	"1": kline.OneMin, "3": kline.ThreeMin, "5": kline.FiveMin, "15": kline.FifteenMin, "30": kline.ThirtyMin,
+	"60": kline.OneHour, "120": kline.TwoHour, "240": kline.FourHour, "360": kline.SixHour, "720": kline.SevenHour,
+	"D": kline.OneDay, "W": kline.OneWeek,
 }
 
 func (b *ByBit) GetKlines(symbol, intervalStr string, limit uint64) ([]model.KlineData, error) {
-	ctx := context.Background()
+	return b.GetKlinesContext(context.Background(), symbol, intervalStr, limit)
+}
+
+func (b *ByBit) GetKlinesContext(ctx context.Context, symbol, intervalStr string, limit uint64) ([]model.KlineData, error) {
 	category := "linear"
-	var intervalEnum kline.Interval
-	var intervalMinutes int
-	switch intervalStr {
-	case "1":
-		intervalEnum = kline.OneMin
-		intervalMinutes = 1
-	case "30":
-		intervalEnum = kline.ThirtyMin
-		intervalMinutes = 30
-	default:
+	intervalEnum, ok := klineIntervals[intervalStr]
+	step, known := model.KlineIntervalDuration(intervalStr)
+	if !ok || !known {
 		return nil, fmt.Errorf("unsupported interval: %s", intervalStr)
 	}
 
-	startTime := time.Now().Add(-time.Duration(limit*uint64(intervalMinutes)) * time.Minute)
+	startTime := time.Now().Add(-time.Duration(limit) * step)
 	endTime := time.Now()
 
-	raw, err := b.client.GetKlines(ctx, category, symbol, intervalEnum, startTime, endTime, limit)
+	var raw []bybit.KlineItem
+	err := b.do(ctx, GroupMarket, "GetKlines", true, func(ctx context.Context) (err error) {
+		raw, err = b.client.GetKlines(ctx, category, symbol, intervalEnum, startTime, endTime, limit)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting Klines: %w", err)
 	}
 
+	// Закрыта свеча, интервал которой истёк; незакрытой может быть только самая новая.
+	now := time.Now().UnixMilli()
 	var out []model.KlineData
-	for i, it := range raw {
+	for _, it := range raw {
 		startMs := it.StartTime.UnixNano() / int64(time.Millisecond)
-		endMs := startMs + int64(time.Minute/time.Millisecond) - 1
-
-		confirm := true
-		if i == len(raw)-1 {
-			confirm = false
-		}
+		endMs := startMs + step.Milliseconds() - 1
 
 		out = append(out, model.KlineData{
+			Symbol:    symbol,
 			Start:     startMs,
 			End:       endMs,
-			Interval:  strconv.Itoa(int(intervalEnum)),
+			Interval:  intervalStr,
 			Open:      it.Open,
 			High:      it.High,
 			Low:       it.Low,
 			Close:     it.Close,
 			Volume:    it.TradeVolume,
 			Turnover:  it.Turnover,
-			Confirm:   confirm,
+			Confirm:   endMs < now,
 			Timestamp: startMs,
 		})
 	}
@@ -117,15 +146,22 @@ func (b *ByBit) GetKlines(symbol, intervalStr string, limit uint64) ([]model.Kli
 }
 
 // GetDepth возвращает данные ордербука для заданного символа и лимита.
-func (b *ByBit) GetDepth(symbol, category string, limit int64) *model.OrderbookData {
-	ctx := context.Background()
+func (b *ByBit) GetDepth(symbol, category string, limit int64) (*model.OrderbookData, error) {
+	return b.GetDepthContext(context.Background(), symbol, category, limit)
+}
+
+func (b *ByBit) GetDepthContext(ctx context.Context, symbol, category string, limit int64) (*model.OrderbookData, error) {
 	if limit > 200 {
 		limit = 200
 	}
 
-	orderBook, err := b.client.GetOrderBook(ctx, category, symbol, limit)
+	var orderBook *bybit.Orderbook
+	err := b.do(ctx, GroupMarket, "GetOrderBook", true, func(ctx context.Context) (err error) {
+		orderBook, err = b.client.GetOrderBook(ctx, category, symbol, limit)
+		return err
+	})
 	if err != nil {
-		log.Fatalf("Error getting OrderBook: %s", err)
+		return nil, fmt.Errorf("error getting orderbook for %s: %w", symbol, err)
 	}
 
 	modelOrderBook := &model.OrderbookData{
@@ -145,16 +181,23 @@ func (b *ByBit) GetDepth(symbol, category string, limit int64) *model.OrderbookD
 			Size:  ask.Amount,
 		}
 	}
-	return modelOrderBook
+	return modelOrderBook, nil
 }
 
 // GetTickers возвращает данные тикеров для заданных символов.
-func (b *ByBit) GetTickers(category, symbol string) []model.WSTickerPrice {
-	ctx := context.Background()
+func (b *ByBit) GetTickers(category, symbol string) ([]model.WSTickerPrice, error) {
+	return b.GetTickersContext(context.Background(), category, symbol)
+}
+
+func (b *ByBit) GetTickersContext(ctx context.Context, category, symbol string) ([]model.WSTickerPrice, error) {
 	tickers := make([]model.WSTickerPrice, 0)
-	tickerData, err := b.client.GetTickers(ctx, category, symbol, "", time.Time{})
+	var tickerData *bybit.TickerData
+	err := b.do(ctx, GroupMarket, "GetTickers", true, func(ctx context.Context) (err error) {
+		tickerData, err = b.client.GetTickers(ctx, category, symbol, "", time.Time{})
+		return err
+	})
 	if err != nil {
-		log.Fatalf("Ошибка при получении тикеров: %s", err)
+		return nil, fmt.Errorf("error getting tickers for %s: %w", symbol, err)
 	}
 
 	for _, t := range tickerData.List {
@@ -165,13 +208,19 @@ func (b *ByBit) GetTickers(category, symbol string) []model.WSTickerPrice {
 			})
 		}
 	}
-	return tickers
+	return tickers, nil
 }
 
 func (b *ByBit) GetTradingRequirements(category, symbol string) (*model.TradingRequirements, error) {
-	ctx := context.Background()
+	return b.GetTradingRequirementsContext(context.Background(), category, symbol)
+}
 
-	req, err := b.client.GetFeeRate(ctx, category, symbol, "")
+func (b *ByBit) GetTradingRequirementsContext(ctx context.Context, category, symbol string) (*model.TradingRequirements, error) {
+	var req *bybit.AccountFee
+	err := b.do(ctx, GroupAccount, "GetFeeRate", true, func(ctx context.Context) (err error) {
+		req, err = b.client.GetFeeRate(ctx, category, symbol, "")
+		return err
+	})
 	log.Printf("req!!: %v", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trading requirements: %w", err)
@@ -201,8 +250,11 @@ func (b *ByBit) GetTradingRequirements(category, symbol string) (*model.TradingR
 }
 
 func (b *ByBit) GetTradingFees(category, symbol string) (float64, error) {
+	return b.GetTradingFeesContext(context.Background(), category, symbol)
+}
 
-	tradingReq, err := b.GetTradingRequirements(category, symbol)
+func (b *ByBit) GetTradingFeesContext(ctx context.Context, category, symbol string) (float64, error) {
+	tradingReq, err := b.GetTradingRequirementsContext(ctx, category, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get trading requirements: %w", err)
 	}
@@ -213,8 +265,15 @@ func (b *ByBit) GetTradingFees(category, symbol string) (float64, error) {
 
 // GetOpenOrders смотрит открытые ордера
 func (b *ByBit) GetOpenOrders(category, symbol string) (*model.TradeOrders, error) {
-	ctx := context.Background()
-	libOrders, err := b.client.GetOpenOrders(ctx, category, symbol, "", "", "", "", "", "", 0, 10)
+	return b.GetOpenOrdersContext(context.Background(), category, symbol)
+}
+
+func (b *ByBit) GetOpenOrdersContext(ctx context.Context, category, symbol string) (*model.TradeOrders, error) {
+	var libOrders *bybit.TradeOrders
+	err := b.do(ctx, GroupAccount, "GetOpenOrders", true, func(ctx context.Context) (err error) {
+		libOrders, err = b.client.GetOpenOrders(ctx, category, symbol, "", "", "", "", "", "", 0, 10)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting open orders for %s: %w", symbol, err)
 	}
@@ -243,8 +302,10 @@ func convertTradeOrders(libOrders *bybit.TradeOrders) *model.TradeOrders {
 
 // CreateOrder размещает ордер через клиента Bybit.
 func (b *ByBit) CreateOrderSpot(symbol, side, orderType string, price, amount, stopLoss, takeProfit float64) (*order.SubmitResponse, error) {
-	ctx := context.Background()
+	return b.CreateOrderSpotContext(context.Background(), symbol, side, orderType, price, amount, stopLoss, takeProfit)
+}
 
+func (b *ByBit) CreateOrderSpotContext(ctx context.Context, symbol, side, orderType string, price, amount, stopLoss, takeProfit float64) (*order.SubmitResponse, error) {
 	pair, err := currency.NewPairFromString(symbol)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
@@ -291,7 +352,11 @@ func (b *ByBit) CreateOrderSpot(symbol, side, orderType string, price, amount, s
 		log.Printf("Endpoints не настроены")
 	}
 
-	response, err := b.client.SubmitOrder(ctx, submitOrder)
+	var response *order.SubmitResponse
+	err = b.do(ctx, GroupOrder, "SubmitOrder", false, func(ctx context.Context) (err error) {
+		response, err = b.client.SubmitOrder(ctx, submitOrder)
+		return err
+	})
 	if err != nil {
 		log.Printf("Ошибка размещения ордера: %v", err)
 		return nil, err
@@ -300,6 +365,10 @@ func (b *ByBit) CreateOrderSpot(symbol, side, orderType string, price, amount, s
 }
 
 func (b *ByBit) GetTradeLimitsViaInstruments(category, symbol string) (model.TradeLimits, error) {
+	return b.GetTradeLimitsViaInstrumentsContext(context.Background(), category, symbol)
+}
+
+func (b *ByBit) GetTradeLimitsViaInstrumentsContext(ctx context.Context, category, symbol string) (model.TradeLimits, error) {
 	cacheKey := category + ":" + symbol
 	b.cacheMu.RLock()
 	if cached, found := b.tradeLimitsCache[cacheKey]; found {
@@ -311,8 +380,11 @@ func (b *ByBit) GetTradeLimitsViaInstruments(category, symbol string) (model.Tra
 	}
 	b.cacheMu.RUnlock()
 
-	ctx := context.Background()
-	instruments, err := b.client.GetInstrumentInfo(ctx, category, symbol, "", "", "", 0)
+	var instruments *bybit.InstrumentsInfo
+	err := b.do(ctx, GroupMarket, "GetInstrumentInfo", true, func(ctx context.Context) (err error) {
+		instruments, err = b.client.GetInstrumentInfo(ctx, category, symbol, "", "", "", 0)
+		return err
+	})
 	if err != nil {
 		return model.TradeLimits{}, fmt.Errorf("failed to get instrument info: %w", err)
 	}
@@ -341,10 +413,25 @@ func (b *ByBit) GetTradeLimitsViaInstruments(category, symbol string) (model.Tra
 	return model.TradeLimits{}, errors.New("instrument not found")
 }
 
+// PlaceOrder размещает ордер на рынке linear и возвращает его идентификатор.
+func (b *ByBit) PlaceOrder(symbol, side, orderType string, price, qty, stopLoss, takeProfit float64) (string, error) {
+	return b.PlaceOrderContext(context.Background(), symbol, side, orderType, price, qty, stopLoss, takeProfit)
+}
+
+func (b *ByBit) PlaceOrderContext(ctx context.Context, symbol, side, orderType string, price, qty, stopLoss, takeProfit float64) (string, error) {
+	resp, err := b.CreateOrderViaPlaceOrderFutureContext(ctx, symbol, side, orderType, price, qty, stopLoss, takeProfit)
+	if err != nil {
+		return "", err
+	}
+	return resp.OrderID, nil
+}
+
 // CreateOrder размещает ордер через клиента Bybit.
 func (b *ByBit) CreateOrderViaPlaceOrderFuture(symbol, side, orderType string, price, amount, stopLoss, takeProfit float64) (*order.SubmitResponse, error) {
-	ctx := context.Background()
+	return b.CreateOrderViaPlaceOrderFutureContext(context.Background(), symbol, side, orderType, price, amount, stopLoss, takeProfit)
+}
 
+func (b *ByBit) CreateOrderViaPlaceOrderFutureContext(ctx context.Context, symbol, side, orderType string, price, amount, stopLoss, takeProfit float64) (*order.SubmitResponse, error) {
 	// Создаем валютную пару.
 	pair, err := currency.NewPairFromString(symbol)
 	if err != nil {
@@ -404,7 +491,11 @@ func (b *ByBit) CreateOrderViaPlaceOrderFuture(symbol, side, orderType string, p
 	}
 
 	// Отправляем ордер через метод PlaceOrder.
-	response, err := b.client.PlaceOrder(ctx, arg)
+	var response *bybit.OrderResponse
+	err = b.do(ctx, GroupOrder, "PlaceOrder", false, func(ctx context.Context) (err error) {
+		response, err = b.client.PlaceOrder(ctx, arg)
+		return err
+	})
 	if err != nil {
 		log.Printf("Ошибка размещения ордера через PlaceOrder: %v", err)
 		return nil, err
@@ -419,6 +510,326 @@ func (b *ByBit) CreateOrderViaPlaceOrderFuture(symbol, side, orderType string, p
 	return submitResp, nil
 }
 
+// ClosePosition закрывает qty позиции рыночным reduce-only ордером; side — сторона закрывающего
+// ордера (Sell для длинной позиции, Buy для короткой).
+func (b *ByBit) ClosePosition(category, symbol, side string, qty float64) error {
+	return b.ClosePositionContext(context.Background(), category, symbol, side, qty)
+}
+
+func (b *ByBit) ClosePositionContext(ctx context.Context, category, symbol, side string, qty float64) error {
+	pair, err := currency.NewPairFromString(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
+	}
+	err = b.do(ctx, GroupOrder, "PlaceOrder", false, func(ctx context.Context) error {
+		_, err := b.client.PlaceOrder(ctx, &bybit.PlaceOrderParams{
+			Category:      category,
+			Symbol:        pair,
+			Side:          side,
+			OrderType:     orderTypeToString(order.Market),
+			OrderQuantity: qty,
+			ReduceOnly:    true,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to close position %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SetStopLoss переносит стоп позиции, выставленный в режиме Full (на весь объём).
+func (b *ByBit) SetStopLoss(category, symbol string, stopLoss float64) error {
+	return b.SetStopLossContext(context.Background(), category, symbol, stopLoss)
+}
+
+func (b *ByBit) SetStopLossContext(ctx context.Context, category, symbol string, stopLoss float64) error {
+	pair, err := currency.NewPairFromString(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
+	}
+	err = b.do(ctx, GroupOrder, "SetTradingStop", true, func(ctx context.Context) error {
+		return b.client.SetTradingStop(ctx, &bybit.TradingStopParams{
+			Category:                 category,
+			Symbol:                   pair,
+			StopLoss:                 strconv.FormatFloat(stopLoss, 'f', -1, 64),
+			StopLossTriggerType:      "MarkPrice",
+			TakeProfitOrStopLossMode: "Full",
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set stop loss for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SetPartialTakeProfit добавляет к позиции лимитный TP режима Partial на qty по цене price.
+// Такие TP сосуществуют с TP/SL режима Full, выставленными при входе.
+func (b *ByBit) SetPartialTakeProfit(category, symbol string, price, qty float64) error {
+	return b.SetPartialTakeProfitContext(context.Background(), category, symbol, price, qty)
+}
+
+func (b *ByBit) SetPartialTakeProfitContext(ctx context.Context, category, symbol string, price, qty float64) error {
+	pair, err := currency.NewPairFromString(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
+	}
+	err = b.do(ctx, GroupOrder, "SetTradingStop", false, func(ctx context.Context) error {
+		return b.client.SetTradingStop(ctx, &bybit.TradingStopParams{
+			Category:                 category,
+			Symbol:                   pair,
+			TakeProfit:               strconv.FormatFloat(price, 'f', -1, 64),
+			TakeProfitTriggerType:    "LastPrice",
+			TakeProfitOrStopLossMode: "Partial",
+			TakeProfitOrderType:      orderTypeToString(order.Limit),
+			TakeProfitLimitPrice:     price,
+			TakeProfitSize:           qty,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set partial take profit for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// CancelOrder отменяет открытый или частично исполненный ордер.
+func (b *ByBit) CancelOrder(category, symbol, orderID string) error {
+	return b.CancelOrderContext(context.Background(), category, symbol, orderID)
+}
+
+func (b *ByBit) CancelOrderContext(ctx context.Context, category, symbol, orderID string) error {
+	pair, err := currency.NewPairFromString(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
+	}
+	err = b.do(ctx, GroupOrder, "CancelTradeOrder", true, func(ctx context.Context) error {
+		_, err := b.client.CancelTradeOrder(ctx, &bybit.CancelOrderParams{
+			Category: category,
+			Symbol:   pair,
+			OrderID:  orderID,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel order %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// AmendOrder меняет цену и/или количество открытого ордера. Нулевые значения не меняются.
+func (b *ByBit) AmendOrder(category, symbol, orderID string, price, qty float64) error {
+	return b.AmendOrderContext(context.Background(), category, symbol, orderID, price, qty)
+}
+
+func (b *ByBit) AmendOrderContext(ctx context.Context, category, symbol, orderID string, price, qty float64) error {
+	pair, err := currency.NewPairFromString(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to create currency pair from symbol %s: %v", symbol, err)
+	}
+	err = b.do(ctx, GroupOrder, "AmendOrder", true, func(ctx context.Context) error {
+		_, err := b.client.AmendOrder(ctx, &bybit.AmendOrderParams{
+			Category:      category,
+			Symbol:        pair,
+			OrderID:       orderID,
+			Price:         price,
+			OrderQuantity: qty,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to amend order %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// GetOrderStatus возвращает текущее состояние ордера: сначала ищет среди активных,
+// затем в истории ордеров. nil без ошибки — ордер на бирже не найден.
+func (b *ByBit) GetOrderStatus(category, symbol, orderID string) (*model.OrderUpdate, error) {
+	return b.GetOrderStatusContext(context.Background(), category, symbol, orderID)
+}
+
+func (b *ByBit) GetOrderStatusContext(ctx context.Context, category, symbol, orderID string) (*model.OrderUpdate, error) {
+	var open *bybit.TradeOrders
+	err := b.do(ctx, GroupAccount, "GetOpenOrders", true, func(ctx context.Context) (err error) {
+		open, err = b.client.GetOpenOrders(ctx, category, symbol, "", "", orderID, "", "", "", 0, 1)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting order %s: %w", orderID, err)
+	}
+	if open != nil && len(open.List) > 0 {
+		return convertOrderUpdate(category, open.List[0]), nil
+	}
+	var history *bybit.TradeOrders
+	err = b.do(ctx, GroupAccount, "GetTradeOrderHistory", true, func(ctx context.Context) (err error) {
+		history, err = b.client.GetTradeOrderHistory(ctx, category, symbol, orderID, "", "", "", "", "", "", time.Time{}, time.Time{}, 1)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting order history for %s: %w", orderID, err)
+	}
+	if history != nil && len(history.List) > 0 {
+		return convertOrderUpdate(category, history.List[0]), nil
+	}
+	return nil, nil
+}
+
+// ListOpenOrders возвращает все активные ордера символа, включая условные TP/SL,
+// в виде обновлений приватного потока.
+func (b *ByBit) ListOpenOrders(category, symbol string) ([]model.OrderUpdate, error) {
+	return b.ListOpenOrdersContext(context.Background(), category, symbol)
+}
+
+func (b *ByBit) ListOpenOrdersContext(ctx context.Context, category, symbol string) ([]model.OrderUpdate, error) {
+	var out []model.OrderUpdate
+	cursor := ""
+	for {
+		var resp *bybit.TradeOrders
+		err := b.do(ctx, GroupAccount, "GetOpenOrders", true, func(ctx context.Context) (err error) {
+			resp, err = b.client.GetOpenOrders(ctx, category, symbol, "", "", "", "", "", cursor, 0, 50)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting open orders for %s: %w", symbol, err)
+		}
+		if resp == nil {
+			break
+		}
+		for _, o := range resp.List {
+			out = append(out, *convertOrderUpdate(category, o))
+		}
+		if resp.NextPageCursor == "" || len(resp.List) == 0 {
+			break
+		}
+		cursor = resp.NextPageCursor
+	}
+	return out, nil
+}
+
+// GetPositions возвращает позиции символа в виде обновлений приватного потока.
+// В one-way режиме Bybit возвращает запись и для пустой позиции (Size = 0).
+func (b *ByBit) GetPositions(category, symbol string) ([]model.PositionUpdate, error) {
+	return b.GetPositionsContext(context.Background(), category, symbol)
+}
+
+func (b *ByBit) GetPositionsContext(ctx context.Context, category, symbol string) ([]model.PositionUpdate, error) {
+	var resp *bybit.PositionInfoList
+	err := b.do(ctx, GroupAccount, "GetPositionInfo", true, func(ctx context.Context) (err error) {
+		resp, err = b.client.GetPositionInfo(ctx, category, symbol, "", "", "", 50)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting positions for %s: %w", symbol, err)
+	}
+	if resp == nil {
+		return nil, nil
+	}
+	out := make([]model.PositionUpdate, 0, len(resp.List))
+	for _, p := range resp.List {
+		out = append(out, model.PositionUpdate{
+			Category:       category,
+			Symbol:         p.Symbol,
+			Side:           p.Side,
+			Size:           p.Size,
+			EntryPrice:     p.AveragePrice,
+			MarkPrice:      p.MarkPrice,
+			PositionValue:  p.PositionValue,
+			Leverage:       p.Leverage,
+			UnrealisedPnl:  p.UnrealisedPnl,
+			CumRealisedPnl: p.CumRealisedPnl,
+			TakeProfit:     p.TakeProfit,
+			StopLoss:       p.StopLoss,
+			TrailingStop:   p.TrailingStop,
+			PositionStatus: p.PositionStatus,
+			TpslMode:       p.TpslMode,
+			CreatedTime:    p.CreatedTime,
+			UpdatedTime:    p.UpdatedTime,
+		})
+	}
+	return out, nil
+}
+
+// GetExecutions возвращает исполнения по символу начиная с since (Bybit отдаёт не более 7 дней),
+// по возрастанию времени, в виде обновлений приватного потока.
+func (b *ByBit) GetExecutions(category, symbol string, since time.Time) ([]model.ExecutionUpdate, error) {
+	return b.GetExecutionsContext(context.Background(), category, symbol, since)
+}
+
+func (b *ByBit) GetExecutionsContext(ctx context.Context, category, symbol string, since time.Time) ([]model.ExecutionUpdate, error) {
+	var out []model.ExecutionUpdate
+	cursor := ""
+	for {
+		var resp *bybit.ExecutionResponse
+		err := b.do(ctx, GroupAccount, "GetExecution", true, func(ctx context.Context) (err error) {
+			resp, err = b.client.GetExecution(ctx, category, symbol, "", "", "", "", "", cursor, since, time.Time{}, 100)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting executions for %s: %w", symbol, err)
+		}
+		if resp == nil {
+			break
+		}
+		for _, e := range resp.List {
+			execValue, _ := strconv.ParseFloat(e.ExecValue, 64)
+			out = append(out, model.ExecutionUpdate{
+				Category:      category,
+				Symbol:        e.Symbol,
+				ExecID:        e.ExecID,
+				OrderID:       e.OrderID,
+				OrderLinkID:   e.OrderLinkID,
+				Side:          e.Side,
+				OrderType:     e.OrderType,
+				StopOrderType: e.StopOrderType,
+				ExecPrice:     e.ExecPrice,
+				ExecQty:       e.ExecQuantity,
+				ExecValue:     types.Number(execValue),
+				ExecFee:       e.ExecFee,
+				FeeRate:       e.FeeRate,
+				ExecType:      e.ExecType,
+				IsMaker:       e.IsMaker,
+				ClosedSize:    e.ClosedSize,
+				LeavesQty:     e.LeavesQuantity,
+				MarkPrice:     e.MarkPrice,
+				ExecTime:      e.ExecTime,
+			})
+		}
+		if resp.NextPageCursor == "" || len(resp.List) == 0 {
+			break
+		}
+		cursor = resp.NextPageCursor
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExecTime.Time().Before(out[j].ExecTime.Time()) })
+	return out, nil
+}
+
+// convertOrderUpdate приводит ответ REST к виду обновления из приватного потока.
+func convertOrderUpdate(category string, o bybit.TradeOrder) *model.OrderUpdate {
+	return &model.OrderUpdate{
+		Category:      category,
+		Symbol:        o.Symbol,
+		OrderID:       o.OrderID,
+		OrderLinkID:   o.OrderLinkID,
+		Side:          o.Side,
+		OrderType:     o.OrderType,
+		Price:         o.Price,
+		Qty:           o.OrderQuantity,
+		AvgPrice:      o.AveragePrice,
+		CumExecQty:    o.CumulativeExecQuantity,
+		CumExecFee:    o.CumulativeExecFee,
+		LeavesQty:     o.LeavesQuantity,
+		OrderStatus:   o.OrderStatus,
+		RejectReason:  o.RejectReason,
+		CancelType:    o.CancelType,
+		StopOrderType: o.StopOrderType,
+		TakeProfit:    o.TakeProfitPrice,
+		StopLoss:      o.StopLossPrice,
+		ReduceOnly:    o.ReduceOnly,
+		CreatedTime:   o.CreatedTime,
+		UpdatedTime:   o.UpdatedTime,
+	}
+}
+
 func getCategoryName(assetType asset.Item) string {
 	switch assetType {
 	case asset.USDTMarginedFutures:
@@ -441,11 +852,47 @@ func orderTypeToString(t order.Type) string {
 }
 
 func (b *ByBit) GetBalance() (*bybit.WalletBalance, error) {
-	ctx := context.Background()
-	balance, err := b.client.GetWalletBalance(ctx, "UNIFIED", "")
+	return b.GetBalanceContext(context.Background())
+}
+
+func (b *ByBit) GetBalanceContext(ctx context.Context) (*bybit.WalletBalance, error) {
+	var balance *bybit.WalletBalance
+	err := b.do(ctx, GroupAccount, "GetWalletBalance", true, func(ctx context.Context) (err error) {
+		balance, err = b.client.GetWalletBalance(ctx, "UNIFIED", "")
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wallet balance: %w", err)
 	}
 	log.Printf("Получен баланс: %+v", balance)
 	return balance, nil
 }
+
+// GetWalletInfo возвращает баланс монеты coin единого торгового аккаунта.
+func (b *ByBit) GetWalletInfo(coin string) (*model.WalletInfoRep, error) {
+	return b.GetWalletInfoContext(context.Background(), coin)
+}
+
+func (b *ByBit) GetWalletInfoContext(ctx context.Context, coin string) (*model.WalletInfoRep, error) {
+	balance, err := b.GetBalanceContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	info := &model.WalletInfoRep{Coin: coin, RecordedAt: now, CreatedAt: now, UpdatedAt: now}
+	if len(balance.List) > 0 {
+		info.TotalMarginBalance = balance.List[0].TotalMarginBalance.Float64()
+		info.TotalWalletBalance = balance.List[0].TotalWalletBalance.Float64()
+	}
+	for _, wallet := range balance.List {
+		for _, c := range wallet.Coin {
+			if c.Coin.String() == coin {
+				info.WalletBalance = c.WalletBalance.Float64()
+				return info, nil
+			}
+		}
+	}
+	return info, nil
+}
+
+var _ interfaces.Exchange = (*ByBit)(nil)
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/thrasher-corp/gocryptotrader/exchanges/request"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+)
+
+// Виды ошибок запросов к Bybit. Проверяются через errors.Is.
+var (
+	ErrTimeout     = errors.New("bybit: request timeout")
+	ErrNetwork     = errors.New("bybit: network error")
+	ErrRateLimited = errors.New("bybit: rate limit exceeded")
+	ErrServer      = errors.New("bybit: server error")
+	ErrRejected    = errors.New("bybit: request rejected")
+)
+
+// Коды retCode Bybit, после которых запрос можно повторить.
+const (
+	retCodeServerTimeout = 10000 // server timeout
+	retCodeTooManyVisits = 10006 // превышен лимит запросов UID
+	retCodeServerError   = 10016 // внутренняя ошибка сервиса
+	retCodeIPRateLimit   = 10018 // превышен лимит запросов IP
+)
+
+var (
+	retCodePattern    = regexp.MustCompile(`code: (-?\d+) message: (.*)`)
+	httpStatusPattern = regexp.MustCompile(`: (\d{3}) raw response`)
+)
+
+// APIError — ошибка запроса Op. Kind — один из ErrTimeout, ErrNetwork, ErrRateLimited, ErrServer
+// или ErrRejected; RetCode и HTTPStatus заполняются, если их удалось определить.
+type APIError struct {
+	Op         string
+	Kind       error
+	RetCode    int
+	HTTPStatus int
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.RetCode != 0:
+		return fmt.Sprintf("%s: %v (retCode %d: %s)", e.Op, e.Kind, e.RetCode, e.Message)
+	case e.HTTPStatus != 0:
+		return fmt.Sprintf("%s: %v (HTTP %d)", e.Op, e.Kind, e.HTTPStatus)
+	default:
+		return fmt.Sprintf("%s: %v: %v", e.Op, e.Kind, e.Err)
+	}
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable сообщает, имеет ли смысл повторить запрос.
+func (e *APIError) Retryable() bool {
+	return e.Kind != ErrRejected
+}
+
+// IsRetryable сообщает, что err — временная ошибка Bybit (таймаут, обрыв соединения, лимит запросов, 5xx).
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// classify оборачивает ошибку библиотеки в APIError. Отмену ctx вызывающим не оборачивает.
+func classify(op string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+	e := &APIError{Op: op, Kind: ErrRejected, Err: err}
+
+	if m := retCodePattern.FindStringSubmatch(err.Error()); m != nil {
+		e.RetCode, _ = strconv.Atoi(m[1])
+		e.Message = m[2]
+		switch e.RetCode {
+		case retCodeTooManyVisits, retCodeIPRateLimit:
+			e.Kind = ErrRateLimited
+		case retCodeServerTimeout:
+			e.Kind = ErrTimeout
+		case retCodeServerError:
+			e.Kind = ErrServer
+		}
+		return e
+	}
+	if errors.Is(err, request.ErrBadStatus) {
+		if m := httpStatusPattern.FindStringSubmatch(err.Error()); m != nil {
+			e.HTTPStatus, _ = strconv.Atoi(m[1])
+		}
+		switch {
+		case e.HTTPStatus == 429:
+			e.Kind = ErrRateLimited
+		case e.HTTPStatus >= 500:
+			e.Kind = ErrServer
+		}
+		return e
+	}
+	// Транспортные ошибки (сброс или отказ в соединении, обрыв ответа) временные:
+	// до Bybit запрос мог не дойти или ответ потерялся по дороге.
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		e.Kind = ErrTimeout
+	case netErr != nil || errors.Is(err, io.ErrUnexpectedEOF):
+		e.Kind = ErrNetwork
+	}
+	return e
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	resetErr := &url.Error{Op: "Get", URL: "https://api.bybit.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+	eofErr := &url.Error{Op: "Get", URL: "https://api.bybit.com", Err: io.EOF}
+
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		retryable bool
+	}{
+		{"rate limit retCode", errors.New("code: 10006 message: Too many visits"), ErrRateLimited, true},
+		{"server timeout retCode", errors.New("code: 10000 message: Server Timeout"), ErrTimeout, true},
+		{"rejected retCode", errors.New("code: 110007 message: insufficient balance"), ErrRejected, false},
+		{"deadline", fmt.Errorf("GetKlines: %w", context.DeadlineExceeded), ErrTimeout, true},
+		{"connection refused", dialErr, ErrNetwork, true},
+		{"connection reset", resetErr, ErrNetwork, true},
+		{"eof", eofErr, ErrNetwork, true},
+		{"unexpected eof", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), ErrNetwork, true},
+		{"other", errors.New("invalid symbol"), ErrRejected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify("op", tt.err)
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("classify(%v) = %v, want kind %v", tt.err, err, tt.kind)
+			}
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", err, got, tt.retryable)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("classify(%v) lost the original error", tt.err)
+			}
+		})
+	}
+
+	if err := classify("op", context.Canceled); err != context.Canceled {
+		t.Fatalf("classify(context.Canceled) = %v, want it unwrapped", err)
+	}
+}
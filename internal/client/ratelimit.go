@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// EndpointGroup — группа REST-эндпоинтов Bybit с общим лимитом запросов.
+type EndpointGroup string
+
+const (
+	GroupMarket  EndpointGroup = "market"  // публичные данные: свечи, ордербук, тикеры, инструменты
+	GroupOrder   EndpointGroup = "order"   // создание, изменение и отмена ордеров, TP/SL позиции
+	GroupAccount EndpointGroup = "account" // баланс, комиссии, позиции, ордера и исполнения аккаунта
+)
+
+// DefaultRequestTimeout — предельное время одной попытки запроса.
+const DefaultRequestTimeout = 10 * time.Second
+
+// RateLimit — скорость (запросов в секунду) и запас token bucket группы эндпоинтов.
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// DefaultRateLimits — лимиты ниже опубликованных Bybit для v5 API, чтобы оставался запас
+// на встроенный ограничитель библиотеки и ручные запросы.
+var DefaultRateLimits = map[EndpointGroup]RateLimit{
+	GroupMarket:  {PerSecond: 20, Burst: 40},
+	GroupOrder:   {PerSecond: 8, Burst: 10},
+	GroupAccount: {PerSecond: 5, Burst: 10},
+}
+
+// RetryPolicy — повтор временных ошибок (IsRetryable) с экспоненциальной паузой и полным jitter:
+// перед попыткой n пауза случайна в [0, min(MaxDelay, BaseDelay*2^n)).
+type RetryPolicy struct {
+	MaxAttempts int // всего попыток, включая первую; 1 — без повторов
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 4, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := math.Min(float64(p.MaxDelay), float64(p.BaseDelay)*math.Pow(2, float64(attempt)))
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// tokenBucket пополняется со скоростью rate токенов в секунду до burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := math.Max(1, float64(limit.Burst))
+	return &tokenBucket{rate: limit.PerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait ждёт токен или отмену ctx. Лимит с нулевой скоростью не ограничивает запросы.
+func (t *tokenBucket) Wait(ctx context.Context) error {
+	if t.rate <= 0 {
+		return nil
+	}
+	for {
+		t.mu.Lock()
+		now := time.Now()
+		t.tokens = math.Min(t.burst, t.tokens+now.Sub(t.last).Seconds()*t.rate)
+		t.last = now
+		if t.tokens >= 1 {
+			t.tokens--
+			t.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+		t.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// SetRateLimit меняет лимит группы эндпоинтов. Вызывается до начала работы клиента.
+func (b *ByBit) SetRateLimit(group EndpointGroup, limit RateLimit) {
+	if b.limiters == nil {
+		b.limiters = make(map[EndpointGroup]*tokenBucket)
+	}
+	b.limiters[group] = newTokenBucket(limit)
+}
+
+// do выполняет запрос op группы group: ждёт токен лимита, ограничивает попытку Timeout
+// и повторяет временные ошибки по Retry. Неидемпотентные запросы (создание ордера)
+// повторяются только после отказа по лимиту: при таймауте или 5xx ордер мог быть принят.
+func (b *ByBit) do(ctx context.Context, group EndpointGroup, op string, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := max(1, b.Retry.MaxAttempts)
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := b.Retry.backoff(attempt)
+			log.Printf("[Bybit] %s: попытка %d из %d через %s после ошибки: %v", op, attempt+1, attempts, delay.Round(time.Millisecond), err)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+		if limiter := b.limiters[group]; limiter != nil {
+			if waitErr := limiter.Wait(ctx); waitErr != nil {
+				return classify(op, waitErr)
+			}
+		}
+		err = b.attempt(ctx, op, fn)
+		if err == nil || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if !idempotent && !errors.Is(err, ErrRateLimited) {
+			return err
+		}
+	}
+	return err
+}
+
+func (b *ByBit) attempt(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+	return classify(op, fn(ctx))
+}
@@ -0,0 +1,800 @@
+package client
+
+import (
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"fmt"
+	"github.com/thrasher-corp/gocryptotrader/types"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Коды отказов, которые Simulated возвращает в APIError так же, как Bybit.
+const (
+	retCodeParamsError       = 10001  // неверные параметры запроса
+	retCodeOrderNotExists    = 110001 // ордер не найден или уже завершён
+	retCodeInsufficientFunds = 110007 // недостаточно средств для ордера
+)
+
+// maxSimKlines — сколько 1-минутных свечей хранит Simulated (около недели).
+const maxSimKlines = 10000
+
+// DefaultSimLimits — лимиты инструмента Simulated, если для символа не заданы Limits.
+var DefaultSimLimits = model.TradeLimits{MinQuantity: 0.001, MaxQuantity: 1000, StepSize: 0.001, TickSize: 0.01}
+
+// PricePoint — шаг сценария цены Simulated.
+type PricePoint struct {
+	Time   time.Time
+	Price  float64
+	Volume float64
+}
+
+// Simulated — детерминированная биржа в памяти с интерфейсом interfaces.Exchange.
+// Цена меняется только шагами сценария (Step, Play); на каждом шаге исполняются лимитные
+// ордера, которых коснулась цена (по цене ордера, как мейкер), и срабатывают SL, TP и
+// частичные TP позиции (по цене шага, как тейкер). Вместо шагов цены можно подавать
+// котировки (Quote): тогда покупки исполняются по ask, продажи — по bid. Позиции ведутся в one-way режиме,
+// баланс кошелька меняется на реализованный PnL и комиссии, а новые ордера проверяются
+// по доступной марже с учётом Leverage. Из 1-минутных свечей сценария строятся свечи
+// любого интервала в минутах.
+type Simulated struct {
+	Category string
+	Coin     string
+	MakerFee float64
+	TakerFee float64
+	Leverage float64
+	Limits   map[string]model.TradeLimits
+	IDPrefix string // префикс идентификаторов ордеров и исполнений
+
+	// OnOrder и OnExecution получают обновления так же, как приватный поток Bybit.
+	// Вызываются после снятия блокировки, поэтому могут обращаться к Simulated.
+	OnOrder     func(model.OrderUpdate)
+	OnExecution func(model.ExecutionUpdate)
+
+	mu         sync.Mutex
+	now        time.Time
+	balance    float64
+	prices     map[string]float64
+	quotes     map[string]simQuote
+	klines     map[string][]model.KlineData // 1-минутные свечи по возрастанию времени
+	open       []*model.OrderUpdate
+	closed     map[string]*model.OrderUpdate
+	positions  map[string]*simPosition
+	executions []model.ExecutionUpdate
+	nextID     int
+
+	pendingOrders     []model.OrderUpdate
+	pendingExecutions []model.ExecutionUpdate
+}
+
+type simQuote struct {
+	bid, ask float64
+}
+
+type simPosition struct {
+	side       string // Buy или Sell
+	size       float64
+	entry      float64
+	stopLoss   float64
+	takeProfit float64
+	partials   []simPartial
+	realized   float64
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+type simPartial struct {
+	id        string
+	price     float64
+	qty       float64
+	createdAt time.Time
+}
+
+var _ interfaces.Exchange = (*Simulated)(nil)
+
+// NewSimulated создаёт биржу с балансом balance USDT и комиссиями Bybit linear без VIP.
+func NewSimulated(balance float64) *Simulated {
+	return &Simulated{
+		Category:  "linear",
+		Coin:      "USDT",
+		MakerFee:  0.0002,
+		TakerFee:  0.00055,
+		Leverage:  1,
+		IDPrefix:  "sim",
+		Limits:    make(map[string]model.TradeLimits),
+		balance:   balance,
+		prices:    make(map[string]float64),
+		quotes:    make(map[string]simQuote),
+		klines:    make(map[string][]model.KlineData),
+		closed:    make(map[string]*model.OrderUpdate),
+		positions: make(map[string]*simPosition),
+	}
+}
+
+// unlock снимает блокировку и передаёт накопленные обновления обработчикам.
+func (s *Simulated) unlock() {
+	orders, executions := s.pendingOrders, s.pendingExecutions
+	s.pendingOrders, s.pendingExecutions = nil, nil
+	s.mu.Unlock()
+	for _, o := range orders {
+		if s.OnOrder != nil {
+			s.OnOrder(o)
+		}
+	}
+	for _, e := range executions {
+		if s.OnExecution != nil {
+			s.OnExecution(e)
+		}
+	}
+}
+
+// Play проигрывает сценарий цены symbol по порядку.
+func (s *Simulated) Play(symbol string, path []PricePoint) {
+	for _, p := range path {
+		s.Step(symbol, p)
+	}
+}
+
+// Step переводит цену symbol в p.Price на момент p.Time (время не идёт назад): обновляет
+// свечи, исполняет ордера, которых коснулась цена, и проверяет SL/TP позиции.
+func (s *Simulated) Step(symbol string, p PricePoint) {
+	s.mu.Lock()
+	defer s.unlock()
+	s.advance(symbol, p, p.Price, p.Price)
+}
+
+// Quote переводит котировку symbol в лучшие bid и ask на момент at. Лимитная покупка
+// исполняется, когда ask опускается до её цены, продажа — когда bid поднимается;
+// SL/TP срабатывают по середине спреда.
+func (s *Simulated) Quote(symbol string, at time.Time, bid, ask float64) {
+	s.mu.Lock()
+	defer s.unlock()
+	s.advance(symbol, PricePoint{Time: at, Price: (bid + ask) / 2}, bid, ask)
+}
+
+func (s *Simulated) advance(symbol string, p PricePoint, bid, ask float64) {
+	if p.Time.After(s.now) {
+		s.now = p.Time
+	}
+	s.prices[symbol] = p.Price
+	s.quotes[symbol] = simQuote{bid: bid, ask: ask}
+	s.appendKline(symbol, p)
+	s.matchOrders(symbol)
+	s.triggerStops(symbol, p.Price)
+}
+
+// SetLimits задаёт торговые лимиты символа.
+func (s *Simulated) SetLimits(symbol string, limits model.TradeLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Limits == nil {
+		s.Limits = make(map[string]model.TradeLimits)
+	}
+	s.Limits[symbol] = limits
+}
+
+// Balance возвращает баланс кошелька без нереализованного PnL.
+func (s *Simulated) Balance() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balance
+}
+
+func (s *Simulated) appendKline(symbol string, p PricePoint) {
+	start := p.Time.Truncate(time.Minute)
+	bars := s.klines[symbol]
+	if n := len(bars); n > 0 && bars[n-1].Start == start.UnixMilli() {
+		bar := &bars[n-1]
+		bar.High = math.Max(bar.High, p.Price)
+		bar.Low = math.Min(bar.Low, p.Price)
+		bar.Close = p.Price
+		bar.Volume += p.Volume
+		bar.Turnover += p.Volume * p.Price
+		return
+	}
+	if len(bars) >= maxSimKlines {
+		bars = append(bars[:0], bars[len(bars)-maxSimKlines+1:]...)
+	}
+	s.klines[symbol] = append(bars, model.KlineData{
+		Start:     start.UnixMilli(),
+		End:       start.Add(time.Minute).UnixMilli() - 1,
+		Interval:  "1",
+		Open:      p.Price,
+		High:      p.Price,
+		Low:       p.Price,
+		Close:     p.Price,
+		Volume:    p.Volume,
+		Turnover:  p.Volume * p.Price,
+		Timestamp: start.UnixMilli(),
+		Symbol:    symbol,
+	})
+}
+
+func (s *Simulated) matchOrders(symbol string) {
+	q := s.quotes[symbol]
+	active := s.open[:0]
+	for _, o := range s.open {
+		limit := o.Price.Float64()
+		touched := (o.Side == "Buy" && q.ask <= limit) || (o.Side == "Sell" && q.bid >= limit)
+		if o.Symbol != symbol || !touched {
+			active = append(active, o)
+			continue
+		}
+		s.fill(o, limit, true)
+	}
+	s.open = active
+}
+
+// triggerStops проверяет сначала SL, затем частичные TP и TP на весь объём.
+func (s *Simulated) triggerStops(symbol string, price float64) {
+	pos := s.positions[symbol]
+	if pos == nil {
+		return
+	}
+	long := pos.side == "Buy"
+	closeSide := opposite(pos.side)
+	if pos.stopLoss > 0 && ((long && price <= pos.stopLoss) || (!long && price >= pos.stopLoss)) {
+		s.conditional(symbol, closeSide, "StopLoss", s.marketPrice(symbol, closeSide), pos.size, false)
+		return
+	}
+	for len(pos.partials) > 0 {
+		tp := pos.partials[0]
+		if (long && price < tp.price) || (!long && price > tp.price) {
+			break
+		}
+		pos.partials = pos.partials[1:]
+		s.conditional(symbol, closeSide, "PartialTakeProfit", tp.price, math.Min(tp.qty, pos.size), true)
+		if s.positions[symbol] == nil {
+			return
+		}
+	}
+	if pos.takeProfit > 0 && ((long && price >= pos.takeProfit) || (!long && price <= pos.takeProfit)) {
+		s.conditional(symbol, closeSide, "TakeProfit", s.marketPrice(symbol, closeSide), pos.size, false)
+	}
+}
+
+// conditional исполняет сработавший условный reduce-only ордер позиции.
+func (s *Simulated) conditional(symbol, side, stopOrderType string, price, qty float64, maker bool) {
+	orderType := "Market"
+	if maker {
+		orderType = "Limit"
+	}
+	o := s.newOrder(symbol, side, orderType, price, qty)
+	o.StopOrderType = stopOrderType
+	o.ReduceOnly = true
+	s.fill(o, price, maker)
+}
+
+// marketPrice — цена рыночного исполнения стороны side: ask для покупки, bid для продажи.
+func (s *Simulated) marketPrice(symbol, side string) float64 {
+	if side == "Buy" {
+		return s.quotes[symbol].ask
+	}
+	return s.quotes[symbol].bid
+}
+
+func (s *Simulated) newOrder(symbol, side, orderType string, price, qty float64) *model.OrderUpdate {
+	s.nextID++
+	return &model.OrderUpdate{
+		Category:    s.Category,
+		Symbol:      symbol,
+		OrderID:     fmt.Sprintf("%s-%d", s.IDPrefix, s.nextID),
+		Side:        side,
+		OrderType:   orderType,
+		Price:       types.Number(price),
+		Qty:         types.Number(qty),
+		LeavesQty:   types.Number(qty),
+		OrderStatus: "New",
+		CreatedTime: types.Time(s.now),
+		UpdatedTime: types.Time(s.now),
+	}
+}
+
+// fill исполняет остаток ордера по цене price. Reduce-only ордер урезается до объёма позиции.
+func (s *Simulated) fill(o *model.OrderUpdate, price float64, maker bool) {
+	qty := o.LeavesQty.Float64()
+	if o.ReduceOnly {
+		pos := s.positions[o.Symbol]
+		if pos == nil || pos.side == o.Side {
+			s.finish(o, "Cancelled")
+			return
+		}
+		qty = math.Min(qty, pos.size)
+	}
+	rate := s.TakerFee
+	if maker {
+		rate = s.MakerFee
+	}
+	fee := price * qty * rate
+	closedSize := s.applyFill(o.Symbol, o.Side, qty, price, fee)
+
+	o.AvgPrice = types.Number(price)
+	o.CumExecQty = types.Number(qty)
+	o.CumExecFee = types.Number(fee)
+	o.LeavesQty = 0
+	if pos := s.positions[o.Symbol]; pos != nil && pos.side == o.Side && !o.ReduceOnly {
+		if sl := o.StopLoss.Float64(); sl > 0 {
+			pos.stopLoss = sl
+		}
+		if tp := o.TakeProfit.Float64(); tp > 0 {
+			pos.takeProfit = tp
+		}
+	}
+
+	s.nextID++
+	execution := model.ExecutionUpdate{
+		Category:      s.Category,
+		Symbol:        o.Symbol,
+		ExecID:        fmt.Sprintf("%s-exec-%d", s.IDPrefix, s.nextID),
+		OrderID:       o.OrderID,
+		Side:          o.Side,
+		OrderType:     o.OrderType,
+		StopOrderType: o.StopOrderType,
+		ExecPrice:     types.Number(price),
+		ExecQty:       types.Number(qty),
+		ExecValue:     types.Number(price * qty),
+		ExecFee:       types.Number(fee),
+		FeeRate:       types.Number(rate),
+		ExecType:      "Trade",
+		IsMaker:       maker,
+		ClosedSize:    types.Number(closedSize),
+		MarkPrice:     types.Number(s.prices[o.Symbol]),
+		ExecTime:      types.Time(s.now),
+	}
+	s.executions = append(s.executions, execution)
+	s.pendingExecutions = append(s.pendingExecutions, execution)
+	s.finish(o, "Filled")
+}
+
+// applyFill меняет позицию и баланс на исполнение и возвращает закрытый объём.
+func (s *Simulated) applyFill(symbol, side string, qty, price, fee float64) float64 {
+	s.balance -= fee
+	pos := s.positions[symbol]
+	if pos == nil {
+		s.positions[symbol] = &simPosition{side: side, size: qty, entry: price, createdAt: s.now, updatedAt: s.now}
+		return 0
+	}
+	pos.updatedAt = s.now
+	if pos.side == side {
+		pos.entry = (pos.entry*pos.size + price*qty) / (pos.size + qty)
+		pos.size += qty
+		return 0
+	}
+	closeQty := math.Min(qty, pos.size)
+	direction := 1.0
+	if pos.side == "Sell" {
+		direction = -1
+	}
+	pnl := (price - pos.entry) * closeQty * direction
+	s.balance += pnl
+	pos.realized += pnl
+	pos.size -= closeQty
+	if pos.size <= 1e-12 {
+		delete(s.positions, symbol)
+		if rest := qty - closeQty; rest > 1e-12 {
+			s.positions[symbol] = &simPosition{side: side, size: rest, entry: price, createdAt: s.now, updatedAt: s.now}
+		}
+	}
+	return closeQty
+}
+
+func (s *Simulated) finish(o *model.OrderUpdate, status string) {
+	o.OrderStatus = status
+	o.UpdatedTime = types.Time(s.now)
+	s.closed[o.OrderID] = o
+	s.pendingOrders = append(s.pendingOrders, *o)
+}
+
+func (s *Simulated) reject(op string, code int, format string, args ...interface{}) error {
+	return &APIError{Op: op, Kind: ErrRejected, RetCode: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Simulated) limits(symbol string) model.TradeLimits {
+	limits, ok := s.Limits[symbol]
+	if !ok {
+		limits = DefaultSimLimits
+	}
+	limits.Symbol = symbol
+	return limits
+}
+
+// availableMargin — баланс с нереализованным PnL за вычетом маржи позиций и открытых ордеров.
+func (s *Simulated) availableMargin() float64 {
+	leverage := math.Max(1, s.Leverage)
+	available := s.balance
+	for symbol, pos := range s.positions {
+		available -= pos.entry * pos.size / leverage
+		if price, ok := s.prices[symbol]; ok {
+			available += s.unrealized(pos, price)
+		}
+	}
+	for _, o := range s.open {
+		if !o.ReduceOnly {
+			available -= o.Price.Float64() * o.LeavesQty.Float64() / leverage
+		}
+	}
+	return available
+}
+
+func (s *Simulated) unrealized(pos *simPosition, price float64) float64 {
+	if pos.side == "Sell" {
+		return (pos.entry - price) * pos.size
+	}
+	return (price - pos.entry) * pos.size
+}
+
+func (s *Simulated) GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error) {
+	minutes, err := strconv.Atoi(interval)
+	if err != nil || minutes <= 0 {
+		return nil, s.reject("GetKlines", retCodeParamsError, "unsupported interval: %s", interval)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(minutes) * time.Minute.Milliseconds()
+	var bars []model.KlineData
+	for _, m := range s.klines[symbol] {
+		start := m.Start - m.Start%size
+		if n := len(bars); n > 0 && bars[n-1].Start == start {
+			bar := &bars[n-1]
+			bar.High = math.Max(bar.High, m.High)
+			bar.Low = math.Min(bar.Low, m.Low)
+			bar.Close = m.Close
+			bar.Volume += m.Volume
+			bar.Turnover += m.Turnover
+			continue
+		}
+		m.Start, m.End, m.Timestamp, m.Interval = start, start+size-1, start, interval
+		bars = append(bars, m)
+	}
+	for i := range bars {
+		bars[i].Confirm = bars[i].End < s.now.UnixMilli()
+	}
+	if limit > 0 && uint64(len(bars)) > limit {
+		bars = bars[uint64(len(bars))-limit:]
+	}
+	out := make([]model.KlineData, len(bars))
+	for i := range bars {
+		out[len(bars)-1-i] = bars[i]
+	}
+	return out, nil
+}
+
+// GetDepth строит ордербук из limit уровней по шагу цены вокруг текущей цены.
+func (s *Simulated) GetDepth(symbol, _ string, limit int64) (*model.OrderbookData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	price, ok := s.prices[symbol]
+	if !ok {
+		return nil, s.reject("GetOrderBook", retCodeParamsError, "no price for %s", symbol)
+	}
+	tick := s.limits(symbol).TickSize
+	book := &model.OrderbookData{Symbol: symbol, Ts: s.now.UnixMilli(), Cts: s.now.UnixMilli()}
+	for i := int64(1); i <= max(1, limit); i++ {
+		book.Bids = append(book.Bids, model.PriceLevel{Price: price - float64(i)*tick, Size: 1})
+		book.Asks = append(book.Asks, model.PriceLevel{Price: price + float64(i)*tick, Size: 1})
+	}
+	return book, nil
+}
+
+func (s *Simulated) GetTickers(_, symbol string) ([]model.WSTickerPrice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.WSTickerPrice
+	for sym, price := range s.prices {
+		if symbol == "" || sym == symbol {
+			out = append(out, model.WSTickerPrice{Symbol: sym, Price: price})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+func (s *Simulated) GetTradeLimitsViaInstruments(_, symbol string) (model.TradeLimits, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limits(symbol), nil
+}
+
+func (s *Simulated) GetTradingFees(string, string) (float64, error) {
+	return s.TakerFee, nil
+}
+
+func (s *Simulated) GetWalletInfo(coin string) (*model.WalletInfoRep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := &model.WalletInfoRep{Coin: coin, RecordedAt: s.now, CreatedAt: s.now, UpdatedAt: s.now}
+	if coin != s.Coin {
+		return info, nil
+	}
+	info.WalletBalance = s.balance
+	info.TotalWalletBalance = s.balance
+	info.TotalMarginBalance = s.balance
+	for symbol, pos := range s.positions {
+		if price, ok := s.prices[symbol]; ok {
+			info.TotalMarginBalance += s.unrealized(pos, price)
+		}
+	}
+	return info, nil
+}
+
+// PlaceOrder принимает лимитный или рыночный ордер. Рыночный и пересекающий цену лимитный
+// ордер исполняются сразу по текущей цене как тейкер.
+func (s *Simulated) PlaceOrder(symbol, side, orderType string, price, qty, stopLoss, takeProfit float64) (string, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	bybitSide, err := normalizeSide(side)
+	if err != nil {
+		return "", s.reject("PlaceOrder", retCodeParamsError, "%v", err)
+	}
+	_, havePrice := s.prices[symbol]
+	current := s.marketPrice(symbol, bybitSide)
+	market := strings.EqualFold(orderType, "market")
+	switch {
+	case !market && !strings.EqualFold(orderType, "limit"):
+		return "", s.reject("PlaceOrder", retCodeParamsError, "unsupported order type: %s", orderType)
+	case market && !havePrice:
+		return "", s.reject("PlaceOrder", retCodeParamsError, "no price for %s", symbol)
+	case !market && price <= 0:
+		return "", s.reject("PlaceOrder", retCodeParamsError, "invalid price %v", price)
+	}
+	limits := s.limits(symbol)
+	if qty < limits.MinQuantity || (limits.MaxQuantity > 0 && qty > limits.MaxQuantity) {
+		return "", s.reject("PlaceOrder", retCodeParamsError, "qty %v out of [%v, %v]", qty, limits.MinQuantity, limits.MaxQuantity)
+	}
+	crosses := havePrice && ((bybitSide == "Buy" && current <= price) || (bybitSide == "Sell" && current >= price))
+	execPrice := price
+	if market || crosses {
+		execPrice = current
+	}
+	required := execPrice*qty/math.Max(1, s.Leverage) + execPrice*qty*s.TakerFee
+	if available := s.availableMargin(); required > available {
+		return "", s.reject("PlaceOrder", retCodeInsufficientFunds, "ab not enough for new order: need %.4f, available %.4f", required, available)
+	}
+
+	o := s.newOrder(symbol, bybitSide, "Limit", price, qty)
+	if market {
+		o.OrderType = "Market"
+	}
+	o.StopLoss = types.Number(stopLoss)
+	o.TakeProfit = types.Number(takeProfit)
+	s.pendingOrders = append(s.pendingOrders, *o)
+	if market || crosses {
+		s.fill(o, execPrice, false)
+		s.triggerStops(symbol, s.prices[symbol])
+		return o.OrderID, nil
+	}
+	s.open = append(s.open, o)
+	return o.OrderID, nil
+}
+
+func (s *Simulated) ClosePosition(_, symbol, side string, qty float64) error {
+	s.mu.Lock()
+	defer s.unlock()
+	bybitSide, err := normalizeSide(side)
+	if err != nil {
+		return s.reject("PlaceOrder", retCodeParamsError, "%v", err)
+	}
+	if _, ok := s.prices[symbol]; !ok {
+		return s.reject("PlaceOrder", retCodeParamsError, "no price for %s", symbol)
+	}
+	price := s.marketPrice(symbol, bybitSide)
+	o := s.newOrder(symbol, bybitSide, "Market", price, qty)
+	o.ReduceOnly = true
+	s.pendingOrders = append(s.pendingOrders, *o)
+	s.fill(o, price, false)
+	return nil
+}
+
+// SetStopLoss переносит SL позиции на весь объём.
+func (s *Simulated) SetStopLoss(_, symbol string, stopLoss float64) error {
+	s.mu.Lock()
+	defer s.unlock()
+	pos := s.positions[symbol]
+	if pos == nil {
+		return s.reject("SetTradingStop", retCodeParamsError, "no position for %s", symbol)
+	}
+	pos.stopLoss = stopLoss
+	s.triggerStops(symbol, s.prices[symbol])
+	return nil
+}
+
+// SetPartialTakeProfit добавляет лимитный TP на часть позиции.
+func (s *Simulated) SetPartialTakeProfit(_, symbol string, price, qty float64) error {
+	s.mu.Lock()
+	defer s.unlock()
+	pos := s.positions[symbol]
+	if pos == nil {
+		return s.reject("SetTradingStop", retCodeParamsError, "no position for %s", symbol)
+	}
+	s.nextID++
+	pos.partials = append(pos.partials, simPartial{
+		id:        fmt.Sprintf("%s-%d", s.IDPrefix, s.nextID),
+		price:     price,
+		qty:       qty,
+		createdAt: s.now,
+	})
+	long := pos.side == "Buy"
+	sort.SliceStable(pos.partials, func(i, j int) bool {
+		if long {
+			return pos.partials[i].price < pos.partials[j].price
+		}
+		return pos.partials[i].price > pos.partials[j].price
+	})
+	return nil
+}
+
+func (s *Simulated) CancelOrder(_, symbol, orderID string) error {
+	s.mu.Lock()
+	defer s.unlock()
+	for i, o := range s.open {
+		if o.OrderID == orderID && o.Symbol == symbol {
+			s.open = append(s.open[:i], s.open[i+1:]...)
+			s.finish(o, "Cancelled")
+			return nil
+		}
+	}
+	if pos := s.positions[symbol]; pos != nil {
+		for i, tp := range pos.partials {
+			if tp.id == orderID {
+				pos.partials = append(pos.partials[:i], pos.partials[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return s.reject("CancelTradeOrder", retCodeOrderNotExists, "order not exists or too late to cancel")
+}
+
+// AmendOrder меняет цену и/или объём открытого ордера; ставший пересекающим ордер исполняется.
+func (s *Simulated) AmendOrder(_, symbol, orderID string, price, qty float64) error {
+	s.mu.Lock()
+	defer s.unlock()
+	for _, o := range s.open {
+		if o.OrderID != orderID || o.Symbol != symbol {
+			continue
+		}
+		if price > 0 {
+			o.Price = types.Number(price)
+		}
+		if qty > 0 {
+			o.Qty = types.Number(qty)
+			o.LeavesQty = types.Number(qty)
+		}
+		o.UpdatedTime = types.Time(s.now)
+		s.pendingOrders = append(s.pendingOrders, *o)
+		if current, ok := s.prices[symbol]; ok {
+			s.matchOrders(symbol)
+			s.triggerStops(symbol, current)
+		}
+		return nil
+	}
+	return s.reject("AmendOrder", retCodeOrderNotExists, "order not exists or too late to replace")
+}
+
+func (s *Simulated) GetOrderStatus(_, symbol, orderID string) (*model.OrderUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.open {
+		if o.OrderID == orderID && o.Symbol == symbol {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	if o, ok := s.closed[orderID]; ok && o.Symbol == symbol {
+		copied := *o
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// ListOpenOrders возвращает открытые ордера и частичные TP позиций символа; пустой symbol — всех символов.
+func (s *Simulated) ListOpenOrders(_, symbol string) ([]model.OrderUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.OrderUpdate
+	for _, o := range s.open {
+		if symbol == "" || o.Symbol == symbol {
+			out = append(out, *o)
+		}
+	}
+	// Частичные TP, как и на Bybit, видны ожидающими срабатывания условными ордерами.
+	symbols := make([]string, 0, len(s.positions))
+	for sym := range s.positions {
+		if symbol == "" || sym == symbol {
+			symbols = append(symbols, sym)
+		}
+	}
+	sort.Strings(symbols)
+	for _, sym := range symbols {
+		pos := s.positions[sym]
+		for _, tp := range pos.partials {
+			out = append(out, model.OrderUpdate{
+				Category:      s.Category,
+				Symbol:        sym,
+				OrderID:       tp.id,
+				Side:          opposite(pos.side),
+				OrderType:     "Limit",
+				Price:         types.Number(tp.price),
+				Qty:           types.Number(tp.qty),
+				LeavesQty:     types.Number(tp.qty),
+				OrderStatus:   "Untriggered",
+				StopOrderType: "PartialTakeProfit",
+				ReduceOnly:    true,
+				CreatedTime:   types.Time(tp.createdAt),
+				UpdatedTime:   types.Time(tp.createdAt),
+			})
+		}
+	}
+	return out, nil
+}
+
+// GetPositions возвращает позицию символа; как и Bybit в one-way режиме, для пустой
+// позиции отдаёт запись с нулевым объёмом.
+func (s *Simulated) GetPositions(category, symbol string) ([]model.PositionUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbols = symbols[:0]
+		for sym := range s.positions {
+			symbols = append(symbols, sym)
+		}
+		sort.Strings(symbols)
+	}
+	out := make([]model.PositionUpdate, 0, len(symbols))
+	for _, sym := range symbols {
+		update := model.PositionUpdate{Category: category, Symbol: sym, PositionStatus: "Normal", TpslMode: "Full",
+			Leverage: types.Number(math.Max(1, s.Leverage))}
+		if pos := s.positions[sym]; pos != nil {
+			price := s.prices[sym]
+			update.Side = pos.side
+			update.Size = types.Number(pos.size)
+			update.EntryPrice = types.Number(pos.entry)
+			update.MarkPrice = types.Number(price)
+			update.PositionValue = types.Number(pos.entry * pos.size)
+			update.UnrealisedPnl = types.Number(s.unrealized(pos, price))
+			update.CumRealisedPnl = types.Number(pos.realized)
+			update.StopLoss = types.Number(pos.stopLoss)
+			update.TakeProfit = types.Number(pos.takeProfit)
+			update.CreatedTime = types.Time(pos.createdAt)
+			update.UpdatedTime = types.Time(pos.updatedAt)
+		}
+		out = append(out, update)
+	}
+	return out, nil
+}
+
+func (s *Simulated) GetExecutions(_, symbol string, since time.Time) ([]model.ExecutionUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.ExecutionUpdate
+	for _, e := range s.executions {
+		if (symbol == "" || e.Symbol == symbol) && !e.ExecTime.Time().Before(since) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func normalizeSide(side string) (string, error) {
+	switch strings.ToLower(side) {
+	case "buy":
+		return "Buy", nil
+	case "sell":
+		return "Sell", nil
+	default:
+		return "", fmt.Errorf("invalid order side: %s", side)
+	}
+}
+
+func opposite(side string) string {
+	if side == "Buy" {
+		return "Sell"
+	}
+	return "Buy"
+}
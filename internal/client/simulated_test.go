@@ -0,0 +1,230 @@
+package client
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// simAction — шаг сценария теста Simulated; ошибка допустима только у последнего шага.
+type simAction func(s *Simulated) error
+
+// simFill — ожидаемое исполнение.
+type simFill struct {
+	side          string
+	stopOrderType string
+	price         float64
+	qty           float64
+	maker         bool
+}
+
+var simStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// simNow — время следующего шага: через секунду после предыдущего.
+func simNow(s *Simulated) time.Time {
+	if s.now.IsZero() {
+		return simStart
+	}
+	return s.now.Add(time.Second)
+}
+
+func step(prices ...float64) simAction {
+	return func(s *Simulated) error {
+		path := make([]PricePoint, len(prices))
+		for i, price := range prices {
+			path[i] = PricePoint{Time: simNow(s).Add(time.Duration(i) * time.Second), Price: price, Volume: 1}
+		}
+		s.Play("BTCUSDT", path)
+		return nil
+	}
+}
+
+func quote(bid, ask float64) simAction {
+	return func(s *Simulated) error {
+		s.Quote("BTCUSDT", simNow(s), bid, ask)
+		return nil
+	}
+}
+
+func placeOrder(side, orderType string, price, qty, stopLoss, takeProfit float64) simAction {
+	return func(s *Simulated) error {
+		_, err := s.PlaceOrder("BTCUSDT", side, orderType, price, qty, stopLoss, takeProfit)
+		return err
+	}
+}
+
+func stopLoss(price float64) simAction {
+	return func(s *Simulated) error { return s.SetStopLoss("linear", "BTCUSDT", price) }
+}
+
+func partialTakeProfit(price, qty float64) simAction {
+	return func(s *Simulated) error { return s.SetPartialTakeProfit("linear", "BTCUSDT", price, qty) }
+}
+
+func closePosition(side string, qty float64) simAction {
+	return func(s *Simulated) error { return s.ClosePosition("linear", "BTCUSDT", side, qty) }
+}
+
+func TestSimulatedScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  float64
+		script   []simAction
+		retCode  int // код отказа последнего шага; 0 — без ошибки
+		fills    []simFill
+		position float64 // объём позиции в конце: > 0 — long, < 0 — short
+	}{
+		{
+			name:     "resting limit fills as maker at its price",
+			script:   []simAction{step(100), placeOrder("Buy", "Limit", 99, 1, 0, 0), step(99.5, 98.7)},
+			fills:    []simFill{{side: "Buy", price: 99, qty: 1, maker: true}},
+			position: 1,
+		},
+		{
+			name:     "crossing limit fills as taker at the current price",
+			script:   []simAction{step(100), placeOrder("Buy", "Limit", 101, 1, 0, 0)},
+			fills:    []simFill{{side: "Buy", price: 100, qty: 1}},
+			position: 1,
+		},
+		{
+			name:     "market order fills at the quote side",
+			script:   []simAction{quote(99.9, 100.1), placeOrder("Sell", "Market", 0, 2, 0, 0)},
+			fills:    []simFill{{side: "Sell", price: 99.9, qty: 2}},
+			position: -2,
+		},
+		{
+			name: "limit buy fills when the ask reaches it",
+			script: []simAction{
+				quote(100, 100.2), placeOrder("Buy", "Limit", 100, 1, 0, 0),
+				quote(99.9, 100.1), quote(99.8, 100),
+			},
+			fills:    []simFill{{side: "Buy", price: 100, qty: 1, maker: true}},
+			position: 1,
+		},
+		{
+			name:   "stop loss fills as taker at the bid",
+			script: []simAction{quote(99.9, 100.1), placeOrder("Buy", "Market", 0, 1, 95, 110), quote(96, 96.2), quote(94.8, 95)},
+			fills: []simFill{
+				{side: "Buy", price: 100.1, qty: 1},
+				{side: "Sell", stopOrderType: "StopLoss", price: 94.8, qty: 1},
+			},
+		},
+		{
+			name:   "stop loss wins over take profit on the same step",
+			script: []simAction{step(101), placeOrder("Buy", "Limit", 100, 1, 100, 100), step(100)},
+			fills: []simFill{
+				{side: "Buy", price: 100, qty: 1, maker: true},
+				{side: "Sell", stopOrderType: "StopLoss", price: 100, qty: 1},
+			},
+		},
+		{
+			name: "partial take profits fill as makers at their prices",
+			script: []simAction{
+				step(100), placeOrder("Buy", "Market", 0, 1, 90, 0),
+				partialTakeProfit(110, 0.3), partialTakeProfit(105, 0.3), step(104, 112),
+			},
+			fills: []simFill{
+				{side: "Buy", price: 100, qty: 1},
+				{side: "Sell", stopOrderType: "PartialTakeProfit", price: 105, qty: 0.3, maker: true},
+				{side: "Sell", stopOrderType: "PartialTakeProfit", price: 110, qty: 0.3, maker: true},
+			},
+			position: 0.4,
+		},
+		{
+			name:   "stop loss moved past the price triggers at once",
+			script: []simAction{step(100), placeOrder("Sell", "Market", 0, 1, 0, 0), step(95), stopLoss(94)},
+			fills: []simFill{
+				{side: "Sell", price: 100, qty: 1},
+				{side: "Buy", stopOrderType: "StopLoss", price: 95, qty: 1},
+			},
+		},
+		{
+			name:   "reduce-only close is trimmed to the position",
+			script: []simAction{step(100), placeOrder("Buy", "Market", 0, 1, 0, 0), closePosition("Sell", 3)},
+			fills: []simFill{
+				{side: "Buy", price: 100, qty: 1},
+				{side: "Sell", price: 100, qty: 1},
+			},
+		},
+		{
+			name: "partial take profit is trimmed to the rest of the position",
+			script: []simAction{
+				step(100), placeOrder("Buy", "Market", 0, 1, 0, 0),
+				partialTakeProfit(105, 0.7), partialTakeProfit(106, 0.7), step(107),
+			},
+			fills: []simFill{
+				{side: "Buy", price: 100, qty: 1},
+				{side: "Sell", stopOrderType: "PartialTakeProfit", price: 105, qty: 0.7, maker: true},
+				{side: "Sell", stopOrderType: "PartialTakeProfit", price: 106, qty: 0.3, maker: true},
+			},
+		},
+		{
+			name:    "order above the available margin is rejected",
+			balance: 100,
+			script:  []simAction{step(100), placeOrder("Buy", "Market", 0, 1, 0, 0)},
+			retCode: retCodeInsufficientFunds,
+		},
+		{
+			name:    "resting orders reserve margin",
+			balance: 1000,
+			script:  []simAction{step(100), placeOrder("Buy", "Limit", 90, 10, 0, 0), placeOrder("Buy", "Limit", 90, 2, 0, 0)},
+			retCode: retCodeInsufficientFunds,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance := tt.balance
+			if balance == 0 {
+				balance = 10000
+			}
+			s := NewSimulated(balance)
+			for i, action := range tt.script {
+				err := action(s)
+				if i < len(tt.script)-1 || tt.retCode == 0 {
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					continue
+				}
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.RetCode != tt.retCode || !errors.Is(err, ErrRejected) {
+					t.Fatalf("step %d: error %v, want rejection %d", i, err, tt.retCode)
+				}
+			}
+
+			executions, err := s.GetExecutions("linear", "BTCUSDT", time.Time{})
+			if err != nil {
+				t.Fatalf("GetExecutions: %v", err)
+			}
+			if len(executions) != len(tt.fills) {
+				t.Fatalf("%d executions %+v, want %d", len(executions), executions, len(tt.fills))
+			}
+			for i, want := range tt.fills {
+				e := executions[i]
+				rate := s.TakerFee
+				if want.maker {
+					rate = s.MakerFee
+				}
+				got := simFill{side: e.Side, stopOrderType: e.StopOrderType, price: e.ExecPrice.Float64(), qty: e.ExecQty.Float64(), maker: e.IsMaker}
+				if math.Abs(got.qty-want.qty) < 1e-9 {
+					got.qty = want.qty
+				}
+				if got != want || e.FeeRate.Float64() != rate {
+					t.Fatalf("execution %d = %+v with fee rate %v, want %+v with %v", i, got, e.FeeRate.Float64(), want, rate)
+				}
+			}
+
+			var position float64
+			if pos := s.positions["BTCUSDT"]; pos != nil {
+				position = pos.size
+				if pos.side == "Sell" {
+					position = -position
+				}
+			}
+			if math.Abs(position-tt.position) > 1e-9 {
+				t.Fatalf("position %v, want %v", position, tt.position)
+			}
+		})
+	}
+}
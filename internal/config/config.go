@@ -0,0 +1,437 @@
+package config
+
+import (
+	"bybit-bot/internal/model"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvPrefix — префикс переменных окружения, переопределяющих значения из файла.
+// Имя переменной строится из пути ключа: exchange.environment -> BYBIT_BOT_EXCHANGE_ENVIRONMENT.
+const EnvPrefix = "BYBIT_BOT"
+
+// Окружения биржи.
+const (
+	EnvironmentDemo    = "demo"
+	EnvironmentTestnet = "testnet"
+	EnvironmentMainnet = "mainnet"
+)
+
+// Config — полная конфигурация бота и бэктеста.
+type Config struct {
+	Database    DatabaseConfig  `yaml:"database" toml:"database"`
+	Exchange    ExchangeConfig  `yaml:"exchange" toml:"exchange"`
+	Trading     TradingConfig   `yaml:"trading" toml:"trading"`
+	Strategy    StrategyConfig  `yaml:"strategy" toml:"strategy"`
+	Risk        RiskConfig      `yaml:"risk" toml:"risk"`
+	Orders      OrdersConfig    `yaml:"orders" toml:"orders"`
+	Reconcile   ReconcileConfig `yaml:"reconcile" toml:"reconcile"`
+	Paper       PaperConfig     `yaml:"paper" toml:"paper"`
+	Notify      NotifyConfig    `yaml:"notify" toml:"notify"`
+	Backtest    BacktestConfig  `yaml:"backtest" toml:"backtest"`
+	Logging     LoggingConfig   `yaml:"logging" toml:"logging"`
+	SecretsFile string          `yaml:"secrets_file" toml:"secrets_file"`
+}
+
+// Хранилища данных бота (database.driver).
+const (
+	DatabasePostgres = "postgres"
+	DatabaseSQLite   = "sqlite"
+	DatabaseMemory   = "memory"
+)
+
+// DatabaseConfig выбирает хранилище. Для sqlite DSN — путь к файлу базы, для memory не нужен.
+type DatabaseConfig struct {
+	Driver string `yaml:"driver" toml:"driver"` // postgres | sqlite | memory
+	DSN    string `yaml:"dsn" toml:"dsn"`
+}
+
+// ExchangeConfig описывает окружение Bybit. Пустые URL заполняются значениями по умолчанию для окружения.
+type ExchangeConfig struct {
+	Environment  string `yaml:"environment" toml:"environment"`
+	Category     string `yaml:"category" toml:"category"`
+	RestURL      string `yaml:"rest_url" toml:"rest_url"`
+	PublicWSURL  string `yaml:"public_ws_url" toml:"public_ws_url"`
+	PrivateWSURL string `yaml:"private_ws_url" toml:"private_ws_url"`
+
+	RequestTimeout Duration `yaml:"request_timeout" toml:"request_timeout"` // предел одной попытки REST-запроса
+	MaxAttempts    int      `yaml:"max_attempts" toml:"max_attempts"`       // попыток на временные ошибки, включая первую
+}
+
+type TradingConfig struct {
+	Symbols       []string `yaml:"symbols" toml:"symbols"`
+	Interval      string   `yaml:"interval" toml:"interval"`             // интервал свечей для сигналов
+	KlineInterval string   `yaml:"kline_interval" toml:"kline_interval"` // интервал свечей WebSocket
+	WarmUp        Duration `yaml:"warm_up" toml:"warm_up"`               // ожидание данных WebSocket перед стартом
+	RestartDelay  Duration `yaml:"restart_delay" toml:"restart_delay"`   // пауза перед перезапуском упавшего воркера символа
+}
+
+type StrategyConfig struct {
+	Name                 string   `yaml:"name" toml:"name"`
+	TickInterval         Duration `yaml:"tick_interval" toml:"tick_interval"`
+	MaxDataAge           Duration `yaml:"max_data_age" toml:"max_data_age"`
+	MaxMidPriceDeviation float64  `yaml:"max_mid_price_deviation" toml:"max_mid_price_deviation"`
+
+	Signal model.SignalParams `yaml:"signal" toml:"signal"`
+	SLTP   model.SLTPParams   `yaml:"sltp" toml:"sltp"`
+	Manage model.ManageParams `yaml:"manage" toml:"manage"`
+	// ManageInterval — период проверки открытых позиций при сопровождении (trades.Manager).
+	ManageInterval Duration `yaml:"manage_interval" toml:"manage_interval"`
+}
+
+type RiskConfig struct {
+	MaxPositionPercent float64      `yaml:"max_position_percent" toml:"max_position_percent"` // доля баланса (маржи) на одну позицию
+	Sizing             SizingConfig `yaml:"sizing" toml:"sizing"`
+	Guard              GuardConfig  `yaml:"guard" toml:"guard"`
+}
+
+// GuardConfig — ограничения уровня аккаунта (risk.Guard). Нулевое значение отключает проверку.
+type GuardConfig struct {
+	CheckInterval        Duration `yaml:"check_interval" toml:"check_interval"`                 // периодическая проверка убытка и просадки
+	MaxDailyLoss         float64  `yaml:"max_daily_loss" toml:"max_daily_loss"`                 // реализованный убыток за сутки UTC, USDT
+	MaxDrawdown          float64  `yaml:"max_drawdown" toml:"max_drawdown"`                     // просадка капитала от пика, доля
+	DrawdownWindow       Duration `yaml:"drawdown_window" toml:"drawdown_window"`               // окно поиска пика; 0 — вся история
+	MaxOpenPositions     int      `yaml:"max_open_positions" toml:"max_open_positions"`         // одновременно открытых позиций
+	MaxSymbolNotional    float64  `yaml:"max_symbol_notional" toml:"max_symbol_notional"`       // стоимость позиций и нового ордера по символу, USDT
+	MaxTotalNotional     float64  `yaml:"max_total_notional" toml:"max_total_notional"`         // стоимость всех позиций и нового ордера, USDT
+	MaxOrdersPerHour     int      `yaml:"max_orders_per_hour" toml:"max_orders_per_hour"`       // новых ордеров за скользящий час
+	MaxConsecutiveLosses int      `yaml:"max_consecutive_losses" toml:"max_consecutive_losses"` // убыточных сделок подряд до паузы
+	Cooldown             Duration `yaml:"cooldown" toml:"cooldown"`                             // пауза после серии убытков
+	FlattenOnHalt        bool     `yaml:"flatten_on_halt" toml:"flatten_on_halt"`               // закрывать позиции и снимать ордера при остановке по убытку или просадке
+}
+
+// NotifyConfig — доставка уведомлений оператору.
+type NotifyConfig struct {
+	WebhookURL string `yaml:"webhook_url" toml:"webhook_url"` // пусто — только журнал
+}
+
+// SizingConfig — расчёт объёма позиции (exchange.PriceCalculator). Во всех режимах маржа
+// позиции с комиссией входа ограничена долей max_position_percent от капитала.
+type SizingConfig struct {
+	Mode          string  `yaml:"mode" toml:"mode"`                     // fixed_notional | percent_equity | fixed_fractional | atr | kelly
+	FixedNotional float64 `yaml:"fixed_notional" toml:"fixed_notional"` // стоимость позиции для fixed_notional, USDT
+	RiskPercent   float64 `yaml:"risk_percent" toml:"risk_percent"`     // доля капитала под риск на сделку (fixed_fractional, atr)
+	ATRMultiplier float64 `yaml:"atr_multiplier" toml:"atr_multiplier"` // расстояние до стопа в ATR для режима atr
+	KellyWinRate  float64 `yaml:"kelly_win_rate" toml:"kelly_win_rate"` // доля прибыльных сделок
+	KellyPayoff   float64 `yaml:"kelly_payoff" toml:"kelly_payoff"`     // средняя прибыль / средний убыток
+	KellyFraction float64 `yaml:"kelly_fraction" toml:"kelly_fraction"` // множитель Келли (0.5 — половина Келли)
+	KellyCap      float64 `yaml:"kelly_cap" toml:"kelly_cap"`           // максимальная доля капитала под риск
+	Leverage      float64 `yaml:"leverage" toml:"leverage"`             // плечо: маржа = стоимость / leverage
+	FeeRate       float64 `yaml:"fee_rate" toml:"fee_rate"`             // комиссия входа и выхода, доля
+}
+
+// Действия с лимитным ордером, не исполненным вовремя.
+const (
+	OrderActionCancel  = "cancel"
+	OrderActionReprice = "reprice"
+)
+
+// OrdersConfig — сопровождение размещённых лимитных ордеров (orders.Manager).
+type OrdersConfig struct {
+	CheckInterval Duration `yaml:"check_interval" toml:"check_interval"`
+	Timeout       Duration `yaml:"timeout" toml:"timeout"`                 // время жизни лимита без исполнения; 0 — без ограничения
+	MaxPriceDrift float64  `yaml:"max_price_drift" toml:"max_price_drift"` // уход лучшей цены от лимита, доля; 0 — не проверять
+	Action        string   `yaml:"action" toml:"action"`                   // cancel | reprice
+	MaxReprices   int      `yaml:"max_reprices" toml:"max_reprices"`       // после стольких перестановок ордер отменяется
+}
+
+// ReconcileConfig — сверка БД с биржей при запуске и по расписанию.
+type ReconcileConfig struct {
+	Interval     Duration `yaml:"interval" toml:"interval"`           // 0 — только при запуске
+	AdoptOrphans bool     `yaml:"adopt_orphans" toml:"adopt_orphans"` // принимать неизвестные ордера и позиции, иначе только помечать
+}
+
+// PaperConfig — бумажная торговля (cmd/app -paper): ордера исполняются локально по живому
+// ордербуку, виртуальный кошелёк сохраняется в БД.
+type PaperConfig struct {
+	InitialBalance float64  `yaml:"initial_balance" toml:"initial_balance"` // стартовый баланс USDT, если в БД ещё нет снимка кошелька
+	MakerFee       float64  `yaml:"maker_fee" toml:"maker_fee"`
+	TakerFee       float64  `yaml:"taker_fee" toml:"taker_fee"`
+	Leverage       float64  `yaml:"leverage" toml:"leverage"`
+	Interval       Duration `yaml:"interval" toml:"interval"` // период сопоставления ордеров с ордербуком
+}
+
+type BacktestConfig struct {
+	Symbol   string `yaml:"symbol" toml:"symbol"`
+	Category string `yaml:"category" toml:"category"`
+	Interval string `yaml:"interval" toml:"interval"`
+	Start    Date   `yaml:"start" toml:"start"`       // начало периода; пусто — 30 дней назад
+	End      Date   `yaml:"end" toml:"end"`           // конец периода; пусто — текущий момент
+	DataDir  string `yaml:"data_dir" toml:"data_dir"` // локальное хранилище свечей
+	BaseURL  string `yaml:"base_url" toml:"base_url"` // REST API для исторических свечей
+
+	InitialBalance float64 `yaml:"initial_balance" toml:"initial_balance"` // стартовый баланс USDT симуляции
+	ReportDir      string  `yaml:"report_dir" toml:"report_dir"`           // каталог отчётов JSON и CSV
+
+	Execution ExecutionConfig `yaml:"execution" toml:"execution"`
+	Optimize  OptimizeConfig  `yaml:"optimize" toml:"optimize"`
+}
+
+// OptimizeConfig — настройки подбора параметров (cmd/backtest -optimize).
+type OptimizeConfig struct {
+	Method    string       `yaml:"method" toml:"method"`       // grid | random
+	Objective string       `yaml:"objective" toml:"objective"` // sharpe | sortino | calmar | total_return | profit_factor | expectancy
+	Samples   int          `yaml:"samples" toml:"samples"`     // число наборов для random
+	Workers   int          `yaml:"workers" toml:"workers"`     // 0 — по числу CPU
+	MinTrades int          `yaml:"min_trades" toml:"min_trades"`
+	Seed      int64        `yaml:"seed" toml:"seed"`
+	Top       int          `yaml:"top" toml:"top"` // сколько лучших результатов выводить
+	Params    []ParamRange `yaml:"params" toml:"params"`
+
+	// Walk-forward включается, если заданы оба окна (в свечах).
+	InSampleBars    int `yaml:"in_sample_bars" toml:"in_sample_bars"`
+	OutOfSampleBars int `yaml:"out_of_sample_bars" toml:"out_of_sample_bars"`
+	WarmupBars      int `yaml:"warmup_bars" toml:"warmup_bars"`
+}
+
+// ParamRange — диапазон значений параметра стратегии, например signal.ema_fast.
+type ParamRange struct {
+	Name string  `yaml:"name" toml:"name" json:"name"`
+	Min  float64 `yaml:"min" toml:"min" json:"min"`
+	Max  float64 `yaml:"max" toml:"max" json:"max"`
+	Step float64 `yaml:"step" toml:"step" json:"step"`
+}
+
+// ExecutionConfig — модель исполнения ордеров в бэктесте (см. engine.FillModel).
+type ExecutionConfig struct {
+	MakerFee        float64 `yaml:"maker_fee" toml:"maker_fee"`
+	TakerFee        float64 `yaml:"taker_fee" toml:"taker_fee"`
+	Slippage        string  `yaml:"slippage" toml:"slippage"`           // fixed | volatility
+	SlippageRate    float64 `yaml:"slippage_rate" toml:"slippage_rate"` // доля цены или диапазона свечи
+	FillProbability float64 `yaml:"fill_probability" toml:"fill_probability"`
+	TradeThrough    float64 `yaml:"trade_through" toml:"trade_through"`
+	LatencyBars     int     `yaml:"latency_bars" toml:"latency_bars"`
+	Intrabar        string  `yaml:"intrabar" toml:"intrabar"`             // pessimistic | optimistic | lower_timeframe
+	LowerInterval   string  `yaml:"lower_interval" toml:"lower_interval"` // интервал для lower_timeframe
+	Seed            int64   `yaml:"seed" toml:"seed"`
+}
+
+type LoggingConfig struct {
+	File   string `yaml:"file" toml:"file"`
+	Stdout bool   `yaml:"stdout" toml:"stdout"`
+}
+
+// Duration — time.Duration, который читается из строк вида "13s" или "5m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// Date — момент времени, который читается из "2006-01-02" или RFC3339 (UTC для даты без времени).
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		d.Time = time.Time{}
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, string(text)); err == nil {
+			d.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q, want YYYY-MM-DD or RFC3339", text)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return []byte(d.Format(time.RFC3339)), nil
+}
+
+// Range возвращает период бэктеста с учётом значений по умолчанию.
+func (b BacktestConfig) Range(now time.Time) (start, end time.Time) {
+	end = b.End.Time
+	if end.IsZero() {
+		end = now
+	}
+	start = b.Start.Time
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -30)
+	}
+	return start, end
+}
+
+// Default возвращает конфигурацию со значениями, соответствующими прежним захардкоженным.
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Driver: DatabasePostgres,
+			DSN:    "postgres://postgres@localhost:5433/postgres?sslmode=disable", // пароль — в секретах,
+		},
+		Exchange: ExchangeConfig{
+			Environment:    EnvironmentDemo,
+			Category:       "linear",
+			RequestTimeout: Duration{10 * time.Second},
+			MaxAttempts:    4,
+		},
+		Trading: TradingConfig{
+			Symbols:       []string{"BTCUSDT"},
+			Interval:      model.DefaultSignalInterval,
+			KlineInterval: "1",
+			WarmUp:        Duration{15 * time.Second},
+			RestartDelay:  Duration{10 * time.Second},
+		},
+		Strategy: StrategyConfig{
+			Name:                 "vpa_scalping",
+			TickInterval:         Duration{13 * time.Second},
+			MaxDataAge:           Duration{30 * time.Second},
+			MaxMidPriceDeviation: 0.002,
+			ManageInterval:       Duration{2 * time.Second},
+			Signal:               model.DefaultSignalParams(),
+			SLTP:                 model.DefaultSLTPParams(),
+		},
+		Risk: RiskConfig{
+			MaxPositionPercent: 0.10,
+			Sizing: SizingConfig{
+				Mode:          model.SizingPercentEquity,
+				RiskPercent:   0.01,
+				ATRMultiplier: 1.5,
+				KellyFraction: 0.5,
+				KellyCap:      0.02,
+				Leverage:      1,
+				FeeRate:       0.0010,
+			},
+			Guard: GuardConfig{
+				CheckInterval:        Duration{time.Minute},
+				MaxDrawdown:          0.20,
+				MaxOpenPositions:     3,
+				MaxOrdersPerHour:     20,
+				MaxConsecutiveLosses: 3,
+				Cooldown:             Duration{time.Hour},
+			},
+		},
+		Orders: OrdersConfig{
+			CheckInterval: Duration{5 * time.Second},
+			Timeout:       Duration{2 * time.Minute},
+			MaxPriceDrift: 0.003,
+			Action:        OrderActionReprice,
+			MaxReprices:   2,
+		},
+		Reconcile: ReconcileConfig{
+			Interval: Duration{5 * time.Minute},
+		},
+		Paper: PaperConfig{
+			InitialBalance: 10000,
+			MakerFee:       0.0002,
+			TakerFee:       0.00055,
+			Leverage:       1,
+			Interval:       Duration{250 * time.Millisecond},
+		},
+		Backtest: BacktestConfig{
+			Symbol:   "BTCUSDT",
+			Category: "linear",
+			Interval: "30",
+			DataDir:  "data/klines",
+			BaseURL:  "https://api.bybit.com",
+
+			InitialBalance: 10000,
+			ReportDir:      "reports",
+			Execution: ExecutionConfig{
+				MakerFee:        0.00036,
+				TakerFee:        0.0010,
+				Slippage:        "fixed",
+				FillProbability: 1,
+				Intrabar:        "pessimistic",
+				LowerInterval:   "1",
+			},
+			Optimize: OptimizeConfig{
+				Method:     "grid",
+				Objective:  "sharpe",
+				Samples:    100,
+				MinTrades:  10,
+				Top:        10,
+				WarmupBars: 220,
+			},
+		},
+		Logging: LoggingConfig{
+			File:   "vpa_scalping.log",
+			Stdout: true,
+		},
+	}
+}
+
+// Load читает конфигурацию из YAML или TOML файла (по расширению), применяет переопределения
+// из окружения, заполняет URL по умолчанию и проверяет значения.
+// Пустой path означает конфигурацию по умолчанию с переопределениями из окружения.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, cfg)
+		case ".toml":
+			err = toml.Unmarshal(data, cfg)
+		default:
+			return nil, fmt.Errorf("unsupported config format %q", filepath.Ext(path))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(cfg, EnvPrefix, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.Exchange.applyDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (e *ExchangeConfig) applyDefaults() {
+	var rest, public, private string
+	switch e.Environment {
+	case EnvironmentMainnet:
+		rest = "https://api.bybit.com"
+		public = "wss://stream.bybit.com/v5/public/"
+		private = "wss://stream.bybit.com/v5/private"
+	case EnvironmentTestnet:
+		rest = "https://api-testnet.bybit.com"
+		public = "wss://stream-testnet.bybit.com/v5/public/"
+		private = "wss://stream-testnet.bybit.com/v5/private"
+	case EnvironmentDemo:
+		// Демо-торговля использует публичные данные основной сети.
+		rest = "https://api-demo.bybit.com"
+		public = "wss://stream.bybit.com/v5/public/"
+		private = "wss://stream-demo.bybit.com/v5/private"
+	default:
+		return
+	}
+	if e.RestURL == "" {
+		e.RestURL = rest
+	}
+	if e.PublicWSURL == "" {
+		e.PublicWSURL = public + e.Category
+	}
+	if e.PrivateWSURL == "" {
+		e.PrivateWSURL = private
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyEnv обходит структуру конфигурации и подставляет значения из переменных окружения.
+// Имя переменной — prefix + путь из yaml-тегов в верхнем регистре через "_".
+// Списки строк задаются через запятую: BYBIT_BOT_TRADING_SYMBOLS=BTCUSDT,ETHUSDT.
+func applyEnv(cfg interface{}, prefix string, lookup func(string) (string, bool)) error {
+	return applyEnvValue(reflect.ValueOf(cfg).Elem(), prefix, "", lookup)
+}
+
+func applyEnvValue(v reflect.Value, envName, path string, lookup func(string) (string, bool)) error {
+	if v.Kind() == reflect.Struct && !isTextValue(v) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if key == "" || key == "-" {
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if err := applyEnvValue(v.Field(i), envName+"_"+strings.ToUpper(key), childPath, lookup); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	raw, ok := lookup(envName)
+	if !ok {
+		return nil
+	}
+	if err := setFromString(v, raw); err != nil {
+		return &FieldError{Path: path, Message: fmt.Sprintf("invalid value %q from %s: %v", raw, envName, err)}
+	}
+	return nil
+}
+
+func isTextValue(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+func setFromString(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
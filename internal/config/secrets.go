@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Переменные окружения с ключами API и паролем базы. Имеют приоритет над файлом секретов.
+const (
+	EnvAPIKey           = "BYBIT_API_KEY"
+	EnvAPISecret        = "BYBIT_API_SECRET"
+	EnvDatabasePassword = "BYBIT_DB_PASSWORD"
+)
+
+// Secrets хранит учётные данные отдельно от основной конфигурации,
+// чтобы файл конфигурации можно было коммитить.
+type Secrets struct {
+	APIKey           string `yaml:"api_key"`
+	APISecret        string `yaml:"api_secret"`
+	DatabasePassword string `yaml:"database_password"`
+}
+
+// LoadSecrets читает секреты из YAML файла (если path не пуст) и переменных окружения.
+// Ключи API обязательны.
+func LoadSecrets(path string) (*Secrets, error) {
+	s, err := readSecrets(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{}
+	v.check(s.APIKey != "", "secrets.api_key", "must be set in %s or %s", path, EnvAPIKey)
+	v.check(s.APISecret != "", "secrets.api_secret", "must be set in %s or %s", path, EnvAPISecret)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DatabaseDSN возвращает строку подключения к базе. Для postgres пароль берётся из
+// database_password файла секретов или BYBIT_DB_PASSWORD и подставляется в database.dsn;
+// без пароля подключение возможно, только если он уже указан в самом DSN.
+func (c *Config) DatabaseDSN() (string, error) {
+	dsn := c.Database.DSN
+	if c.Database.Driver != DatabasePostgres {
+		return dsn, nil
+	}
+	s, err := readSecrets(c.SecretsFile)
+	if err != nil {
+		return "", err
+	}
+	if s.DatabasePassword != "" {
+		return withPassword(dsn, s.DatabasePassword)
+	}
+	if !hasPassword(dsn) {
+		return "", &FieldError{Path: "secrets.database_password",
+			Message: fmt.Sprintf("must be set in %s or %s", c.SecretsFile, EnvDatabasePassword)}
+	}
+	return dsn, nil
+}
+
+// withPassword подставляет пароль в DSN вида URL (postgres://user@host/db) или key=value.
+func withPassword(dsn, password string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", &FieldError{Path: "database.dsn", Message: err.Error()}
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String(), nil
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+	return dsn + " password='" + quoted + "'", nil
+}
+
+func hasPassword(dsn string) bool {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		_, ok := u.User.Password()
+		return ok
+	}
+	return strings.Contains(dsn, "password=")
+}
+
+// readSecrets читает файл секретов (если path не пуст) и переопределения из окружения.
+func readSecrets(path string) (*Secrets, error) {
+	s := &Secrets{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read secrets: %w", err)
+		}
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("parse secrets %s: %w", path, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvAPIKey); ok {
+		s.APIKey = v
+	}
+	if v, ok := os.LookupEnv(EnvAPISecret); ok {
+		s.APISecret = v
+	}
+	if v, ok := os.LookupEnv(EnvDatabasePassword); ok {
+		s.DatabasePassword = v
+	}
+	return s, nil
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bybit-bot/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FieldError — ошибка значения конкретного ключа конфигурации.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError содержит все найденные ошибки конфигурации.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) check(ok bool, path, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// Validate проверяет конфигурацию и возвращает *ValidationError с путями всех некорректных ключей.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	switch c.Database.Driver {
+	case DatabasePostgres, DatabaseSQLite:
+		v.check(c.Database.DSN != "", "database.dsn", "must not be empty")
+	case DatabaseMemory:
+	default:
+		v.check(false, "database.driver", "must be %s, %s or %s, got %q",
+			DatabasePostgres, DatabaseSQLite, DatabaseMemory, c.Database.Driver)
+	}
+
+	switch c.Exchange.Environment {
+	case EnvironmentDemo, EnvironmentTestnet, EnvironmentMainnet:
+	default:
+		v.check(false, "exchange.environment", "must be one of %s, %s, %s, got %q",
+			EnvironmentDemo, EnvironmentTestnet, EnvironmentMainnet, c.Exchange.Environment)
+	}
+	v.check(c.Exchange.Category == "linear" || c.Exchange.Category == "spot",
+		"exchange.category", "must be linear or spot, got %q", c.Exchange.Category)
+	v.check(strings.HasPrefix(c.Exchange.RestURL, "http"), "exchange.rest_url", "must be an http(s) URL, got %q", c.Exchange.RestURL)
+	v.check(strings.HasPrefix(c.Exchange.PublicWSURL, "ws"), "exchange.public_ws_url", "must be a ws(s) URL, got %q", c.Exchange.PublicWSURL)
+	v.check(strings.HasPrefix(c.Exchange.PrivateWSURL, "ws"), "exchange.private_ws_url", "must be a ws(s) URL, got %q", c.Exchange.PrivateWSURL)
+	v.check(c.Exchange.RequestTimeout.Duration > 0, "exchange.request_timeout", "must be positive")
+	v.check(c.Exchange.MaxAttempts > 0, "exchange.max_attempts", "must be positive, got %d", c.Exchange.MaxAttempts)
+
+	v.check(len(c.Trading.Symbols) > 0, "trading.symbols", "must contain at least one symbol")
+	seen := make(map[string]bool, len(c.Trading.Symbols))
+	for i, s := range c.Trading.Symbols {
+		v.check(s != "" && s == strings.ToUpper(s), fmt.Sprintf("trading.symbols[%d]", i), "must be an upper-case symbol, got %q", s)
+		v.check(!seen[s], fmt.Sprintf("trading.symbols[%d]", i), "duplicate symbol %q", s)
+		seen[s] = true
+	}
+	v.check(isSupportedInterval(c.Trading.Interval), "trading.interval", "unsupported interval %q", c.Trading.Interval)
+	v.check(isSupportedInterval(c.Trading.KlineInterval), "trading.kline_interval", "unsupported interval %q", c.Trading.KlineInterval)
+	v.check(c.Trading.WarmUp.Duration >= 0, "trading.warm_up", "must not be negative")
+	v.check(c.Trading.RestartDelay.Duration >= 0, "trading.restart_delay", "must not be negative")
+
+	v.check(c.Strategy.Name != "", "strategy.name", "must not be empty")
+	v.check(c.Strategy.TickInterval.Duration > 0, "strategy.tick_interval", "must be positive")
+	v.check(c.Strategy.MaxDataAge.Duration > 0, "strategy.max_data_age", "must be positive")
+	v.check(c.Strategy.MaxMidPriceDeviation > 0 && c.Strategy.MaxMidPriceDeviation < 1,
+		"strategy.max_mid_price_deviation", "must be in (0, 1), got %v", c.Strategy.MaxMidPriceDeviation)
+	sp := c.Strategy.Signal
+	for path, period := range map[string]int{
+		"strategy.signal.ema_fast": sp.EMAFast, "strategy.signal.ema_slow": sp.EMASlow,
+		"strategy.signal.rsi_period": sp.RSIPeriod, "strategy.signal.volume_window": sp.VolumeWindow,
+		"strategy.signal.long_lookback": sp.LongLookback, "strategy.signal.short_lookback": sp.ShortLookback,
+		"strategy.signal.atr_period": sp.ATRPeriod, "strategy.signal.sma_fast": sp.SMAFast, "strategy.signal.sma_slow": sp.SMASlow,
+	} {
+		v.check(period > 0, path, "must be positive, got %d", period)
+	}
+	v.check(sp.EMAFast < sp.EMASlow, "strategy.signal.ema_fast", "must be less than ema_slow")
+	v.check(sp.SMAFast < sp.SMASlow, "strategy.signal.sma_fast", "must be less than sma_slow")
+	v.check(sp.VolumeSpikeFactor > 0, "strategy.signal.volume_spike_factor", "must be positive")
+	v.check(sp.PullbackPercent > 0, "strategy.signal.pullback_percent", "must be positive")
+	v.check(sp.MinATR >= 0, "strategy.signal.min_atr", "must not be negative")
+	v.check(c.Strategy.SLTP.StopLossPercent > 0 && c.Strategy.SLTP.StopLossPercent < 1,
+		"strategy.sltp.stop_loss_percent", "must be in (0, 1), got %v", c.Strategy.SLTP.StopLossPercent)
+	v.check(c.Strategy.SLTP.TakeProfitPercent > 0 && c.Strategy.SLTP.TakeProfitPercent < 1,
+		"strategy.sltp.take_profit_percent", "must be in (0, 1), got %v", c.Strategy.SLTP.TakeProfitPercent)
+	sl := c.Strategy.SLTP
+	switch sl.Mode {
+	case model.SLTPFixedPercent, model.SLTPRiskReward, model.SLTPATR, model.SLTPStructure:
+	default:
+		v.check(false, "strategy.sltp.mode", "must be one of %s, %s, %s, %s, got %q",
+			model.SLTPFixedPercent, model.SLTPRiskReward, model.SLTPATR, model.SLTPStructure, sl.Mode)
+	}
+	v.check(sl.RiskReward > 0, "strategy.sltp.risk_reward", "must be positive, got %v", sl.RiskReward)
+	v.check(sl.ATRPeriod > 0, "strategy.sltp.atr_period", "must be positive, got %d", sl.ATRPeriod)
+	v.check(sl.ATRMultiplier > 0, "strategy.sltp.atr_multiplier", "must be positive, got %v", sl.ATRMultiplier)
+	v.check(sl.MinSLPercent > 0 && sl.MinSLPercent < sl.MaxSLPercent && sl.MaxSLPercent < 1,
+		"strategy.sltp.min_sl_percent", "must satisfy 0 < min_sl_percent < max_sl_percent < 1")
+	v.check(sl.SwingLookback > 0, "strategy.sltp.swing_lookback", "must be positive, got %d", sl.SwingLookback)
+	v.check(sl.SwingBuffer >= 0 && sl.SwingBuffer < 0.1, "strategy.sltp.swing_buffer", "must be in [0, 0.1), got %v", sl.SwingBuffer)
+	mp := c.Strategy.Manage
+	v.check(mp.BreakEvenR >= 0, "strategy.manage.break_even_r", "must not be negative, got %v", mp.BreakEvenR)
+	v.check(mp.BreakEvenOffset >= 0 && mp.BreakEvenOffset < 0.01,
+		"strategy.manage.break_even_offset", "must be in [0, 0.01), got %v", mp.BreakEvenOffset)
+	switch mp.Trail {
+	case model.TrailNone:
+	case model.TrailATR:
+		v.check(mp.TrailATRPeriod > 0, "strategy.manage.trail_atr_period", "must be positive for atr trailing, got %d", mp.TrailATRPeriod)
+		v.check(mp.TrailATRMultiplier > 0, "strategy.manage.trail_atr_multiplier", "must be positive for atr trailing, got %v", mp.TrailATRMultiplier)
+	case model.TrailPercent:
+		v.check(mp.TrailPercent > 0 && mp.TrailPercent < 1, "strategy.manage.trail_percent", "must be in (0, 1), got %v", mp.TrailPercent)
+	default:
+		v.check(false, "strategy.manage.trail", "must be empty, %s or %s, got %q", model.TrailATR, model.TrailPercent, mp.Trail)
+	}
+	v.check(mp.TrailActivationR >= 0, "strategy.manage.trail_activation_r", "must not be negative, got %v", mp.TrailActivationR)
+	var fractions float64
+	for i, tp := range mp.TakeProfits {
+		path := fmt.Sprintf("strategy.manage.take_profits[%d]", i)
+		v.check(tp.R > 0, path+".r", "must be positive, got %v", tp.R)
+		v.check(tp.Fraction > 0 && tp.Fraction < 1, path+".fraction", "must be in (0, 1), got %v", tp.Fraction)
+		fractions += tp.Fraction
+	}
+	v.check(fractions < 1, "strategy.manage.take_profits", "total fraction must be less than 1, got %v", fractions)
+	v.check(!mp.Enabled() || c.Strategy.ManageInterval.Duration > 0,
+		"strategy.manage_interval", "must be positive when manage is enabled")
+
+	v.check(c.Risk.MaxPositionPercent > 0 && c.Risk.MaxPositionPercent <= 1,
+		"risk.max_position_percent", "must be in (0, 1], got %v", c.Risk.MaxPositionPercent)
+	sz := c.Risk.Sizing
+	switch sz.Mode {
+	case model.SizingPercentEquity:
+	case model.SizingFixedNotional:
+		v.check(sz.FixedNotional > 0, "risk.sizing.fixed_notional", "must be positive for fixed_notional, got %v", sz.FixedNotional)
+	case model.SizingFixedFractional, model.SizingATR:
+		v.check(sz.RiskPercent > 0 && sz.RiskPercent < 1, "risk.sizing.risk_percent", "must be in (0, 1), got %v", sz.RiskPercent)
+		v.check(sz.Mode != model.SizingATR || sz.ATRMultiplier > 0, "risk.sizing.atr_multiplier", "must be positive, got %v", sz.ATRMultiplier)
+	case model.SizingKelly:
+		v.check(sz.KellyWinRate > 0 && sz.KellyWinRate < 1, "risk.sizing.kelly_win_rate", "must be in (0, 1), got %v", sz.KellyWinRate)
+		v.check(sz.KellyPayoff > 0, "risk.sizing.kelly_payoff", "must be positive, got %v", sz.KellyPayoff)
+		v.check(sz.KellyFraction > 0 && sz.KellyFraction <= 1, "risk.sizing.kelly_fraction", "must be in (0, 1], got %v", sz.KellyFraction)
+		v.check(sz.KellyCap > 0 && sz.KellyCap < 1, "risk.sizing.kelly_cap", "must be in (0, 1), got %v", sz.KellyCap)
+	default:
+		v.check(false, "risk.sizing.mode", "must be one of %s, %s, %s, %s, %s, got %q",
+			model.SizingFixedNotional, model.SizingPercentEquity, model.SizingFixedFractional, model.SizingATR, model.SizingKelly, sz.Mode)
+	}
+	v.check(sz.Leverage >= 1 && sz.Leverage <= 100, "risk.sizing.leverage", "must be in [1, 100], got %v", sz.Leverage)
+	v.check(sz.FeeRate >= 0 && sz.FeeRate < 0.01, "risk.sizing.fee_rate", "must be in [0, 0.01), got %v", sz.FeeRate)
+	g := c.Risk.Guard
+	v.check(g.CheckInterval.Duration > 0, "risk.guard.check_interval", "must be positive")
+	v.check(g.MaxDailyLoss >= 0, "risk.guard.max_daily_loss", "must not be negative")
+	v.check(g.MaxDrawdown >= 0 && g.MaxDrawdown < 1, "risk.guard.max_drawdown", "must be in [0, 1), got %v", g.MaxDrawdown)
+	v.check(g.DrawdownWindow.Duration >= 0, "risk.guard.drawdown_window", "must not be negative")
+	v.check(g.MaxOpenPositions >= 0, "risk.guard.max_open_positions", "must not be negative")
+	v.check(g.MaxSymbolNotional >= 0, "risk.guard.max_symbol_notional", "must not be negative")
+	v.check(g.MaxTotalNotional >= 0, "risk.guard.max_total_notional", "must not be negative")
+	v.check(g.MaxOrdersPerHour >= 0, "risk.guard.max_orders_per_hour", "must not be negative")
+	v.check(g.MaxConsecutiveLosses >= 0, "risk.guard.max_consecutive_losses", "must not be negative")
+	v.check(g.MaxConsecutiveLosses == 0 || g.Cooldown.Duration > 0, "risk.guard.cooldown", "must be positive when max_consecutive_losses is set")
+	v.check(c.Notify.WebhookURL == "" || strings.HasPrefix(c.Notify.WebhookURL, "http"),
+		"notify.webhook_url", "must be an http(s) URL, got %q", c.Notify.WebhookURL)
+
+	v.check(c.Orders.CheckInterval.Duration > 0, "orders.check_interval", "must be positive")
+	v.check(c.Orders.Timeout.Duration >= 0, "orders.timeout", "must not be negative")
+	v.check(c.Orders.MaxPriceDrift >= 0 && c.Orders.MaxPriceDrift < 1,
+		"orders.max_price_drift", "must be in [0, 1), got %v", c.Orders.MaxPriceDrift)
+	v.check(c.Orders.Action == OrderActionCancel || c.Orders.Action == OrderActionReprice,
+		"orders.action", "must be %s or %s, got %q", OrderActionCancel, OrderActionReprice, c.Orders.Action)
+	v.check(c.Orders.MaxReprices >= 0, "orders.max_reprices", "must not be negative")
+	v.check(c.Reconcile.Interval.Duration >= 0, "reconcile.interval", "must not be negative")
+	v.check(c.Paper.InitialBalance > 0, "paper.initial_balance", "must be positive, got %v", c.Paper.InitialBalance)
+	v.check(c.Paper.MakerFee > -0.01 && c.Paper.MakerFee < 0.01, "paper.maker_fee", "must be in (-0.01, 0.01), got %v", c.Paper.MakerFee)
+	v.check(c.Paper.TakerFee >= 0 && c.Paper.TakerFee < 0.01, "paper.taker_fee", "must be in [0, 0.01), got %v", c.Paper.TakerFee)
+	v.check(c.Paper.Leverage >= 1, "paper.leverage", "must be at least 1, got %v", c.Paper.Leverage)
+	v.check(c.Paper.Interval.Duration > 0, "paper.interval", "must be positive")
+
+	v.check(c.Backtest.Symbol != "", "backtest.symbol", "must not be empty")
+	v.check(isSupportedInterval(c.Backtest.Interval), "backtest.interval", "unsupported interval %q", c.Backtest.Interval)
+	v.check(c.Backtest.End.IsZero() || c.Backtest.Start.Before(c.Backtest.End.Time),
+		"backtest.start", "must be before backtest.end")
+	v.check(c.Backtest.DataDir != "", "backtest.data_dir", "must not be empty")
+	v.check(strings.HasPrefix(c.Backtest.BaseURL, "http"), "backtest.base_url", "must be an http(s) URL, got %q", c.Backtest.BaseURL)
+	v.check(c.Backtest.ReportDir != "", "backtest.report_dir", "must not be empty")
+	v.check(c.Backtest.InitialBalance > 0, "backtest.initial_balance", "must be positive, got %v", c.Backtest.InitialBalance)
+	ex := c.Backtest.Execution
+	v.check(ex.MakerFee > -0.01 && ex.MakerFee < 0.01, "backtest.execution.maker_fee", "must be in (-0.01, 0.01), got %v", ex.MakerFee)
+	v.check(ex.TakerFee >= 0 && ex.TakerFee < 0.01, "backtest.execution.taker_fee", "must be in [0, 0.01), got %v", ex.TakerFee)
+	v.check(ex.Slippage == "fixed" || ex.Slippage == "volatility", "backtest.execution.slippage", "must be fixed or volatility, got %q", ex.Slippage)
+	v.check(ex.SlippageRate >= 0, "backtest.execution.slippage_rate", "must not be negative")
+	v.check(ex.FillProbability > 0 && ex.FillProbability <= 1, "backtest.execution.fill_probability", "must be in (0, 1], got %v", ex.FillProbability)
+	v.check(ex.TradeThrough >= 0, "backtest.execution.trade_through", "must not be negative")
+	v.check(ex.LatencyBars >= 0, "backtest.execution.latency_bars", "must not be negative")
+	switch ex.Intrabar {
+	case "pessimistic", "optimistic":
+	case "lower_timeframe":
+		v.check(isSupportedInterval(ex.LowerInterval) && ex.LowerInterval != c.Backtest.Interval,
+			"backtest.execution.lower_interval", "must be a supported interval lower than backtest.interval, got %q", ex.LowerInterval)
+	default:
+		v.check(false, "backtest.execution.intrabar", "must be pessimistic, optimistic or lower_timeframe, got %q", ex.Intrabar)
+	}
+	opt := c.Backtest.Optimize
+	v.check(opt.Method == "grid" || opt.Method == "random", "backtest.optimize.method", "must be grid or random, got %q", opt.Method)
+	v.check(opt.Method != "random" || opt.Samples > 0, "backtest.optimize.samples", "must be positive for random search")
+	v.check(opt.Workers >= 0, "backtest.optimize.workers", "must not be negative")
+	v.check(opt.InSampleBars >= 0 && opt.OutOfSampleBars >= 0, "backtest.optimize", "walk-forward windows must not be negative")
+	v.check(opt.WarmupBars >= 0 && (opt.InSampleBars == 0 || opt.WarmupBars <= opt.InSampleBars),
+		"backtest.optimize.warmup_bars", "must be in [0, in_sample_bars]")
+	for i, p := range opt.Params {
+		path := fmt.Sprintf("backtest.optimize.params[%d]", i)
+		v.check(p.Name != "", path+".name", "must not be empty")
+		v.check(p.Max >= p.Min, path+".max", "must not be less than min")
+		v.check(p.Step >= 0, path+".step", "must not be negative")
+	}
+
+	v.check(c.Logging.File != "" || c.Logging.Stdout, "logging", "either logging.file or logging.stdout must be set")
+
+	return v.err()
+}
+
+// isSupportedInterval проверяет интервал свечей Bybit v5, который бот умеет запрашивать и обрабатывать.
+func isSupportedInterval(interval string) bool {
+	_, ok := model.KlineIntervalDuration(interval)
+	return ok
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"bybit-bot/internal/service/strategy"
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// Engine доставляет события из Feed одной стратегии. Одна и та же стратегия работает
+// и в реальной торговле (LiveFeed), и в бэктесте (SimExchange): различаются только
+// источник событий и исполнитель, переданные ей через strategy.Dependencies.
+type Engine struct {
+	Strategy strategy.Strategy
+	Symbol   string
+	Category string
+	Feed     Feed
+}
+
+func New(s strategy.Strategy, symbol, category string, feed Feed) *Engine {
+	return &Engine{
+		Strategy: s,
+		Symbol:   symbol,
+		Category: category,
+		Feed:     feed,
+	}
+}
+
+// Run блокируется, пока Feed не закончится или ctx не будет отменён.
+func (e *Engine) Run(ctx context.Context) error {
+	if err := e.Strategy.Init(e.Symbol, e.Category); err != nil {
+		return fmt.Errorf("init %s for %s: %w", e.Strategy.Name(), e.Symbol, err)
+	}
+	log.Printf("Стратегия %s запущена для %s", e.Strategy.Name(), e.Symbol)
+
+	for {
+		ev, ok := e.Feed.Next(ctx)
+		if !ok {
+			return ctx.Err()
+		}
+		e.safely(ev.Type.String(), func() { e.dispatch(ev) })
+	}
+}
+
+func (e *Engine) dispatch(ev Event) {
+	switch ev.Type {
+	case EventKline:
+		e.Strategy.OnKline(ev.Kline)
+	case EventOrderbook:
+		e.Strategy.OnOrderbook(ev.Orderbook)
+	case EventFill:
+		e.Strategy.OnFill(ev.Fill)
+	case EventTick:
+		e.Strategy.OnTick(ev.Time)
+	}
+}
+
+// safely изолирует панику в стратегии, чтобы она не остановила остальные символы.
+func (e *Engine) safely(stage string, fn func()) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("[%s/%s] Паника в %s: %v\n%s", e.Strategy.Name(), e.Symbol, stage, rec, debug.Stack())
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"context"
+	"time"
+)
+
+type EventType int
+
+const (
+	EventKline     EventType = iota // закрытая свеча
+	EventOrderbook                  // актуальный ордербук
+	EventFill                       // исполнение ордера
+	EventTick                       // момент принятия решения
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventKline:
+		return "kline"
+	case EventOrderbook:
+		return "orderbook"
+	case EventFill:
+		return "fill"
+	case EventTick:
+		return "tick"
+	}
+	return "unknown"
+}
+
+// Event — рыночное событие для стратегии. Заполнено только поле, соответствующее Type.
+type Event struct {
+	Type      EventType
+	Symbol    string
+	Time      time.Time
+	Kline     model.KlineData
+	Orderbook *model.OrderbookData
+	Fill      model.ExecutionUpdate
+}
+
+// Feed — источник событий. Next блокируется до следующего события и возвращает false,
+// когда поток закончился (конец истории в бэктесте) или ctx отменён.
+type Feed interface {
+	Next(ctx context.Context) (Event, bool)
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"math"
+)
+
+// IntrabarPolicy определяет, какой уровень считается достигнутым первым,
+// если SL и TP оказались внутри диапазона одной свечи.
+type IntrabarPolicy string
+
+const (
+	IntrabarPessimistic    IntrabarPolicy = "pessimistic"     // первым срабатывает SL, TP не исполняется на свече лимитного входа
+	IntrabarOptimistic     IntrabarPolicy = "optimistic"      // первым срабатывает TP
+	IntrabarLowerTimeframe IntrabarPolicy = "lower_timeframe" // порядок по свечам младшего интервала, внутри них — как pessimistic
+)
+
+// SlippageMode определяет способ расчёта проскальзывания рыночных исполнений.
+type SlippageMode string
+
+const (
+	SlippageFixed      SlippageMode = "fixed"      // доля цены
+	SlippageVolatility SlippageMode = "volatility" // доля диапазона High-Low текущей свечи
+)
+
+// FillModel — модель исполнения ордеров симуляции.
+//
+// Лимитный вход активируется через 1+LatencyBars свечей после размещения. Если на открытии
+// свечи активации цена уже лучше лимита, ордер исполняется по цене открытия как тейкер
+// с проскальзыванием. Иначе он стоит в стакане как мейкер: при проходе цены за лимит на
+// TradeThrough исполняется гарантированно, при простом касании — с вероятностью FillProbability.
+// TP исполняется как лимитный ордер мейкера, SL — как рыночный ордер тейкера с проскальзыванием.
+type FillModel struct {
+	MakerFee float64
+	TakerFee float64
+
+	Slippage     SlippageMode
+	SlippageRate float64
+
+	FillProbability float64 // вероятность исполнения лимита при касании цены, (0, 1]
+	TradeThrough    float64 // доля цены за лимитом, при которой очередь гарантированно пройдена
+	LatencyBars     int     // задержка активации ордера в свечах
+
+	Intrabar IntrabarPolicy
+	Seed     int64 // зерно генератора для FillProbability
+}
+
+// DefaultFillModel — комиссии, совпадающие с заложенными в exchange.CalculateSLTP,
+// без проскальзывания и с пессимистичным разрешением неоднозначных свечей.
+func DefaultFillModel() FillModel {
+	return FillModel{
+		MakerFee:        0.00036,
+		TakerFee:        0.0010,
+		Slippage:        SlippageFixed,
+		FillProbability: 1,
+		Intrabar:        IntrabarPessimistic,
+	}
+}
+
+// slippage возвращает абсолютное проскальзывание для рыночного исполнения по цене price на свече bar.
+func (m FillModel) slippage(price float64, bar model.KlineData) float64 {
+	switch m.Slippage {
+	case SlippageVolatility:
+		return math.Max(bar.High-bar.Low, 0) * m.SlippageRate
+	default:
+		return price * m.SlippageRate
+	}
+}
+
+// fee возвращает комиссию за исполнение.
+func (m FillModel) fee(price, qty float64, maker bool) float64 {
+	if maker {
+		return price * qty * m.MakerFee
+	}
+	return price * qty * m.TakerFee
+}
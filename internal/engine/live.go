@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/service/event"
+	"context"
+	"log"
+	"time"
+)
+
+const DefaultTickInterval = 13 * time.Second
+
+// LiveFeed строит поток событий из WSListener: на каждом тике отдаёт новые закрытые свечи,
+// текущий ордербук и сам тик. Исполнения из приватного потока передаются через Fill.
+type LiveFeed struct {
+	Symbol        string
+	KlineInterval string // интервал свечей WebSocket (trading.kline_interval)
+	Interval      time.Duration
+	WSListener    *event.WSListener
+
+	fills         chan model.ExecutionUpdate
+	queue         []Event
+	ticker        *time.Ticker
+	lastKlineTime int64
+}
+
+func NewLiveFeed(symbol, klineInterval string, ws *event.WSListener, interval time.Duration) *LiveFeed {
+	if interval <= 0 {
+		interval = DefaultTickInterval
+	}
+	return &LiveFeed{
+		Symbol:        symbol,
+		KlineInterval: klineInterval,
+		Interval:      interval,
+		WSListener:    ws,
+		fills:         make(chan model.ExecutionUpdate, 100),
+	}
+}
+
+// Fill передаёт исполнение в поток событий. Не блокирует вызывающего:
+// при переполненном буфере исполнение отбрасывается с записью в лог.
+func (f *LiveFeed) Fill(execution model.ExecutionUpdate) {
+	select {
+	case f.fills <- execution:
+	default:
+		log.Printf("[%s] Буфер исполнений переполнен, исполнение %s пропущено", f.Symbol, execution.ExecID)
+	}
+}
+
+func (f *LiveFeed) Next(ctx context.Context) (Event, bool) {
+	if f.ticker == nil {
+		f.ticker = time.NewTicker(f.Interval)
+	}
+	for len(f.queue) == 0 {
+		select {
+		case <-ctx.Done():
+			f.ticker.Stop()
+			return Event{}, false
+		case execution := <-f.fills:
+			f.queue = append(f.queue, Event{Type: EventFill, Symbol: f.Symbol, Time: time.Now(), Fill: execution})
+		case now := <-f.ticker.C:
+			f.collect(now)
+		}
+	}
+	ev := f.queue[0]
+	f.queue = f.queue[1:]
+	return ev, true
+}
+
+func (f *LiveFeed) collect(now time.Time) {
+	if f.WSListener != nil {
+		if klines, ok := f.WSListener.GetKlinesByTopic(event.KlineTopic(f.KlineInterval, f.Symbol)); ok {
+			for _, k := range klines {
+				if k.Confirm && k.Start > f.lastKlineTime {
+					f.lastKlineTime = k.Start
+					f.queue = append(f.queue, Event{Type: EventKline, Symbol: f.Symbol, Time: now, Kline: k})
+				}
+			}
+		}
+		if orderBook, ok := f.WSListener.GetOrderbookByTopic(event.OrderbookTopic(f.Symbol)); ok {
+			f.queue = append(f.queue, Event{Type: EventOrderbook, Symbol: f.Symbol, Time: now, Orderbook: orderBook})
+		}
+	}
+	f.queue = append(f.queue, Event{Type: EventTick, Symbol: f.Symbol, Time: now})
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/utils"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// OrderRouter превращает намерения стратегии в лимитные ордера: форматирует цены по
+// лимитам инструмента, рассчитывает объём, проверяет баланс и передаёт ордер исполнителю.
+// В реальной торговле зависимости — клиент Bybit и сервисы аккаунта, в бэктесте — SimExchange.
+// Роутер общий для воркеров всех символов: намерения обрабатываются по одному, чтобы
+// расчёт объёма, проверка баланса и лимиты риск-контроля учитывали уже выставленные ордера.
+type OrderRouter struct {
+	Limits    interfaces.LimitsProvider
+	Formatter *utils.Formatter
+	Sizer     interfaces.Sizer
+	Balance   interfaces.BalanceChecker
+	Executor  interfaces.Executor
+
+	mu sync.Mutex
+}
+
+// Submit размещает ордер по намерению и возвращает его идентификатор.
+func (r *OrderRouter) Submit(intent model.OrderIntent) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limits, err := r.Limits.GetTradeLimitsViaInstruments(intent.Category, intent.Symbol)
+	if err != nil {
+		return "", fmt.Errorf("get trade limits for %s: %w", intent.Symbol, err)
+	}
+	price := r.Formatter.FormatPrice(limits, intent.EntryPrice)
+	stopLoss := r.Formatter.FormatPrice(limits, intent.StopLoss)
+	takeProfit := r.Formatter.FormatPrice(limits, intent.TakeProfit)
+
+	sizing, err := r.Sizer.CalculateQuantity(model.SizingRequest{
+		Symbol:     intent.Symbol,
+		Side:       intent.Side,
+		EntryPrice: intent.EntryPrice,
+		StopLoss:   intent.StopLoss,
+		ATR:        intent.ATR,
+		Limits:     limits,
+	})
+	if err != nil {
+		return "", fmt.Errorf("calculate quantity for %s: %w", intent.Symbol, err)
+	}
+	quantity := sizing.Quantity
+	log.Printf("Рассчитанное количество для %s: %v", intent.Symbol, quantity)
+	if quantity <= 0 {
+		return "", fmt.Errorf("zero quantity for %s: %s", intent.Symbol, sizing.Explanation)
+	}
+	if !r.Balance.CheckBalance(intent.EntryPrice, quantity, intent.EntryPrice, quantity) {
+		return "", fmt.Errorf("insufficient balance for %s", intent.Symbol)
+	}
+
+	if intent.Reason != "" {
+		log.Printf("Уровни SL/TP для %s: %s", intent.Symbol, intent.Reason)
+	}
+	return r.Executor.PlaceLimitOrder(intent.Symbol, intent.OrderSide(), price, quantity, stopLoss, takeProfit)
+}
@@ -0,0 +1,590 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/service/exchange"
+	"context"
+	"fmt"
+	"github.com/thrasher-corp/gocryptotrader/types"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// DefaultInitialBalance — стартовый баланс USDT симуляции по умолчанию.
+const DefaultInitialBalance = 10000.0
+
+// simSpread — половина спреда синтетического ордербука относительно цены закрытия.
+const simSpread = 0.0001
+
+// SimOrder — лимитный ордер в симуляции. После исполнения ордер остаётся активным,
+// пока позиция не закроется по SL или TP.
+type SimOrder struct {
+	ID         string
+	Side       string // buy или sell
+	Price      float64
+	Qty        float64
+	StopLoss   float64
+	TakeProfit float64
+	PlacedBar  int
+	ActiveBar  int // первая свеча, на которой ордер находится на бирже
+	FilledBar  int
+	Filled     bool
+
+	live       bool    // ордер уже проверен на открытии свечи активации
+	fillPrice  float64 // фактическая цена входа с учётом проскальзывания
+	entryFee   float64 // комиссия входа, ещё не отнесённая на закрытые части позиции
+	entrySlip  float64
+	entryMaker bool
+	plan       *TradePlan // сопровождение позиции; nil, если Manage не задан
+}
+
+// SimTrade — закрытая сделка симуляции. PnL учитывает комиссии; проскальзывание
+// уже входит в цены EntryPrice/ExitPrice и отдельно показано в Slippage.
+type SimTrade struct {
+	OrderID    string
+	Side       string // long или short
+	Entry      model.KlineData
+	Exit       model.KlineData
+	EntryPrice float64
+	ExitPrice  float64
+	Qty        float64
+	Result     string // sl, breakeven, trailing, tp, partial_tp или none (позиция не закрылась до конца данных)
+	GrossPnL   float64
+	EntryFee   float64
+	ExitFee    float64
+	Slippage   float64 // стоимость проскальзывания входа и выхода в USDT
+	PnL        float64 // GrossPnL за вычетом комиссий
+}
+
+// EquitySample — оценка капитала на закрытии свечи.
+type EquitySample struct {
+	Time       time.Time
+	Equity     float64 // баланс плюс нереализованный PnL открытых позиций
+	InPosition bool
+}
+
+// SimExchange воспроизводит исторические свечи одного символа и исполняет ордера стратегии.
+// Он одновременно является Feed для Engine и реализацией интерфейсов, через которые
+// стратегия и OrderRouter обращаются к бирже, поэтому стратегия работает без изменений.
+// Правила исполнения задаёт Model (см. FillModel).
+type SimExchange struct {
+	Symbol   string
+	Interval string // интервал исторических свечей
+	Limits   model.TradeLimits
+	Balance  float64 // свободный баланс USDT с учётом реализованного PnL и комиссий
+	Initial  float64 // стартовый баланс
+	Model    FillModel
+
+	// WarmupBars — число первых свечей, которые только прогревают индикаторы:
+	// ордера на них отклоняются, а капитал не учитывается.
+	WarmupBars int
+
+	// Manage — сопровождение позиций (TradePlan): частичные TP исполняются лимитом по уровню,
+	// а перенесённый стоп действует со следующей свечи, чтобы не заглядывать внутрь текущей.
+	// Каждая частичная фиксация записывается отдельной сделкой с результатом partial_tp.
+	Manage model.ManageParams
+
+	Trades []SimTrade
+	Equity []EquitySample
+
+	klines  []model.KlineData
+	lower   []model.KlineData // свечи младшего интервала для IntrabarLowerTimeframe
+	current int               // индекс последней закрытой свечи, -1 до старта
+	orders  []*SimOrder
+	queue   []Event
+	nextID  int
+	rnd     *rand.Rand
+	err     error // первая ошибка запроса данных, останавливает симуляцию
+}
+
+// NewSimExchange копирует свечи, упорядочивает их по времени и нормализует Start/End
+// к секундам, как их отдаёт marketdata.ByBitMarketData.
+func NewSimExchange(symbol, interval string, klines []model.KlineData, balance float64) *SimExchange {
+	if balance <= 0 {
+		balance = DefaultInitialBalance
+	}
+	return &SimExchange{
+		Symbol:   symbol,
+		Interval: interval,
+		Limits: model.TradeLimits{
+			Symbol:      symbol,
+			MinQuantity: 0.001,
+			StepSize:    0.001,
+			TickSize:    0.01,
+		},
+		Balance: balance,
+		Initial: balance,
+		Model:   DefaultFillModel(),
+		klines:  normalizeBars(symbol, interval, klines),
+		current: -1,
+	}
+}
+
+// SetLowerTimeframe задаёт свечи младшего интервала, по которым IntrabarLowerTimeframe
+// восстанавливает порядок движения цены внутри основной свечи.
+func (s *SimExchange) SetLowerTimeframe(interval string, klines []model.KlineData) {
+	s.lower = normalizeBars(s.Symbol, interval, klines)
+}
+
+func normalizeBars(symbol, interval string, klines []model.KlineData) []model.KlineData {
+	bars := make([]model.KlineData, len(klines))
+	copy(bars, klines)
+	for i := range bars {
+		if bars[i].Start == 0 {
+			bars[i].Start = bars[i].Timestamp / 1000
+		}
+		bars[i].Symbol = symbol
+		bars[i].Interval = interval
+		bars[i].Confirm = true
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Start < bars[j].Start })
+
+	barSize := time.Minute
+	for i := 1; i < len(bars); i++ {
+		if d := time.Duration(bars[i].Start-bars[i-1].Start) * time.Second; d > 0 && (i == 1 || d < barSize) {
+			barSize = d
+		}
+	}
+	for i := range bars {
+		if bars[i].End == 0 {
+			bars[i].End = bars[i].Start + int64(barSize/time.Second)
+		}
+	}
+	return bars
+}
+
+// Next закрывает очередную свечу: исполняет ордера по её диапазону и отдаёт
+// свечу, исполнения, синтетический ордербук и тик на момент закрытия.
+func (s *SimExchange) Next(ctx context.Context) (Event, bool) {
+	if len(s.queue) == 0 {
+		if ctx.Err() != nil || s.err != nil || s.current+1 >= len(s.klines) {
+			return Event{}, false
+		}
+		s.current++
+		bar := s.klines[s.current]
+		now := s.barClose(bar)
+
+		s.queue = append(s.queue, Event{Type: EventKline, Symbol: s.Symbol, Time: now, Kline: bar})
+		for _, fill := range s.match(bar, now) {
+			s.queue = append(s.queue, Event{Type: EventFill, Symbol: s.Symbol, Time: now, Fill: fill})
+		}
+		s.sampleEquity(bar, now)
+		s.queue = append(s.queue, Event{Type: EventOrderbook, Symbol: s.Symbol, Time: now, Orderbook: s.orderbook()})
+		s.queue = append(s.queue, Event{Type: EventTick, Symbol: s.Symbol, Time: now})
+	}
+	ev := s.queue[0]
+	s.queue = s.queue[1:]
+	return ev, true
+}
+
+// Finish закрывает оставшиеся позиции рыночным ордером по закрытию последней свечи с результатом "none".
+func (s *SimExchange) Finish() {
+	if len(s.klines) == 0 {
+		return
+	}
+	last := s.klines[len(s.klines)-1]
+	for _, o := range s.orders {
+		if o.Filled {
+			s.closeMarket(o, last, last.Close, "none")
+		}
+	}
+	s.orders = nil
+	if n := len(s.Equity); n > 0 {
+		s.Equity[n-1].Equity = s.Balance
+	}
+}
+
+func (s *SimExchange) sampleEquity(bar model.KlineData, now time.Time) {
+	if s.current < s.WarmupBars {
+		return
+	}
+	sample := EquitySample{Time: now, Equity: s.Balance}
+	for _, o := range s.orders {
+		if !o.Filled {
+			continue
+		}
+		sample.InPosition = true
+		if o.Side == "buy" {
+			sample.Equity += (bar.Close - o.fillPrice) * o.Qty
+		} else {
+			sample.Equity += (o.fillPrice - bar.Close) * o.Qty
+		}
+	}
+	s.Equity = append(s.Equity, sample)
+}
+
+func (s *SimExchange) barClose(bar model.KlineData) time.Time {
+	return time.Unix(bar.End, 0)
+}
+
+// path возвращает последовательность свечей, по которой проходит цена внутри bar.
+func (s *SimExchange) path(bar model.KlineData) []model.KlineData {
+	if s.Model.Intrabar != IntrabarLowerTimeframe || len(s.lower) == 0 {
+		return []model.KlineData{bar}
+	}
+	from := sort.Search(len(s.lower), func(i int) bool { return s.lower[i].Start >= bar.Start })
+	to := sort.Search(len(s.lower), func(i int) bool { return s.lower[i].Start >= bar.End })
+	if from >= to {
+		return []model.KlineData{bar}
+	}
+	return s.lower[from:to]
+}
+
+func (s *SimExchange) match(bar model.KlineData, now time.Time) []model.ExecutionUpdate {
+	var fills []model.ExecutionUpdate
+	for _, sub := range s.path(bar) {
+		active := s.orders[:0]
+		for _, o := range s.orders {
+			justFilled := false
+			if !o.Filled {
+				if s.current < o.ActiveBar || !s.tryEntry(o, sub) {
+					active = append(active, o)
+					continue
+				}
+				o.FilledBar = s.current
+				fills = append(fills, s.execution(o, o.Side, o.fillPrice, o.Qty, o.entryFee, o.entryMaker, now))
+				s.startPlan(o)
+				justFilled = true
+			} else if o.plan != nil {
+				fills = append(fills, s.takePartials(o, sub, now)...)
+			}
+
+			exit, ok := s.tryExit(o, sub, now, justFilled)
+			if !ok {
+				if !justFilled {
+					// Диапазон свечи входа до исполнения неизвестен: стоп по ней не переносится.
+					s.updatePlan(o, sub)
+				}
+				active = append(active, o)
+				continue
+			}
+			fills = append(fills, exit)
+		}
+		s.orders = active
+	}
+	return fills
+}
+
+// tryEntry проверяет исполнение лимитного входа на свече bar.
+func (s *SimExchange) tryEntry(o *SimOrder, bar model.KlineData) bool {
+	buy := o.Side == "buy"
+	if !o.live {
+		o.live = true
+		// Цена уже лучше лимита: ордер исполняется сразу как рыночный.
+		if (buy && bar.Open <= o.Price) || (!buy && bar.Open >= o.Price) {
+			slip := s.Model.slippage(bar.Open, bar)
+			price := bar.Open + slip
+			if !buy {
+				price = bar.Open - slip
+			}
+			s.fillEntry(o, price, slip*o.Qty, false)
+			return true
+		}
+	}
+
+	touched := (buy && bar.Low <= o.Price) || (!buy && bar.High >= o.Price)
+	if !touched {
+		return false
+	}
+	// Цена прошла за лимит достаточно далеко: очередь перед ордером исполнена.
+	through := o.Price * s.Model.TradeThrough
+	tradedThrough := (buy && bar.Low < o.Price-through) || (!buy && bar.High > o.Price+through)
+	if !tradedThrough && s.random() >= s.Model.FillProbability {
+		return false
+	}
+	s.fillEntry(o, o.Price, 0, true)
+	return true
+}
+
+func (s *SimExchange) fillEntry(o *SimOrder, price, slippage float64, maker bool) {
+	o.Filled = true
+	o.fillPrice = price
+	o.entrySlip = slippage
+	o.entryMaker = maker
+	o.entryFee = s.Model.fee(price, o.Qty, maker)
+	s.Balance -= o.entryFee
+}
+
+// tryExit проверяет срабатывание SL и TP на свече bar; entryBar — вход исполнен на этой же свече.
+func (s *SimExchange) tryExit(o *SimOrder, bar model.KlineData, now time.Time, entryBar bool) (model.ExecutionUpdate, bool) {
+	buy := o.Side == "buy"
+	slHit := o.StopLoss > 0 && ((buy && bar.Low <= o.StopLoss) || (!buy && bar.High >= o.StopLoss))
+	tpHit := o.TakeProfit > 0 && ((buy && bar.High >= o.TakeProfit) || (!buy && bar.Low <= o.TakeProfit))
+	if tpHit && entryBar && o.entryMaker && s.Model.Intrabar != IntrabarOptimistic {
+		// Лимит исполнен внутри свечи: экстремум, задевший TP, мог случиться до входа.
+		tpHit = false
+	}
+	if slHit && tpHit {
+		// Внутри одной свечи (в том числе младшего интервала) порядок неизвестен.
+		if s.Model.Intrabar == IntrabarOptimistic {
+			slHit = false
+		} else {
+			tpHit = false
+		}
+	}
+
+	switch {
+	case slHit:
+		// При гэпе за уровень стоп исполняется по цене открытия.
+		ref := o.StopLoss
+		if (buy && bar.Open < ref) || (!buy && bar.Open > ref) {
+			ref = bar.Open
+		}
+		result := StopInitial
+		if o.plan != nil {
+			result = o.plan.StopKind()
+		}
+		return s.closeMarket(o, bar, ref, result), true
+	case tpHit:
+		qty := o.Qty
+		fee := s.Model.fee(o.TakeProfit, qty, true)
+		s.settle(o, bar, qty, o.TakeProfit, fee, 0, "tp")
+		return s.execution(o, opposite(o.Side), o.TakeProfit, qty, fee, true, now), true
+	}
+	return model.ExecutionUpdate{}, false
+}
+
+// closeMarket закрывает позицию рыночным ордером от цены ref с проскальзыванием и комиссией тейкера.
+func (s *SimExchange) closeMarket(o *SimOrder, bar model.KlineData, ref float64, result string) model.ExecutionUpdate {
+	slip := s.Model.slippage(ref, bar)
+	price := ref - slip
+	if o.Side == "sell" {
+		price = ref + slip
+	}
+	qty := o.Qty
+	fee := s.Model.fee(price, qty, false)
+	s.settle(o, bar, qty, price, fee, slip*qty, result)
+	return s.execution(o, opposite(o.Side), price, qty, fee, false, s.barClose(bar))
+}
+
+// settle закрывает qty позиции по цене exitPrice. На сделку относится пропорциональная
+// доля комиссии и проскальзывания входа.
+func (s *SimExchange) settle(o *SimOrder, bar model.KlineData, qty, exitPrice, exitFee, exitSlip float64, result string) {
+	share := qty / o.Qty
+	entryFee := o.entryFee * share
+	entrySlip := o.entrySlip * share
+	o.entryFee -= entryFee
+	o.entrySlip -= entrySlip
+	o.Qty -= qty
+
+	side := "long"
+	gross := (exitPrice - o.fillPrice) * qty
+	if o.Side == "sell" {
+		side = "short"
+		gross = -gross
+	}
+	s.Balance += gross - exitFee
+	s.Trades = append(s.Trades, SimTrade{
+		OrderID:    o.ID,
+		Side:       side,
+		Entry:      s.klines[o.FilledBar],
+		Exit:       bar,
+		EntryPrice: o.fillPrice,
+		ExitPrice:  exitPrice,
+		Qty:        qty,
+		Result:     result,
+		GrossPnL:   gross,
+		EntryFee:   entryFee,
+		ExitFee:    exitFee,
+		Slippage:   entrySlip + exitSlip,
+		PnL:        gross - entryFee - exitFee,
+	})
+}
+
+// startPlan создаёт план сопровождения исполненного входа.
+func (s *SimExchange) startPlan(o *SimOrder) {
+	if !s.Manage.Enabled() || o.StopLoss <= 0 {
+		return
+	}
+	side := "long"
+	if o.Side == "sell" {
+		side = "short"
+	}
+	o.plan = NewTradePlan(side, o.fillPrice, o.StopLoss, o.Qty, s.Limits.StepSize, s.Manage)
+}
+
+// takePartials исполняет частичные TP, которых коснулась цена bar. При пессимистичной
+// политике свеча, задевшая и стоп, частичных фиксаций не даёт.
+func (s *SimExchange) takePartials(o *SimOrder, bar model.KlineData, now time.Time) []model.ExecutionUpdate {
+	buy := o.Side == "buy"
+	slHit := o.StopLoss > 0 && ((buy && bar.Low <= o.StopLoss) || (!buy && bar.High >= o.StopLoss))
+	if slHit && s.Model.Intrabar != IntrabarOptimistic {
+		return nil
+	}
+	var fills []model.ExecutionUpdate
+	for {
+		levels := o.plan.PartialTakeProfits()
+		if len(levels) == 0 {
+			return fills
+		}
+		level := levels[0]
+		if (buy && bar.High < level.Price) || (!buy && bar.Low > level.Price) || level.Qty >= o.Qty {
+			return fills
+		}
+		o.plan.TakePartial()
+		fee := s.Model.fee(level.Price, level.Qty, true)
+		s.settle(o, bar, level.Qty, level.Price, fee, 0, "partial_tp")
+		fills = append(fills, s.execution(o, opposite(o.Side), level.Price, level.Qty, fee, true, now))
+	}
+}
+
+// updatePlan переносит стоп по диапазону bar; ATR считается по свечам, закрытым до bar.
+func (s *SimExchange) updatePlan(o *SimOrder, bar model.KlineData) {
+	if o.plan == nil {
+		return
+	}
+	var atr float64
+	if s.Manage.Trail == model.TrailATR {
+		period := s.Manage.TrailATRPeriod
+		from := max(0, s.current-3*period)
+		atr = exchange.LastATR(s.klines[from:s.current], period)
+	}
+	if stop, _, moved := o.plan.Update(bar.High, bar.Low, atr); moved {
+		o.StopLoss = stop
+	}
+}
+
+func (s *SimExchange) random() float64 {
+	if s.rnd == nil {
+		s.rnd = rand.New(rand.NewSource(s.Model.Seed))
+	}
+	return s.rnd.Float64()
+}
+
+func (s *SimExchange) execution(o *SimOrder, side string, price, qty, fee float64, maker bool, now time.Time) model.ExecutionUpdate {
+	s.nextID++
+	orderType, feeRate := "Market", s.Model.TakerFee
+	if maker {
+		orderType, feeRate = "Limit", s.Model.MakerFee
+	}
+	return model.ExecutionUpdate{
+		Category:  "linear",
+		Symbol:    s.Symbol,
+		ExecID:    fmt.Sprintf("sim-exec-%d", s.nextID),
+		OrderID:   o.ID,
+		Side:      side,
+		OrderType: orderType,
+		ExecPrice: types.Number(price),
+		ExecQty:   types.Number(qty),
+		ExecValue: types.Number(price * qty),
+		ExecFee:   types.Number(fee),
+		FeeRate:   types.Number(feeRate),
+		IsMaker:   maker,
+		ExecType:  "Trade",
+		ExecTime:  types.Time(now),
+	}
+}
+
+func (s *SimExchange) orderbook() *model.OrderbookData {
+	bar := s.klines[s.current]
+	ts := bar.End * 1000
+	return &model.OrderbookData{
+		Symbol: s.Symbol,
+		Bids:   []model.PriceLevel{{Price: bar.Close * (1 - simSpread), Size: bar.Volume}},
+		Asks:   []model.PriceLevel{{Price: bar.Close * (1 + simSpread), Size: bar.Volume}},
+		Ts:     ts,
+		Cts:    ts,
+	}
+}
+
+// Err возвращает ошибку, остановившую симуляцию: стратегия запросила свечи,
+// которых нет в загруженных данных (другой символ или интервал).
+func (s *SimExchange) Err() error {
+	return s.err
+}
+
+// GetRecentKlines возвращает n последних закрытых свечей на текущий момент симуляции.
+// Запрос другого символа или интервала останавливает симуляцию с ошибкой (см. Err):
+// иначе бэктест молча завершился бы без сделок.
+func (s *SimExchange) GetRecentKlines(symbol, interval string, n int) ([]model.KlineData, bool) {
+	if symbol != s.Symbol || interval != s.Interval {
+		if s.err == nil {
+			s.err = fmt.Errorf("strategy requested %s klines with interval %q, simulation has %s with interval %q",
+				symbol, interval, s.Symbol, s.Interval)
+			log.Printf("[Симуляция] Остановка: %v", s.err)
+		}
+		return nil, false
+	}
+	if s.current+1 < n {
+		return nil, false
+	}
+	bars := make([]model.KlineData, n)
+	copy(bars, s.klines[s.current+1-n:s.current+1])
+	return bars, true
+}
+
+func (s *SimExchange) GetOrderbookByTopic(string) (*model.OrderbookData, bool) {
+	if s.current < 0 {
+		return nil, false
+	}
+	return s.orderbook(), true
+}
+
+// IsHealthy всегда true: в симуляции нет соединения, которое может устареть.
+func (s *SimExchange) IsHealthy(time.Duration) bool {
+	return true
+}
+
+func (s *SimExchange) PlaceLimitOrder(symbol, side string, price, qty, sl, tp float64) (string, error) {
+	if symbol != s.Symbol {
+		return "", fmt.Errorf("simulated exchange trades %s, got %s", s.Symbol, symbol)
+	}
+	if s.current < s.WarmupBars {
+		return "", fmt.Errorf("warm-up period: %d of %d bars", s.current+1, s.WarmupBars)
+	}
+	s.nextID++
+	id := fmt.Sprintf("sim-%d", s.nextID)
+	s.orders = append(s.orders, &SimOrder{
+		ID:         id,
+		Side:       side,
+		Price:      price,
+		Qty:        qty,
+		StopLoss:   sl,
+		TakeProfit: tp,
+		PlacedBar:  s.current,
+		ActiveBar:  s.current + 1 + s.Model.LatencyBars,
+	})
+	return id, nil
+}
+
+func (s *SimExchange) CountOpenOrders(_, symbol string) (int, error) {
+	if symbol != s.Symbol {
+		return 0, nil
+	}
+	return len(s.orders), nil
+}
+
+func (s *SimExchange) GetTradeLimitsViaInstruments(_, symbol string) (model.TradeLimits, error) {
+	if symbol != s.Symbol {
+		return model.TradeLimits{}, fmt.Errorf("unknown symbol %s", symbol)
+	}
+	return s.Limits, nil
+}
+
+func (s *SimExchange) AvailableBalance(coin string) (float64, error) {
+	if coin != "USDT" {
+		return 0, fmt.Errorf("unknown coin %s", coin)
+	}
+	return s.Balance, nil
+}
+
+func (s *SimExchange) CheckBalance(buyPrice, buyQuantity, sellPrice, sellQuantity float64) bool {
+	required := buyPrice * buyQuantity
+	if sell := sellPrice * sellQuantity; sell > required {
+		required = sell
+	}
+	if s.Balance < required {
+		log.Printf("[Симуляция] Недостаточно средств: доступно %.2f USDT, требуется %.2f USDT", s.Balance, required)
+		return false
+	}
+	return true
+}
+
+func opposite(side string) string {
+	if side == "buy" {
+		return "sell"
+	}
+	return "buy"
+}
@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"context"
+	"math"
+	"testing"
+)
+
+const simTestStart = 1_700_000_040 // начало первой свечи, секунды
+
+// ohlc — свеча сценария: открытие, максимум, минимум, закрытие.
+type ohlc [4]float64
+
+func testBars(step int64, from int64, bars ...ohlc) []model.KlineData {
+	klines := make([]model.KlineData, len(bars))
+	for i, b := range bars {
+		klines[i] = model.KlineData{Start: from + int64(i)*step, Open: b[0], High: b[1], Low: b[2], Close: b[3], Volume: 1}
+	}
+	return klines
+}
+
+// wantTrade — ожидаемая сделка: номера свечей входа и выхода и цены.
+type wantTrade struct {
+	result     string
+	entryBar   int
+	exitBar    int
+	entryPrice float64
+	exitPrice  float64
+	maker      bool // вход исполнен как мейкер
+}
+
+// runSim размещает после первой свечи покупку 1 по 100 со стопом 95 и TP 110,
+// проигрывает все свечи и закрывает остаток по последней.
+func runSim(t *testing.T, fm FillModel, bars []model.KlineData, lower []model.KlineData) *SimExchange {
+	t.Helper()
+	s := NewSimExchange("BTCUSDT", "1", bars, 0)
+	s.Model = fm
+	if lower != nil {
+		s.SetLowerTimeframe("30s", lower)
+	}
+	ctx := context.Background()
+	for {
+		if _, ok := s.Next(ctx); !ok {
+			break
+		}
+		if s.current == 0 && len(s.queue) == 0 {
+			if _, err := s.PlaceLimitOrder("BTCUSDT", "buy", 100, 1, 95, 110); err != nil {
+				t.Fatalf("PlaceLimitOrder: %v", err)
+			}
+		}
+	}
+	s.Finish()
+	return s
+}
+
+func checkTrades(t *testing.T, s *SimExchange, want []wantTrade) {
+	t.Helper()
+	if len(s.Trades) != len(want) {
+		t.Fatalf("%d trades %+v, want %d", len(s.Trades), s.Trades, len(want))
+	}
+	for i, w := range want {
+		got := s.Trades[i]
+		fee := s.Model.TakerFee
+		if w.maker {
+			fee = s.Model.MakerFee
+		}
+		if got.Result != w.result || got.Entry.Start != s.klines[w.entryBar].Start || got.Exit.Start != s.klines[w.exitBar].Start ||
+			math.Abs(got.EntryPrice-w.entryPrice) > 1e-9 || math.Abs(got.ExitPrice-w.exitPrice) > 1e-9 ||
+			math.Abs(got.EntryFee-w.entryPrice*got.Qty*fee) > 1e-9 {
+			t.Fatalf("trade %d = %s entry %v@%d exit %v@%d fee %v, want %+v",
+				i, got.Result, got.EntryPrice, got.Entry.Start, got.ExitPrice, got.Exit.Start, got.EntryFee, w)
+		}
+	}
+}
+
+func TestSimExchangeFillModel(t *testing.T) {
+	base := DefaultFillModel()
+	with := func(change func(*FillModel)) FillModel {
+		fm := base
+		change(&fm)
+		return fm
+	}
+	quiet := ohlc{101, 102, 100.5, 101} // не касается ни входа, ни уровней
+
+	tests := []struct {
+		name  string
+		model FillModel
+		bars  []ohlc
+		want  []wantTrade
+	}{
+		{
+			name:  "limit fills as maker when the price trades through",
+			model: base,
+			bars:  []ohlc{quiet, {101, 101, 99.5, 100}, {100, 111, 99, 109}},
+			want:  []wantTrade{{result: "tp", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 110, maker: true}},
+		},
+		{
+			name:  "gap below the limit fills at the open as taker with fixed slippage",
+			model: with(func(fm *FillModel) { fm.SlippageRate = 0.001 }),
+			bars:  []ohlc{quiet, {99, 99.5, 98, 99}, {99, 99, 94.5, 95}},
+			want:  []wantTrade{{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 99 * 1.001, exitPrice: 95 * 0.999}},
+		},
+		{
+			name:  "volatility slippage scales with the bar range",
+			model: with(func(fm *FillModel) { fm.Slippage, fm.SlippageRate = SlippageVolatility, 0.1 }),
+			bars:  []ohlc{quiet, {99, 101, 98, 100}, {93, 94, 92, 93}},
+			// Стоп при гэпе исполняется от открытия 93 за вычетом 0.1 диапазона 2.
+			want: []wantTrade{{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 99.3, exitPrice: 92.8}},
+		},
+		{
+			name:  "latency delays activation",
+			model: with(func(fm *FillModel) { fm.LatencyBars = 1 }),
+			bars:  []ohlc{quiet, {101, 101, 99, 100}, {101, 102, 100.5, 101}, {101, 101, 99.5, 100}},
+			want:  []wantTrade{{result: "none", entryBar: 3, exitBar: 3, entryPrice: 100, exitPrice: 100, maker: true}},
+		},
+		{
+			name:  "touch without trade-through depends on fill probability",
+			model: with(func(fm *FillModel) { fm.FillProbability, fm.TradeThrough = 1e-12, 0.001 }),
+			bars:  []ohlc{quiet, {101, 101, 100, 100.5}, {101, 101, 99.95, 100}, {101, 101, 99.8, 100}},
+			want:  []wantTrade{{result: "none", entryBar: 3, exitBar: 3, entryPrice: 100, exitPrice: 100, maker: true}},
+		},
+		{
+			name:  "touch fills with full fill probability",
+			model: with(func(fm *FillModel) { fm.TradeThrough = 0.001 }),
+			bars:  []ohlc{quiet, {101, 101, 100, 100.5}, {101, 101, 100.5, 101}},
+			want:  []wantTrade{{result: "none", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 101, maker: true}},
+		},
+		{
+			name:  "pessimistic resolves SL and TP on one bar as SL",
+			model: base,
+			bars:  []ohlc{quiet, {101, 101, 99.5, 100}, {100, 111, 94, 100}},
+			want:  []wantTrade{{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 95, maker: true}},
+		},
+		{
+			name:  "optimistic resolves SL and TP on one bar as TP",
+			model: with(func(fm *FillModel) { fm.Intrabar = IntrabarOptimistic }),
+			bars:  []ohlc{quiet, {101, 101, 99.5, 100}, {100, 111, 94, 100}},
+			want:  []wantTrade{{result: "tp", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 110, maker: true}},
+		},
+		{
+			name:  "pessimistic does not take profit on the limit entry bar",
+			model: base,
+			bars:  []ohlc{quiet, {101, 111, 99.5, 105}, {105, 106, 104, 105}},
+			want:  []wantTrade{{result: "none", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 105, maker: true}},
+		},
+		{
+			name:  "optimistic takes profit on the limit entry bar",
+			model: with(func(fm *FillModel) { fm.Intrabar = IntrabarOptimistic }),
+			bars:  []ohlc{quiet, {101, 111, 99.5, 105}, {105, 106, 104, 105}},
+			want:  []wantTrade{{result: "tp", entryBar: 1, exitBar: 1, entryPrice: 100, exitPrice: 110, maker: true}},
+		},
+		{
+			name:  "entry at the open precedes the whole bar",
+			model: base,
+			bars:  []ohlc{quiet, {99, 111, 98, 105}, {105, 106, 104, 105}},
+			want:  []wantTrade{{result: "tp", entryBar: 1, exitBar: 1, entryPrice: 99, exitPrice: 110}},
+		},
+		{
+			name:  "stop loss may fill on the limit entry bar",
+			model: base,
+			bars:  []ohlc{quiet, {101, 101, 94, 96}, {96, 97, 96, 96}},
+			want:  []wantTrade{{result: "sl", entryBar: 1, exitBar: 1, entryPrice: 100, exitPrice: 95, maker: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := runSim(t, tt.model, testBars(60, simTestStart, tt.bars...), nil)
+			checkTrades(t, s, tt.want)
+		})
+	}
+}
+
+func TestSimExchangeLowerTimeframe(t *testing.T) {
+	fm := DefaultFillModel()
+	fm.Intrabar = IntrabarLowerTimeframe
+	bars := []ohlc{{101, 102, 100.5, 101}, {101, 101, 99.5, 100}, {100, 111, 94, 100}}
+	second := int64(simTestStart + 120) // начало третьей свечи
+
+	tests := []struct {
+		name  string
+		lower []model.KlineData
+		want  wantTrade
+	}{
+		{
+			name:  "take profit reached first",
+			lower: testBars(30, second, ohlc{100, 111, 99, 110}, ohlc{110, 110, 94, 100}),
+			want:  wantTrade{result: "tp", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 110, maker: true},
+		},
+		{
+			name:  "stop loss reached first",
+			lower: testBars(30, second, ohlc{100, 100, 94, 96}, ohlc{96, 111, 96, 110}),
+			want:  wantTrade{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 95, maker: true},
+		},
+		{
+			name:  "both levels inside one lower bar resolve as SL",
+			lower: testBars(30, second, ohlc{100, 111, 94, 100}, ohlc{100, 101, 99, 100}),
+			want:  wantTrade{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 95, maker: true},
+		},
+		{
+			name:  "bar without lower data resolves as SL",
+			lower: testBars(30, second+60, ohlc{100, 101, 99, 100}),
+			want:  wantTrade{result: "sl", entryBar: 1, exitBar: 2, entryPrice: 100, exitPrice: 95, maker: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := runSim(t, fm, testBars(60, simTestStart, bars...), tt.lower)
+			checkTrades(t, s, []wantTrade{tt.want})
+		})
+	}
+}
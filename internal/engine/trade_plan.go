@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"bybit-bot/internal/model"
+	"fmt"
+	"math"
+)
+
+// PartialLevel — уровень частичной фиксации с объёмом, округлённым до шага инструмента.
+type PartialLevel struct {
+	Price float64
+	Qty   float64
+	R     float64
+}
+
+// TradePlan — правила сопровождения одной позиции (model.ManageParams), общие для
+// реальной торговли (trades.Manager) и симуляции (SimExchange). План не обращается
+// к бирже: он по диапазону цен решает, куда перенести стоп, а вызывающий код
+// выставляет стоп и частичные TP доступным ему способом.
+type TradePlan struct {
+	Side      string // long или short
+	Entry     float64
+	Stop      float64 // текущий стоп
+	Qty       float64 // исходный объём
+	Remaining float64
+	Params    model.ManageParams
+
+	risk      float64 // расстояние от входа до начального стопа
+	best      float64 // лучшая цена с момента входа
+	breakEven bool
+	stopKind  string
+	levels    []PartialLevel
+}
+
+// Виды стопа плана: начальный, перенесённый в безубыток или подтянутый трейлингом.
+const (
+	StopInitial   = "sl"
+	StopBreakEven = "breakeven"
+	StopTrailing  = "trailing"
+)
+
+// NewTradePlan создаёт план для позиции с входом entry, начальным стопом stop и объёмом qty.
+// step — шаг объёма инструмента для частичных фиксаций.
+func NewTradePlan(side string, entry, stop, qty, step float64, p model.ManageParams) *TradePlan {
+	t := &TradePlan{
+		Side:      side,
+		Entry:     entry,
+		Stop:      stop,
+		Qty:       qty,
+		Remaining: qty,
+		Params:    p,
+		risk:      math.Abs(entry - stop),
+		best:      entry,
+		stopKind:  StopInitial,
+	}
+	t.levels = t.partialLevels(step)
+	return t
+}
+
+// Risk возвращает начальный риск на единицу объёма (1R).
+func (t *TradePlan) Risk() float64 {
+	return t.risk
+}
+
+// PartialTakeProfits возвращает ещё не исполненные уровни частичной фиксации.
+func (t *TradePlan) PartialTakeProfits() []PartialLevel {
+	return t.levels
+}
+
+// partialLevels рассчитывает уровни частичной фиксации. Объём уровня округляется вниз
+// до шага; уровни, после которых от позиции не осталось бы хотя бы одного шага, пропускаются.
+func (t *TradePlan) partialLevels(step float64) []PartialLevel {
+	if t.risk <= 0 {
+		return nil
+	}
+	var levels []PartialLevel
+	left := t.Qty
+	for _, tp := range t.Params.TakeProfits {
+		qty := tp.Fraction * t.Qty
+		if step > 0 {
+			qty = math.Floor(qty/step+1e-9) * step
+		}
+		if qty <= 0 || left-qty < math.Max(step, 1e-12) {
+			continue
+		}
+		left -= qty
+		levels = append(levels, PartialLevel{Price: t.priceAtR(tp.R), Qty: qty, R: tp.R})
+	}
+	return levels
+}
+
+// priceAtR возвращает цену на расстоянии r начальных рисков в сторону прибыли.
+func (t *TradePlan) priceAtR(r float64) float64 {
+	return t.Entry + t.direction()*r*t.risk
+}
+
+func (t *TradePlan) direction() float64 {
+	if t.Side == "short" {
+		return -1
+	}
+	return 1
+}
+
+// TakePartial отмечает исполнение первого оставшегося уровня частичной фиксации.
+func (t *TradePlan) TakePartial() (PartialLevel, bool) {
+	if len(t.levels) == 0 {
+		return PartialLevel{}, false
+	}
+	level := t.levels[0]
+	t.levels = t.levels[1:]
+	t.Remaining -= level.Qty
+	return level, true
+}
+
+// StopMove — предложенный перенос стопа (Propose), который применяется через Commit.
+type StopMove struct {
+	Stop      float64
+	Kind      string // StopInitial, StopBreakEven или StopTrailing
+	Reason    string
+	BreakEven bool // достигнут порог переноса в безубыток
+}
+
+// Update учитывает диапазон цен [low, high] после входа и возвращает новый стоп, если
+// его нужно перенести. Стоп двигается только в сторону прибыли. atr используется
+// трейлингом в режиме atr; при atr <= 0 этот трейлинг не двигает стоп.
+// Update сразу применяет перенос; если стоп ещё нужно выставить на бирже, используйте Propose и Commit.
+func (t *TradePlan) Update(high, low, atr float64) (stop float64, reason string, moved bool) {
+	move, moved := t.Propose(high, low, atr)
+	t.Commit(move)
+	return move.Stop, move.Reason, moved
+}
+
+// Propose учитывает диапазон цен [low, high], как Update, но не меняет стоп плана:
+// перенос применяется через Commit после того, как стоп принят. Если перенос не
+// применён, следующий вызов предложит его снова.
+func (t *TradePlan) Propose(high, low, atr float64) (StopMove, bool) {
+	current := StopMove{Stop: t.Stop, Kind: t.stopKind, BreakEven: t.breakEven}
+	if t.risk <= 0 {
+		return current, false
+	}
+	dir := t.direction()
+	if dir > 0 {
+		t.best = math.Max(t.best, high)
+	} else {
+		t.best = math.Min(t.best, low)
+	}
+	excursion := (t.best - t.Entry) * dir / t.risk
+
+	move := current
+	p := t.Params
+	if p.BreakEvenR > 0 && !t.breakEven && excursion >= p.BreakEvenR {
+		move.BreakEven = true
+		be := t.Entry * (1 + dir*p.BreakEvenOffset)
+		if t.better(be, move.Stop) {
+			move.Stop, move.Kind = be, StopBreakEven
+			move.Reason = fmt.Sprintf("безубыток после +%.2fR", excursion)
+		}
+	}
+	if p.Trail != model.TrailNone && excursion >= p.TrailActivationR {
+		var distance float64
+		switch p.Trail {
+		case model.TrailATR:
+			distance = atr * p.TrailATRMultiplier
+		case model.TrailPercent:
+			distance = t.best * p.TrailPercent
+		}
+		if distance > 0 {
+			trail := t.best - dir*distance
+			if t.better(trail, move.Stop) {
+				move.Stop, move.Kind = trail, StopTrailing
+				move.Reason = fmt.Sprintf("трейлинг %s от %.8f на %.8f", p.Trail, t.best, distance)
+			}
+		}
+	}
+	return move, move.Stop != t.Stop
+}
+
+// Commit применяет перенос стопа, предложенный Propose.
+func (t *TradePlan) Commit(move StopMove) {
+	if move.BreakEven {
+		t.breakEven = true
+	}
+	if move.Kind != "" {
+		t.Stop, t.stopKind = move.Stop, move.Kind
+	}
+}
+
+// StopKind возвращает вид текущего стопа: StopInitial, StopBreakEven или StopTrailing.
+func (t *TradePlan) StopKind() string {
+	return t.stopKind
+}
+
+// better сообщает, защищает ли стоп a больше прибыли, чем b.
+func (t *TradePlan) better(a, b float64) bool {
+	if t.Side == "short" {
+		return b <= 0 || a < b
+	}
+	return a > b
+}
+
+// BreakEven сообщает, переносился ли стоп в безубыток.
+func (t *TradePlan) BreakEven() bool {
+	return t.breakEven
+}
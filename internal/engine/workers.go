@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Пауза перед перезапуском упавшего воркера: удваивается после каждой ошибки подряд.
+const (
+	DefaultRestartDelay = 10 * time.Second
+	maxRestartDelay     = 5 * time.Minute
+)
+
+// RunWorkers запускает каждый Engine в отдельной горутине и ждёт их завершения после отмены ctx.
+// Воркеры изолированы: ошибка или паника одного символа не останавливает остальные,
+// а упавший воркер перезапускается с паузой restartDelay (0 — DefaultRestartDelay).
+// Клиент биржи, риск-контроль и роутер ордеров общие для всех воркеров.
+func RunWorkers(ctx context.Context, engines []*Engine, restartDelay time.Duration) {
+	if restartDelay <= 0 {
+		restartDelay = DefaultRestartDelay
+	}
+	var wg sync.WaitGroup
+	for _, e := range engines {
+		wg.Add(1)
+		go func(e *Engine) {
+			defer wg.Done()
+			e.supervise(ctx, restartDelay)
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (e *Engine) supervise(ctx context.Context, restartDelay time.Duration) {
+	delay := restartDelay
+	for {
+		started := time.Now()
+		err := e.runRecovered(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// Воркер, проработавший дольше паузы, считается восстановившимся.
+		if time.Since(started) > delay {
+			delay = restartDelay
+		}
+		log.Printf("[%s/%s] Воркер остановлен: %v; перезапуск через %s", e.Strategy.Name(), e.Symbol, err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRestartDelay)
+	}
+}
+
+// runRecovered выполняет Run, превращая панику вне обработчиков событий (Init, Feed) в ошибку.
+func (e *Engine) runRecovered(ctx context.Context) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("[%s/%s] Паника воркера: %v\n%s", e.Strategy.Name(), e.Symbol, rec, debug.Stack())
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	if err := e.Run(ctx); err != nil {
+		return err
+	}
+	return fmt.Errorf("feed closed")
+}
@@ -0,0 +1,64 @@
+package indicator
+
+import (
+	"bybit-bot/internal/model"
+	"math"
+)
+
+// ATR — средний истинный диапазон Уайлдера (talib.Atr). Истинный диапазон первой свечи
+// не определён, поэтому первое значение готово на свече period+1.
+type ATR struct {
+	warmup
+	period    int
+	prevClose float64
+	total     float64
+	value     float64
+}
+
+// NewATR создаёт ATR с периодом period (не меньше 1; при 1 значение — истинный диапазон, talib.TRange).
+func NewATR(period int) *ATR {
+	period = max(period, 1)
+	return &ATR{warmup: warmup{need: period + 1}, period: period}
+}
+
+func (a *ATR) Update(k model.KlineData) { a.Add(k.High, k.Low, k.Close) }
+
+// Add учитывает следующую свечу.
+func (a *ATR) Add(high, low, close float64) {
+	a.n++
+	if a.n == 1 {
+		a.prevClose = close
+		return
+	}
+	tr := trueRange(high, low, a.prevClose)
+	a.prevClose = close
+	period := float64(a.period)
+	switch {
+	case a.period == 1:
+		a.value = tr
+	case a.n < a.need:
+		a.total += tr
+	case a.n == a.need:
+		a.total += tr
+		a.value = a.total / period
+	default:
+		a.value *= period - 1.0
+		a.value += tr
+		a.value /= period
+	}
+}
+
+// Value возвращает текущее значение ATR.
+func (a *ATR) Value() float64 { return a.value }
+
+// trueRange — наибольшее из high-low, |prevClose-high| и |prevClose-low| (talib.TRange).
+func trueRange(high, low, prevClose float64) float64 {
+	greatest := high - low
+	if v := math.Abs(prevClose - high); v > greatest {
+		greatest = v
+	}
+	if v := math.Abs(prevClose - low); v > greatest {
+		greatest = v
+	}
+	return greatest
+}
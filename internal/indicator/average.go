@@ -0,0 +1,72 @@
+package indicator
+
+import "bybit-bot/internal/model"
+
+// SMA — простая скользящая средняя цен закрытия (talib.Sma).
+type SMA struct {
+	warmup
+	period int
+	win    window
+	total  float64
+	value  float64
+}
+
+// NewSMA создаёт SMA с периодом period (не меньше 1).
+func NewSMA(period int) *SMA {
+	period = max(period, 1)
+	return &SMA{warmup: warmup{need: period}, period: period, win: newWindow(period)}
+}
+
+func (s *SMA) Update(k model.KlineData) { s.Add(k.Close) }
+
+// Add учитывает следующее значение ряда.
+func (s *SMA) Add(x float64) {
+	s.n++
+	s.total += x
+	if s.Ready() {
+		s.value = s.total / float64(s.period)
+	}
+	s.total -= s.win.push(x)
+}
+
+// Value возвращает среднее последних period значений.
+func (s *SMA) Value() float64 { return s.value }
+
+// EMA — экспоненциальная скользящая средняя цен закрытия (talib.Ema): первое значение —
+// SMA первых period значений, далее EMA += (x - EMA) * 2/(period+1).
+type EMA struct {
+	warmup
+	period int
+	k      float64
+	total  float64
+	value  float64
+}
+
+// NewEMA создаёт EMA с периодом period (не меньше 1).
+func NewEMA(period int) *EMA {
+	period = max(period, 1)
+	return newEMA(period, 2.0/float64(period+1))
+}
+
+func newEMA(period int, k float64) *EMA {
+	return &EMA{warmup: warmup{need: period}, period: period, k: k}
+}
+
+func (e *EMA) Update(k model.KlineData) { e.Add(k.Close) }
+
+// Add учитывает следующее значение ряда.
+func (e *EMA) Add(x float64) {
+	e.n++
+	switch {
+	case e.n < e.period:
+		e.total += x
+	case e.n == e.period:
+		e.total += x
+		e.value = e.total / float64(e.period)
+	default:
+		e.value = ((x - e.value) * e.k) + e.value
+	}
+}
+
+// Value возвращает текущее значение EMA.
+func (e *EMA) Value() float64 { return e.value }
@@ -0,0 +1,56 @@
+package indicator
+
+import (
+	"bybit-bot/internal/model"
+	"math"
+)
+
+// Bollinger — полосы Боллинджера на SMA цен закрытия (talib.BBands с talib.SMA):
+// средняя линия ± стандартное отклонение за period, умноженное на DevUp и DevDown.
+type Bollinger struct {
+	warmup
+	period  int
+	devUp   float64
+	devDown float64
+	win     window
+	total   float64
+	totalSq float64
+
+	upper  float64
+	middle float64
+	lower  float64
+}
+
+// NewBollinger создаёт полосы с периодом period (не меньше 1) и множителями отклонения.
+func NewBollinger(period int, devUp, devDown float64) *Bollinger {
+	period = max(period, 1)
+	return &Bollinger{warmup: warmup{need: period}, period: period, devUp: devUp, devDown: devDown, win: newWindow(period)}
+}
+
+func (b *Bollinger) Update(k model.KlineData) { b.Add(k.Close) }
+
+// Add учитывает следующее значение ряда.
+func (b *Bollinger) Add(x float64) {
+	b.n++
+	b.total += x
+	b.totalSq += x * x
+	if b.Ready() {
+		mean := b.total / float64(b.period)
+		variance := b.totalSq/float64(b.period) - mean*mean
+		stdDev := 0.0
+		if !(variance < 0.00000000000001) {
+			stdDev = math.Sqrt(variance)
+		}
+		b.middle = mean
+		b.upper = mean + stdDev*b.devUp
+		b.lower = mean - stdDev*b.devDown
+	}
+	oldest := b.win.push(x)
+	b.total -= oldest
+	b.totalSq -= oldest * oldest
+}
+
+// Values возвращает верхнюю, среднюю и нижнюю полосы.
+func (b *Bollinger) Values() (upper, middle, lower float64) {
+	return b.upper, b.middle, b.lower
+}
@@ -0,0 +1,60 @@
+// Package indicator — инкрементальные технические индикаторы. Каждая новая закрытая свеча
+// учитывается за O(1) без пересчёта истории, а после прогрева значения совпадают с go-talib
+// на той же последовательности свечей (включая порядок операций с плавающей точкой).
+// До прогрева значения нулевые, как в выходных массивах talib.
+//
+// Индикаторы не потокобезопасны: синхронизацию обеспечивает владелец.
+package indicator
+
+import "bybit-bot/internal/model"
+
+// Indicator — индикатор, обновляемый закрытыми свечами.
+type Indicator interface {
+	// Update учитывает следующую закрытую свечу.
+	Update(k model.KlineData)
+	// Count возвращает число учтённых свечей.
+	Count() int
+	// WarmUp возвращает число свечей, после которого индикатор готов.
+	WarmUp() int
+	// Ready сообщает, что прогрев завершён.
+	Ready() bool
+}
+
+// warmup считает учтённые свечи и отслеживает прогрев.
+type warmup struct {
+	n    int
+	need int
+}
+
+func (w *warmup) Count() int  { return w.n }
+func (w *warmup) WarmUp() int { return w.need }
+func (w *warmup) Ready() bool { return w.n >= w.need }
+
+// window — кольцевой буфер последних значений для скользящих сумм.
+type window struct {
+	buf []float64
+	pos int
+}
+
+func newWindow(size int) window {
+	return window{buf: make([]float64, size)}
+}
+
+// push записывает x и возвращает самое старое значение окна, включающего x.
+// Пока окно не заполнено, возвращается 0.
+func (w *window) push(x float64) float64 {
+	w.buf[w.pos] = x
+	w.pos = (w.pos + 1) % len(w.buf)
+	return w.buf[w.pos]
+}
+
+var (
+	_ Indicator = (*SMA)(nil)
+	_ Indicator = (*EMA)(nil)
+	_ Indicator = (*RSI)(nil)
+	_ Indicator = (*ATR)(nil)
+	_ Indicator = (*MACD)(nil)
+	_ Indicator = (*Bollinger)(nil)
+	_ Indicator = (*VWAP)(nil)
+	_ Indicator = (*OBV)(nil)
+)
@@ -0,0 +1,150 @@
+package indicator
+
+import (
+	"bybit-bot/internal/model"
+	"github.com/markcheno/go-talib"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var seriesStart = time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+
+// testSeries возвращает n минутных свечей случайного блуждания с участками без движения
+// цены и без объёма, чтобы проверить деление на ноль в RSI, Bollinger и VWAP.
+func testSeries(n int) []model.KlineData {
+	rnd := rand.New(rand.NewSource(42))
+	klines := make([]model.KlineData, n)
+	price := 100.0
+	for i := range klines {
+		open := price
+		if i%50 >= 40 {
+			// плоский участок: цена не меняется, объёма нет
+			klines[i] = model.KlineData{Start: seriesStart.Add(time.Duration(i) * time.Minute).UnixMilli(), Open: price, High: price, Low: price, Close: price}
+			continue
+		}
+		price += rnd.NormFloat64()
+		klines[i] = model.KlineData{
+			Start:  seriesStart.Add(time.Duration(i) * time.Minute).UnixMilli(),
+			Open:   open,
+			High:   math.Max(open, price) + rnd.Float64(),
+			Low:    math.Min(open, price) - rnd.Float64(),
+			Close:  price,
+			Volume: 1 + rnd.Float64()*100,
+		}
+	}
+	return klines
+}
+
+func columns(klines []model.KlineData) (high, low, close, volume []float64) {
+	for _, k := range klines {
+		high = append(high, k.High)
+		low = append(low, k.Low)
+		close = append(close, k.Close)
+		volume = append(volume, k.Volume)
+	}
+	return high, low, close, volume
+}
+
+// check прогоняет ind по klines и после каждой свечи, включая прогрев, сравнивает value()
+// с выходными массивами talib want; готовность должна наступать ровно на свече lookback.
+func check(t *testing.T, name string, ind Indicator, klines []model.KlineData, lookback int, want [][]float64, value func() []float64) {
+	t.Helper()
+	if ind.WarmUp() != lookback+1 {
+		t.Fatalf("%s: WarmUp = %d, want %d", name, ind.WarmUp(), lookback+1)
+	}
+	for i, k := range klines {
+		ind.Update(k)
+		if ind.Count() != i+1 {
+			t.Fatalf("%s: Count = %d after %d klines", name, ind.Count(), i+1)
+		}
+		if ready := i >= lookback; ind.Ready() != ready {
+			t.Fatalf("%s: Ready = %v at kline %d, want %v", name, ind.Ready(), i, ready)
+		}
+		for j, got := range value() {
+			if got != want[j][i] {
+				t.Fatalf("%s: value %d at kline %d = %v, talib %v", name, j, i, got, want[j][i])
+			}
+		}
+	}
+}
+
+func TestIndicatorsMatchTalib(t *testing.T) {
+	klines := testSeries(300)
+	high, low, close, volume := columns(klines)
+
+	for _, period := range []int{1, 2, 9, 21, 50} {
+		sma := NewSMA(period)
+		check(t, "SMA", sma, klines, period-1, [][]float64{talib.Sma(close, period)}, func() []float64 { return []float64{sma.Value()} })
+		ema := NewEMA(period)
+		check(t, "EMA", ema, klines, period-1, [][]float64{talib.Ema(close, period)}, func() []float64 { return []float64{ema.Value()} })
+	}
+	for _, period := range []int{2, 7, 14} {
+		rsi := NewRSI(period)
+		want := talib.Rsi(close, period)
+		check(t, "RSI", rsi, klines, period, [][]float64{want}, func() []float64 { return []float64{rsi.Value()} })
+		prev := append([]float64{0}, want[:len(want)-1]...)
+		rsi = NewRSI(period)
+		check(t, "RSI.Prev", rsi, klines, period, [][]float64{prev}, func() []float64 { return []float64{rsi.Prev()} })
+	}
+	for _, period := range []int{1, 2, 14} {
+		atr := NewATR(period)
+		check(t, "ATR", atr, klines, period, [][]float64{talib.Atr(high, low, close, period)}, func() []float64 { return []float64{atr.Value()} })
+	}
+	for _, p := range [][3]int{{12, 26, 9}, {5, 13, 1}, {26, 12, 9}} {
+		macd := NewMACD(p[0], p[1], p[2])
+		m, s, h := talib.Macd(close, p[0], p[1], p[2])
+		lookback := max(p[0], p[1]) - 1 + p[2] - 1
+		check(t, "MACD", macd, klines, lookback, [][]float64{m, s, h}, func() []float64 {
+			m, s, h := macd.Values()
+			return []float64{m, s, h}
+		})
+	}
+	for _, period := range []int{5, 20} {
+		bb := NewBollinger(period, 2, 1.5)
+		u, m, l := talib.BBands(close, period, 2, 1.5, talib.SMA)
+		check(t, "Bollinger", bb, klines, period-1, [][]float64{u, m, l}, func() []float64 {
+			u, m, l := bb.Values()
+			return []float64{u, m, l}
+		})
+	}
+	obv := NewOBV()
+	check(t, "OBV", obv, klines, 0, [][]float64{talib.Obv(close, volume)}, func() []float64 { return []float64{obv.Value()} })
+}
+
+// TestVWAP сверяет VWAP с накопленными суммами типичной цены talib.TypPrice: в talib VWAP нет.
+func TestVWAP(t *testing.T) {
+	klines := testSeries(300)
+	high, low, close, volume := columns(klines)
+	typical := talib.TypPrice(high, low, close)
+
+	for _, session := range []time.Duration{0, 24 * time.Hour} {
+		vwap := NewVWAP(session)
+		var totalPV, totalV float64
+		count := 0
+		for i, k := range klines {
+			if session > 0 && i > 0 && !time.UnixMilli(k.Start).UTC().Truncate(session).Equal(time.UnixMilli(klines[i-1].Start).UTC().Truncate(session)) {
+				totalPV, totalV, count = 0, 0, 0
+			}
+			totalPV += typical[i] * volume[i]
+			totalV += volume[i]
+			count++
+			want := typical[i]
+			if totalV != 0 {
+				want = totalPV / totalV
+			}
+
+			vwap.Update(k)
+			if !vwap.Ready() || vwap.Count() != count {
+				t.Fatalf("session %s: kline %d: Ready = %v, Count = %d, want %d", session, i, vwap.Ready(), vwap.Count(), count)
+			}
+			if got := vwap.Value(); math.Abs(got-want) > 1e-9*math.Abs(want) {
+				t.Fatalf("session %s: kline %d: VWAP = %v, want %v", session, i, got, want)
+			}
+		}
+		if session > 0 && count == len(klines) {
+			t.Fatalf("series does not cross a session boundary")
+		}
+	}
+}
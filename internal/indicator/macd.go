@@ -0,0 +1,68 @@
+package indicator
+
+import "bybit-bot/internal/model"
+
+// MACD — схождение-расхождение скользящих средних (talib.Macd). Как и talib, до прогрева
+// медленной EMA линия MACD считается нулевой, поэтому сигнальная EMA начинается с нулей.
+// Линии MACD и сигнальная, как в talib, появляются на свече раньше гистограммы;
+// Ready означает, что готовы все три значения (свеча slow+signal-1).
+type MACD struct {
+	warmup
+	fast     *EMA
+	slow     *EMA
+	signal   *EMA
+	lookback int // индекс свечи, с которой линия MACD передаётся в сигнальную EMA
+
+	macd      float64
+	signalVal float64
+	hist      float64
+}
+
+// NewMACD создаёт MACD с периодами fast, slow и signal. Как в talib, fast и slow меняются
+// местами, если slow < fast, а нулевые периоды заменяются на 12 и 26 с коэффициентами 0.15 и 0.075.
+func NewMACD(fast, slow, signal int) *MACD {
+	if slow < fast {
+		slow, fast = fast, slow
+	}
+	slowEMA := newEMA(26, 0.075)
+	if slow != 0 {
+		slowEMA = NewEMA(slow)
+	}
+	fastEMA := newEMA(12, 0.15)
+	if fast != 0 {
+		fastEMA = NewEMA(fast)
+	}
+	signal = max(signal, 1)
+	lookback := (signal - 1) + (slowEMA.period - 1)
+	return &MACD{
+		warmup:   warmup{need: lookback + 1},
+		fast:     fastEMA,
+		slow:     slowEMA,
+		signal:   NewEMA(signal),
+		lookback: max(lookback-1, 0),
+	}
+}
+
+func (m *MACD) Update(k model.KlineData) { m.Add(k.Close) }
+
+// Add учитывает следующее значение ряда.
+func (m *MACD) Add(x float64) {
+	m.n++
+	m.fast.Add(x)
+	m.slow.Add(x)
+	line := 0.0
+	if m.n-1 >= m.lookback {
+		line = m.fast.Value() - m.slow.Value()
+	}
+	m.signal.Add(line)
+	m.macd = line
+	m.signalVal = m.signal.Value()
+	if m.Ready() {
+		m.hist = line - m.signalVal
+	}
+}
+
+// Values возвращает линию MACD, сигнальную линию и гистограмму.
+func (m *MACD) Values() (macd, signal, hist float64) {
+	return m.macd, m.signalVal, m.hist
+}
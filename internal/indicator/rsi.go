@@ -0,0 +1,60 @@
+package indicator
+
+import "bybit-bot/internal/model"
+
+// RSI — индекс относительной силы Уайлдера по ценам закрытия (talib.Rsi).
+type RSI struct {
+	warmup
+	period int
+	prev   float64
+	gain   float64
+	loss   float64
+	value  float64
+	last   float64 // значение на предыдущей свече
+}
+
+// NewRSI создаёт RSI с периодом period (не меньше 2: при меньшем talib возвращает нули).
+func NewRSI(period int) *RSI {
+	period = max(period, 2)
+	return &RSI{warmup: warmup{need: period + 1}, period: period}
+}
+
+func (r *RSI) Update(k model.KlineData) { r.Add(k.Close) }
+
+// Add учитывает следующее значение ряда.
+func (r *RSI) Add(x float64) {
+	r.last = r.value
+	r.n++
+	if r.n == 1 {
+		r.prev = x
+		return
+	}
+	diff := x - r.prev
+	r.prev = x
+	period := float64(r.period)
+	if r.n > r.need {
+		r.loss *= period - 1
+		r.gain *= period - 1
+	}
+	if diff < 0 {
+		r.loss -= diff
+	} else {
+		r.gain += diff
+	}
+	if !r.Ready() {
+		return
+	}
+	r.loss /= period
+	r.gain /= period
+	if sum := r.gain + r.loss; !((-0.00000000000001 < sum) && (sum < 0.00000000000001)) {
+		r.value = 100.0 * (r.gain / sum)
+	} else {
+		r.value = 0
+	}
+}
+
+// Value возвращает RSI от 0 до 100.
+func (r *RSI) Value() float64 { return r.value }
+
+// Prev возвращает RSI предыдущей свечи (ноль, если тогда прогрев ещё не завершился).
+func (r *RSI) Prev() float64 { return r.last }
@@ -0,0 +1,82 @@
+package indicator
+
+import (
+	"bybit-bot/internal/model"
+	"time"
+)
+
+// OBV — балансовый объём (talib.Obv): объём свечи прибавляется при росте цены закрытия
+// и вычитается при падении. Первое значение — объём первой свечи.
+type OBV struct {
+	warmup
+	prevClose float64
+	value     float64
+}
+
+// NewOBV создаёт OBV.
+func NewOBV() *OBV {
+	return &OBV{warmup: warmup{need: 1}}
+}
+
+func (o *OBV) Update(k model.KlineData) { o.Add(k.Close, k.Volume) }
+
+// Add учитывает цену закрытия и объём следующей свечи.
+func (o *OBV) Add(close, volume float64) {
+	o.n++
+	switch {
+	case o.n == 1:
+		o.value = volume
+	case close > o.prevClose:
+		o.value += volume
+	case close < o.prevClose:
+		o.value -= volume
+	}
+	o.prevClose = close
+}
+
+// Value возвращает текущий OBV.
+func (o *OBV) Value() float64 { return o.value }
+
+// VWAP — средневзвешенная по объёму типичная цена (high+low+close)/3 с начала сессии.
+// В talib VWAP нет. Сессии отсчитываются от полуночи UTC: при Session = 24h VWAP
+// сбрасывается каждые сутки, при 0 накапливается без сброса. Count считает свечи текущей сессии.
+type VWAP struct {
+	warmup
+	session time.Duration
+	start   time.Time // начало текущей сессии
+	totalPV float64
+	totalV  float64
+	last    float64 // типичная цена последней свечи: значение при нулевом объёме сессии
+}
+
+// NewVWAP создаёт VWAP с длительностью сессии session.
+func NewVWAP(session time.Duration) *VWAP {
+	return &VWAP{warmup: warmup{need: 1}, session: session}
+}
+
+// Update учитывает свечу; Start свечи — в миллисекундах, как в потоке и REST Bybit.
+func (v *VWAP) Update(k model.KlineData) {
+	v.Add(time.UnixMilli(k.Start), k.High, k.Low, k.Close, k.Volume)
+}
+
+// Add учитывает свечу, начавшуюся в момент t.
+func (v *VWAP) Add(t time.Time, high, low, close, volume float64) {
+	if v.session > 0 {
+		if start := t.UTC().Truncate(v.session); !start.Equal(v.start) {
+			v.start = start
+			v.n, v.totalPV, v.totalV = 0, 0, 0
+		}
+	}
+	v.n++
+	v.last = (high + low + close) / 3
+	v.totalPV += v.last * volume
+	v.totalV += volume
+}
+
+// Value возвращает VWAP текущей сессии.
+func (v *VWAP) Value() float64 {
+	if v.totalV == 0 {
+		return v.last
+	}
+	return v.totalPV / v.totalV
+}
@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"bybit-bot/internal/model"
+	"time"
+)
+
+// Exchange — REST-операции биржи, которые использует бот: рыночные данные, инструменты,
+// комиссии, баланс, ордера и позиции. Реализации: client.ByBit и детерминированный
+// client.Simulated для тестов и бумажной торговли.
+type Exchange interface {
+	LimitsProvider
+	OrderAmender
+
+	// GetKlines возвращает до limit свечей интервала interval от новых к старым, как Bybit;
+	// первая свеча может быть незакрытой. Start и End — в миллисекундах.
+	GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error)
+	GetDepth(symbol, category string, limit int64) (*model.OrderbookData, error)
+	GetTickers(category, symbol string) ([]model.WSTickerPrice, error)
+	// GetTradingFees возвращает ставку комиссии тейкера.
+	GetTradingFees(category, symbol string) (float64, error)
+	GetWalletInfo(coin string) (*model.WalletInfoRep, error)
+
+	// PlaceOrder размещает ордер с TP/SL на всю позицию и возвращает его идентификатор.
+	PlaceOrder(symbol, side, orderType string, price, qty, stopLoss, takeProfit float64) (string, error)
+	// ClosePosition закрывает qty позиции рыночным reduce-only ордером стороны side.
+	ClosePosition(category, symbol, side string, qty float64) error
+	ListOpenOrders(category, symbol string) ([]model.OrderUpdate, error)
+	GetPositions(category, symbol string) ([]model.PositionUpdate, error)
+	GetExecutions(category, symbol string, since time.Time) ([]model.ExecutionUpdate, error)
+}
@@ -1,5 +1,45 @@
 package interfaces
 
+import "bybit-bot/internal/model"
+
 type Executor interface {
-	PlaceLimitOrder(symbol, side string, price, qty, sl, tp float64) error
+	// PlaceLimitOrder возвращает идентификатор размещённого ордера; пустой, если ордер не размещён.
+	PlaceLimitOrder(symbol, side string, price, qty, sl, tp float64) (string, error)
+	// CountOpenOrders возвращает число активных ордеров по символу,
+	// включая исполненный вход, позиция по которому ещё не закрыта.
+	CountOpenOrders(category, symbol string) (int, error)
+}
+
+// IntentHandler принимает намерения стратегий и превращает их в ордера.
+type IntentHandler interface {
+	// Submit возвращает идентификатор размещённого ордера; пустой, если ордер не размещён.
+	Submit(intent model.OrderIntent) (string, error)
+}
+
+// LimitsProvider возвращает торговые лимиты инструмента.
+type LimitsProvider interface {
+	GetTradeLimitsViaInstruments(category, symbol string) (model.TradeLimits, error)
+}
+
+// BalanceChecker проверяет, хватает ли средств на ордера.
+type BalanceChecker interface {
+	CheckBalance(buyPrice, buyQuantity, sellPrice, sellQuantity float64) bool
+}
+
+// Sizer рассчитывает объём позиции по капиталу, стопу и лимитам инструмента.
+type Sizer interface {
+	CalculateQuantity(req model.SizingRequest) (model.SizingResult, error)
+}
+
+// BalanceSource отдаёт доступный баланс монеты.
+type BalanceSource interface {
+	AvailableBalance(coin string) (float64, error)
+}
+
+// OrderAmender отменяет, изменяет и запрашивает размещённые ордера.
+type OrderAmender interface {
+	CancelOrder(category, symbol, orderID string) error
+	AmendOrder(category, symbol, orderID string, price, qty float64) error
+	// GetOrderStatus возвращает nil без ошибки, если ордер на бирже не найден.
+	GetOrderStatus(category, symbol, orderID string) (*model.OrderUpdate, error)
 }
@@ -1,7 +1,16 @@
 package interfaces
 
-import "bybit-bot/internal/model"
+import (
+	"bybit-bot/internal/model"
+	"time"
+)
 
 type Service interface {
 	GetRecentKlines(symbol, interval string, n int) ([]model.KlineData, bool)
 }
+
+// OrderbookSource отдаёт актуальный ордербук и состояние источника данных.
+type OrderbookSource interface {
+	GetOrderbookByTopic(topic string) (*model.OrderbookData, bool)
+	IsHealthy(maxAge time.Duration) bool
+}
@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type KlineData struct {
 	Start     int64   `json:"start"`           // время начала свечи (миллисекунды)
@@ -20,6 +23,24 @@ type KlineData struct {
 
 const PriceValidSecondes = 30
 
+// DefaultSignalInterval — интервал свечей сигналов стратегии по умолчанию (trading.interval).
+const DefaultSignalInterval = "30"
+
+// KlineIntervalDuration возвращает длительность интервала свечей Bybit v5.
+// Месячный интервал "M" не имеет фиксированной длины и не поддерживается.
+func KlineIntervalDuration(interval string) (time.Duration, bool) {
+	switch interval {
+	case "1", "3", "5", "15", "30", "60", "120", "240", "360", "720":
+		minutes, _ := strconv.Atoi(interval)
+		return time.Duration(minutes) * time.Minute, true
+	case "D":
+		return 24 * time.Hour, true
+	case "W":
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
 func (k *KlineData) IsPriceExpiredesas() bool {
 	return (time.Now().Unix() - (k.UpdatedAt)) > PriceValidSecondes
 }
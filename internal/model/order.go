@@ -83,6 +83,58 @@ type TradeOrders struct {
 	Orders []Order
 }
 
+// Статусы ордеров, которые хранятся в таблице orders.
+const (
+	OrderStatusOpen            = "open"
+	OrderStatusPartiallyFilled = "partially_filled"
+	OrderStatusFilled          = "filled"
+	OrderStatusCancelled       = "cancelled"
+	OrderStatusRejected        = "rejected"
+)
+
+// OrderStatusFromBybit переводит статус ордера Bybit (orderStatus) в локальный статус.
+// Пустая строка означает, что статус не влияет на жизненный цикл (например, Untriggered).
+func OrderStatusFromBybit(status string) string {
+	switch status {
+	case "New", "Created":
+		return OrderStatusOpen
+	case "PartiallyFilled":
+		return OrderStatusPartiallyFilled
+	case "Filled":
+		return OrderStatusFilled
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return OrderStatusCancelled
+	case "Rejected":
+		return OrderStatusRejected
+	default:
+		return ""
+	}
+}
+
+// IsFinalOrderStatus сообщает, что ордер завершён и больше не меняется.
+func IsFinalOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected:
+		return true
+	}
+	return false
+}
+
+// IsActiveOrderStatus сообщает, что ордер стоит в стакане.
+func IsActiveOrderStatus(status string) bool {
+	return status == OrderStatusOpen || status == OrderStatusPartiallyFilled
+}
+
+// CanTransitionOrder проверяет переход жизненного цикла
+// open -> partially_filled -> filled/cancelled/rejected. Из завершённого статуса выхода нет,
+// а частично исполненный ордер не может вернуться в open.
+func CanTransitionOrder(from, to string) bool {
+	if from == to || IsFinalOrderStatus(from) {
+		return false
+	}
+	return !(from == OrderStatusPartiallyFilled && to == OrderStatusOpen)
+}
+
 func (o *Order) GetBaseAsset() string {
 	return strings.ReplaceAll(o.Symbol, "USDT", "")
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// OrderIntent — намерение стратегии открыть позицию. Стратегия определяет направление и уровни,
+// а форматирование цен, расчёт объёма и проверку баланса выполняет обработчик намерений.
+type OrderIntent struct {
+	Strategy   string    `json:"strategy"`
+	Symbol     string    `json:"symbol"`
+	Category   string    `json:"category"`
+	Side       string    `json:"side"` // long или short
+	EntryPrice float64   `json:"entry_price"`
+	StopLoss   float64   `json:"stop_loss"`
+	TakeProfit float64   `json:"take_profit"`
+	ATR        float64   `json:"atr"`    // волатильность на момент сигнала для расчёта объёма; 0 — неизвестна
+	Reason     string    `json:"reason"` // обоснование уровней SL/TP (model.SLTPResult.Rationale)
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrderSide возвращает сторону ордера Bybit для направления намерения.
+func (i OrderIntent) OrderSide() string {
+	if i.Side == "short" {
+		return "sell"
+	}
+	return "buy"
+}
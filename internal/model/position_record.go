@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// Статусы позиций в таблице positions.
+const (
+	PositionStatusOpen   = "open"
+	PositionStatusClosed = "closed"
+)
+
+// Причины закрытия сделки.
+const (
+	CloseReasonTakeProfit = "take_profit"
+	CloseReasonStopLoss   = "stop_loss"
+	CloseReasonTrailing   = "trailing_stop"
+	CloseReasonManual     = "close"     // встречный ордер бота или ручное закрытие
+	CloseReasonReconcile  = "reconcile" // позиция закрыта на бирже без учтённых исполнений
+)
+
+// PositionRep — позиция, восстановленная по исполнениям (таблица positions).
+// Side — "long" или "short". Fees — комиссии входа, ещё не отнесённые на закрытые сделки.
+type PositionRep struct {
+	ID          int64      `json:"id"`
+	Symbol      string     `json:"symbol"`
+	Side        string     `json:"side"`
+	Size        float64    `json:"size"`
+	EntryPrice  float64    `json:"entry_price"` // средняя цена входа
+	RealizedPnL float64    `json:"realized_pnl"`
+	Fees        float64    `json:"fees"`
+	Status      string     `json:"status"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	LastFillAt  time.Time  `json:"last_fill_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Direction возвращает 1 для длинной позиции и -1 для короткой.
+func (p *PositionRep) Direction() float64 {
+	if p.Side == "short" {
+		return -1
+	}
+	return 1
+}
+
+// UnrealizedPnL возвращает нереализованный результат по цене price без учёта комиссий.
+func (p *PositionRep) UnrealizedPnL(price float64) float64 {
+	return (price - p.EntryPrice) * p.Size * p.Direction()
+}
+
+// ClosedTradeRep — закрытие позиции полностью или частично (таблица closed_trades).
+// Fees включает долю комиссий входа и комиссию выхода; PnL — результат за вычетом Fees.
+type ClosedTradeRep struct {
+	ID         int64     `json:"id"`
+	PositionID int64     `json:"position_id"`
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	Qty        float64   `json:"qty"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	GrossPnL   float64   `json:"gross_pnl"`
+	Fees       float64   `json:"fees"`
+	PnL        float64   `json:"pnl"`
+	Reason     string    `json:"reason"`
+	OrderID    string    `json:"order_id"`
+	OpenedAt   time.Time `json:"opened_at"`
+	ClosedAt   time.Time `json:"closed_at"`
+}
+
+// PnLSummary — сводка по закрытым сделкам за период.
+type PnLSummary struct {
+	Trades   int     `json:"trades"`
+	Wins     int     `json:"wins"`
+	Losses   int     `json:"losses"`
+	GrossPnL float64 `json:"gross_pnl"`
+	Fees     float64 `json:"fees"`
+	NetPnL   float64 `json:"net_pnl"`
+}
+
+// CloseReasonFromStopOrderType определяет причину закрытия по stopOrderType исполнения Bybit.
+func CloseReasonFromStopOrderType(stopOrderType string) string {
+	switch stopOrderType {
+	case "TakeProfit", "PartialTakeProfit":
+		return CloseReasonTakeProfit
+	case "StopLoss", "PartialStopLoss":
+		return CloseReasonStopLoss
+	case "TrailingStop":
+		return CloseReasonTrailing
+	default:
+		return CloseReasonManual
+	}
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/thrasher-corp/gocryptotrader/types"
+)
+
+// PrivateMessage — общий конверт сообщений приватного потока Bybit (order, execution, position, wallet).
+type PrivateMessage struct {
+	ID           string          `json:"id"`
+	Topic        string          `json:"topic"`
+	CreationTime int64           `json:"creationTime"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// OrderUpdate — обновление ордера из топика order.
+type OrderUpdate struct {
+	Category      string       `json:"category"`
+	Symbol        string       `json:"symbol"`
+	OrderID       string       `json:"orderId"`
+	OrderLinkID   string       `json:"orderLinkId"`
+	Side          string       `json:"side"`
+	OrderType     string       `json:"orderType"`
+	Price         types.Number `json:"price"`
+	Qty           types.Number `json:"qty"`
+	AvgPrice      types.Number `json:"avgPrice"`
+	CumExecQty    types.Number `json:"cumExecQty"`
+	CumExecFee    types.Number `json:"cumExecFee"`
+	LeavesQty     types.Number `json:"leavesQty"`
+	OrderStatus   string       `json:"orderStatus"`
+	RejectReason  string       `json:"rejectReason"`
+	CancelType    string       `json:"cancelType"`
+	StopOrderType string       `json:"stopOrderType"`
+	TakeProfit    types.Number `json:"takeProfit"`
+	StopLoss      types.Number `json:"stopLoss"`
+	ReduceOnly    bool         `json:"reduceOnly"`
+	CreatedTime   types.Time   `json:"createdTime"`
+	UpdatedTime   types.Time   `json:"updatedTime"`
+}
+
+// ExecutionUpdate — исполнение (fill) из топика execution.
+type ExecutionUpdate struct {
+	Category      string       `json:"category"`
+	Symbol        string       `json:"symbol"`
+	ExecID        string       `json:"execId"`
+	OrderID       string       `json:"orderId"`
+	OrderLinkID   string       `json:"orderLinkId"`
+	Side          string       `json:"side"`
+	OrderType     string       `json:"orderType"`
+	StopOrderType string       `json:"stopOrderType"`
+	ExecPrice     types.Number `json:"execPrice"`
+	ExecQty       types.Number `json:"execQty"`
+	ExecValue     types.Number `json:"execValue"`
+	ExecFee       types.Number `json:"execFee"`
+	FeeRate       types.Number `json:"feeRate"`
+	ExecType      string       `json:"execType"`
+	IsMaker       bool         `json:"isMaker"`
+	ClosedSize    types.Number `json:"closedSize"`
+	LeavesQty     types.Number `json:"leavesQty"`
+	MarkPrice     types.Number `json:"markPrice"`
+	ExecTime      types.Time   `json:"execTime"`
+}
+
+// PositionUpdate — состояние позиции из топика position.
+type PositionUpdate struct {
+	Category       string       `json:"category"`
+	Symbol         string       `json:"symbol"`
+	Side           string       `json:"side"` // Buy, Sell или "" для пустой позиции
+	Size           types.Number `json:"size"`
+	EntryPrice     types.Number `json:"entryPrice"`
+	MarkPrice      types.Number `json:"markPrice"`
+	PositionValue  types.Number `json:"positionValue"`
+	Leverage       types.Number `json:"leverage"`
+	UnrealisedPnl  types.Number `json:"unrealisedPnl"`
+	CumRealisedPnl types.Number `json:"cumRealisedPnl"`
+	TakeProfit     types.Number `json:"takeProfit"`
+	StopLoss       types.Number `json:"stopLoss"`
+	TrailingStop   types.Number `json:"trailingStop"`
+	PositionStatus string       `json:"positionStatus"`
+	TpslMode       string       `json:"tpslMode"`
+	CreatedTime    types.Time   `json:"createdTime"`
+	UpdatedTime    types.Time   `json:"updatedTime"`
+}
+
+// WalletUpdate — состояние кошелька из топика wallet.
+type WalletUpdate struct {
+	AccountType        string             `json:"accountType"`
+	TotalEquity        types.Number       `json:"totalEquity"`
+	TotalWalletBalance types.Number       `json:"totalWalletBalance"`
+	TotalMarginBalance types.Number       `json:"totalMarginBalance"`
+	TotalAvailable     types.Number       `json:"totalAvailableBalance"`
+	Coin               []WalletCoinUpdate `json:"coin"`
+}
+
+type WalletCoinUpdate struct {
+	Coin           string       `json:"coin"`
+	Equity         types.Number `json:"equity"`
+	WalletBalance  types.Number `json:"walletBalance"`
+	UnrealisedPnl  types.Number `json:"unrealisedPnl"`
+	CumRealisedPnl types.Number `json:"cumRealisedPnl"`
+}
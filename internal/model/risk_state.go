@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// RiskState — состояние остановки торговли риск-контролем (таблица risk_state).
+// Until — время автоматического возобновления; nil при Halted — только ручное возобновление.
+type RiskState struct {
+	Halted    bool       `json:"halted"`
+	Reason    string     `json:"reason"`
+	HaltedAt  *time.Time `json:"halted_at"`
+	Until     *time.Time `json:"until"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Проверки SignalDetector в порядке выполнения (SignalResult.Passed и Failed).
+const (
+	SignalCheckData          = "data"           // достаточно свечей для расчёта индикаторов
+	SignalCheckEMATrend      = "ema_trend"      // LONG: EMAFast выше EMASlow
+	SignalCheckPullback      = "pullback"       // LONG: цена на откате к EMAFast
+	SignalCheckRSI           = "rsi"            // LONG: RSI не растёт ниже 50
+	SignalCheckLocalLow      = "local_low"      // LONG: минимум свечи ниже большинства предыдущих
+	SignalCheckBullishCandle = "bullish_candle" // LONG: бычья свеча
+	SignalCheckVolume        = "volume_spike"   // SHORT: всплеск объёма
+	SignalCheckLocalHigh     = "local_high"     // SHORT: максимум свечи выше большинства предыдущих
+	SignalCheckBearishCandle = "bearish_candle" // SHORT: медвежья свеча
+	SignalCheckSMATrend      = "sma_trend"      // SHORT: Close < SMAFast < SMASlow
+	SignalCheckMinATR        = "min_atr"        // SHORT: ATR не ниже MinATR
+)
+
+// Источники записей журнала сигналов.
+const (
+	SignalSourceLive     = "live"
+	SignalSourcePaper    = "paper"
+	SignalSourceBacktest = "backtest"
+)
+
+// SignalResult — результат одной проверки сигнала (таблица signals). Проверки выполняются
+// по порядку до первой непройденной; индикаторы считаются заранее, поэтому заполнены
+// и при раннем отказе (0 — не хватило свечей для расчёта).
+type SignalResult struct {
+	ID       int64    `json:"id"`
+	Source   string   `json:"source"` // live, paper или backtest
+	Strategy string   `json:"strategy"`
+	Symbol   string   `json:"symbol"`
+	Side     string   `json:"side"`   // long или short
+	Passed   []string `json:"passed"` // пройденные проверки по порядку
+	Failed   string   `json:"failed"` // первая непройденная проверка; пусто, если сигнал подтверждён
+
+	Price       float64 `json:"price"` // цена закрытия последней свечи
+	EMAFast     float64 `json:"ema_fast"`
+	EMASlow     float64 `json:"ema_slow"`
+	RSI         float64 `json:"rsi"`
+	VolumeRatio float64 `json:"volume_ratio"` // объём свечи к среднему за VolumeWindow
+	ATR         float64 `json:"atr"`
+	SMAFast     float64 `json:"sma_fast"`
+	SMASlow     float64 `json:"sma_slow"`
+
+	OrderID     string    `json:"order_id"`    // ордер, размещённый по сигналу; пусто — ордера нет
+	KlineStart  time.Time `json:"kline_start"` // начало последней свечи
+	EvaluatedAt time.Time `json:"evaluated_at"`
+}
+
+// OK сообщает, подтверждён ли сигнал.
+func (r *SignalResult) OK() bool {
+	return r.Failed == ""
+}
+
+// Pass отмечает пройденную проверку.
+func (r *SignalResult) Pass(check string) {
+	r.Passed = append(r.Passed, check)
+}
+
+// Fail отмечает проверку, на которой сигнал отклонён.
+func (r *SignalResult) Fail(check string) {
+	r.Failed = check
+}
@@ -0,0 +1,30 @@
+package model
+
+// Режимы расчёта объёма позиции (risk.sizing.mode).
+const (
+	SizingFixedNotional   = "fixed_notional"   // фиксированная стоимость позиции
+	SizingPercentEquity   = "percent_equity"   // доля капитала как маржа позиции
+	SizingFixedFractional = "fixed_fractional" // доля капитала под риск до стоп-лосса
+	SizingATR             = "atr"              // доля капитала под риск до стопа в ATR
+	SizingKelly           = "kelly"            // доля риска по критерию Келли с ограничением
+)
+
+// SizingRequest — данные для расчёта объёма позиции.
+type SizingRequest struct {
+	Symbol     string
+	Side       string // long или short
+	EntryPrice float64
+	StopLoss   float64 // 0 — стоп неизвестен
+	ATR        float64 // 0 — ATR неизвестен
+	Limits     TradeLimits
+}
+
+// SizingResult — рассчитанный объём и пояснение расчёта.
+type SizingResult struct {
+	Mode        string  `json:"mode"`
+	Quantity    float64 `json:"quantity"`
+	Notional    float64 `json:"notional"`    // стоимость позиции по цене входа, USDT
+	Equity      float64 `json:"equity"`      // капитал, от которого считался объём, USDT
+	RiskAmount  float64 `json:"risk_amount"` // убыток при срабатывании стопа с комиссиями, USDT; 0 — стоп неизвестен
+	Explanation string  `json:"explanation"`
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type SocketRequest struct {
@@ -131,9 +132,31 @@ type PriceLevel struct {
 
 // OrderbookData описывает данные ордербука, получаемые от биржи.
 type OrderbookData struct {
-	Symbol string       `json:"s"`
-	Bids   []PriceLevel `json:"b"`
-	Asks   []PriceLevel `json:"a"`
+	Symbol   string       `json:"s"`
+	Bids     []PriceLevel `json:"b"`
+	Asks     []PriceLevel `json:"a"`
+	UpdateID int64        `json:"u"`   // Update ID
+	Seq      int64        `json:"seq"` // Cross sequence
+	Ts       int64        `json:"ts"`  // время формирования данных системой (мс)
+	Cts      int64        `json:"cts"` // время match engine (мс)
+}
+
+// UpdatedAt возвращает время последнего обновления ордербука по данным биржи.
+func (ob *OrderbookData) UpdatedAt() time.Time {
+	return time.UnixMilli(ob.Ts)
+}
+
+// IsStale сообщает, что ордербук не обновлялся дольше maxAge.
+func (ob *OrderbookData) IsStale(maxAge time.Duration) bool {
+	return ob.IsStaleAt(time.Now(), maxAge)
+}
+
+// IsStaleAt — то же, что IsStale, но относительно заданного момента (для симуляции).
+func (ob *OrderbookData) IsStaleAt(now time.Time, maxAge time.Duration) bool {
+	if ob.Ts == 0 {
+		return true
+	}
+	return now.Sub(ob.UpdatedAt()) > maxAge
 }
 
 // UnmarshalJSON реализует пользовательский разбор JSON для OrderbookData,
@@ -141,15 +164,19 @@ type OrderbookData struct {
 func (ob *OrderbookData) UnmarshalJSON(data []byte) error {
 	// Определяем временную структуру, соответствующую JSON-формату от биржи.
 	var tmp struct {
-		Symbol string     `json:"s"`
-		Bids   [][]string `json:"b"`
-		Asks   [][]string `json:"a"`
+		Symbol   string     `json:"s"`
+		Bids     [][]string `json:"b"`
+		Asks     [][]string `json:"a"`
+		UpdateID int64      `json:"u"`
+		Seq      int64      `json:"seq"`
 	}
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
 
 	ob.Symbol = tmp.Symbol
+	ob.UpdateID = tmp.UpdateID
+	ob.Seq = tmp.Seq
 
 	// Преобразуем bids.
 	for _, bid := range tmp.Bids {
@@ -197,10 +224,8 @@ type OrderbookMessage struct {
 	Topic string        `json:"topic"` // Например, "orderbook.50.BTCUSDT"
 	Type  string        `json:"type"`  // "snapshot" или "delta"
 	Ts    int64         `json:"ts"`
-	Data  OrderbookData `json:"data"`
-	U     int64         `json:"u"`   // Update ID
-	Seq   int64         `json:"seq"` // Sequence
-	Cts   int64         `json:"cts"` // Timestamp от match engine
+	Data  OrderbookData `json:"data"` // содержит Update ID (u) и Sequence (seq)
+	Cts   int64         `json:"cts"`  // Timestamp от match engine
 }
 
 type UserDataStreamStart struct {
@@ -0,0 +1,114 @@
+package model
+
+// SignalParams — параметры SignalDetector.
+type SignalParams struct {
+	EMAFast           int     `yaml:"ema_fast" toml:"ema_fast" json:"ema_fast"`
+	EMASlow           int     `yaml:"ema_slow" toml:"ema_slow" json:"ema_slow"`
+	RSIPeriod         int     `yaml:"rsi_period" toml:"rsi_period" json:"rsi_period"`
+	PullbackPercent   float64 `yaml:"pullback_percent" toml:"pullback_percent" json:"pullback_percent"` // максимальный откат цены от EMAFast для LONG
+	VolumeWindow      int     `yaml:"volume_window" toml:"volume_window" json:"volume_window"`
+	VolumeSpikeFactor float64 `yaml:"volume_spike_factor" toml:"volume_spike_factor" json:"volume_spike_factor"`
+	LongLookback      int     `yaml:"long_lookback" toml:"long_lookback" json:"long_lookback"`    // свечей для проверки локального минимума
+	ShortLookback     int     `yaml:"short_lookback" toml:"short_lookback" json:"short_lookback"` // свечей для проверки локального максимума
+	ATRPeriod         int     `yaml:"atr_period" toml:"atr_period" json:"atr_period"`
+	MinATR            float64 `yaml:"min_atr" toml:"min_atr" json:"min_atr"`
+	SMAFast           int     `yaml:"sma_fast" toml:"sma_fast" json:"sma_fast"`
+	SMASlow           int     `yaml:"sma_slow" toml:"sma_slow" json:"sma_slow"`
+}
+
+// DefaultSignalParams возвращает значения, которые раньше были зашиты в SignalDetector.
+func DefaultSignalParams() SignalParams {
+	return SignalParams{
+		EMAFast:           50,
+		EMASlow:           200,
+		RSIPeriod:         14,
+		PullbackPercent:   0.015,
+		VolumeWindow:      15,
+		VolumeSpikeFactor: 1.5,
+		LongLookback:      7,
+		ShortLookback:     5,
+		ATRPeriod:         14,
+		MinATR:            0.5,
+		SMAFast:           20,
+		SMASlow:           50,
+	}
+}
+
+// Режимы расчёта SL/TP (strategy.sltp.mode).
+const (
+	SLTPFixedPercent = "fixed_percent" // SL и TP на фиксированных расстояниях
+	SLTPRiskReward   = "risk_reward"   // SL на фиксированном расстоянии, TP = RiskReward x SL
+	SLTPATR          = "atr"           // SL = ATRMultiplier x ATR в пределах MinSL..MaxSL, TP = RiskReward x SL
+	SLTPStructure    = "structure"     // SL за локальным минимумом/максимумом свечей, TP = RiskReward x SL
+)
+
+// SLTPParams — политика расчёта SL и TP. Расстояния — доли цены входа.
+type SLTPParams struct {
+	Mode              string  `yaml:"mode" toml:"mode" json:"mode"`
+	StopLossPercent   float64 `yaml:"stop_loss_percent" toml:"stop_loss_percent" json:"stop_loss_percent"`
+	TakeProfitPercent float64 `yaml:"take_profit_percent" toml:"take_profit_percent" json:"take_profit_percent"` // только fixed_percent
+	RiskReward        float64 `yaml:"risk_reward" toml:"risk_reward" json:"risk_reward"`
+	ATRPeriod         int     `yaml:"atr_period" toml:"atr_period" json:"atr_period"`
+	ATRMultiplier     float64 `yaml:"atr_multiplier" toml:"atr_multiplier" json:"atr_multiplier"`
+	MinSLPercent      float64 `yaml:"min_sl_percent" toml:"min_sl_percent" json:"min_sl_percent"` // пределы SL для atr и structure
+	MaxSLPercent      float64 `yaml:"max_sl_percent" toml:"max_sl_percent" json:"max_sl_percent"`
+	SwingLookback     int     `yaml:"swing_lookback" toml:"swing_lookback" json:"swing_lookback"` // свечей для поиска локального экстремума
+	SwingBuffer       float64 `yaml:"swing_buffer" toml:"swing_buffer" json:"swing_buffer"`       // отступ SL за экстремум
+}
+
+func DefaultSLTPParams() SLTPParams {
+	return SLTPParams{
+		Mode:              SLTPFixedPercent,
+		StopLossPercent:   0.006,
+		TakeProfitPercent: 0.015,
+		RiskReward:        2.0,
+		ATRPeriod:         11,
+		ATRMultiplier:     1.0,
+		MinSLPercent:      0.001,
+		MaxSLPercent:      0.01,
+		SwingLookback:     10,
+		SwingBuffer:       0.0005,
+	}
+}
+
+// SLTPResult — рассчитанные уровни и обоснование.
+type SLTPResult struct {
+	Mode          string  `json:"mode"`
+	StopLoss      float64 `json:"stop_loss"`
+	TakeProfit    float64 `json:"take_profit"`
+	StopPercent   float64 `json:"stop_percent"`    // расстояние SL до учёта комиссий
+	NetRiskReward float64 `json:"net_risk_reward"` // отношение прибыли к убытку после комиссий входа и выхода
+	Rationale     string  `json:"rationale"`
+}
+
+// Режимы трейлинг-стопа (strategy.manage.trail).
+const (
+	TrailNone    = ""
+	TrailATR     = "atr"     // стоп на TrailATRMultiplier x ATR от лучшей цены
+	TrailPercent = "percent" // стоп на TrailPercent от лучшей цены
+)
+
+// PartialTakeProfit — частичная фиксация Fraction исходного объёма на расстоянии R
+// (в единицах начального риска) от цены входа.
+type PartialTakeProfit struct {
+	R        float64 `yaml:"r" toml:"r" json:"r"`
+	Fraction float64 `yaml:"fraction" toml:"fraction" json:"fraction"`
+}
+
+// ManageParams — сопровождение открытой позиции. R — расстояние от входа до начального SL.
+// Нулевые значения отключают соответствующее правило.
+type ManageParams struct {
+	BreakEvenR         float64             `yaml:"break_even_r" toml:"break_even_r" json:"break_even_r"`                // перенос SL в безубыток после +R
+	BreakEvenOffset    float64             `yaml:"break_even_offset" toml:"break_even_offset" json:"break_even_offset"` // SL за ценой входа на долю цены (покрывает комиссии)
+	Trail              string              `yaml:"trail" toml:"trail" json:"trail"`                                     // "" | atr | percent
+	TrailActivationR   float64             `yaml:"trail_activation_r" toml:"trail_activation_r" json:"trail_activation_r"`
+	TrailATRPeriod     int                 `yaml:"trail_atr_period" toml:"trail_atr_period" json:"trail_atr_period"`
+	TrailATRMultiplier float64             `yaml:"trail_atr_multiplier" toml:"trail_atr_multiplier" json:"trail_atr_multiplier"`
+	TrailPercent       float64             `yaml:"trail_percent" toml:"trail_percent" json:"trail_percent"`
+	TakeProfits        []PartialTakeProfit `yaml:"take_profits" toml:"take_profits" json:"take_profits"`
+}
+
+// Enabled сообщает, задано ли хотя бы одно правило сопровождения.
+func (p ManageParams) Enabled() bool {
+	return p.BreakEvenR > 0 || p.Trail != TrailNone || len(p.TakeProfits) > 0
+}
@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"bybit-bot/internal/model"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Реализации репозиториев в памяти с той же семантикой, что и SQL-версии: для локальной
+// разработки, бэктестов и тестов без БД. Данные теряются при остановке процесса.
+// Методы возвращают копии, поэтому изменения записей вне репозитория не сохраняются.
+
+type memoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders []*model.Order // в порядке создания
+	byID   map[string]*model.Order
+}
+
+// NewMemoryOrderRepository возвращает OrderRepository в памяти.
+func NewMemoryOrderRepository() OrderRepository {
+	return &memoryOrderRepository{byID: make(map[string]*model.Order)}
+}
+
+func (r *memoryOrderRepository) InsertOrder(order *model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[order.OrderID]; ok {
+		return fmt.Errorf("InsertOrder: order %s already exists", order.OrderID)
+	}
+	now := time.Now()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	stored := *order
+	r.orders = append(r.orders, &stored)
+	r.byID[order.OrderID] = &stored
+	return nil
+}
+
+func (r *memoryOrderRepository) UpdateOrder(order *model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order.UpdatedAt = time.Now()
+	stored, ok := r.byID[order.OrderID]
+	if !ok {
+		return nil
+	}
+	createdAt := stored.CreatedAt
+	*stored = *order
+	stored.CreatedAt = createdAt
+	return nil
+}
+
+func (r *memoryOrderRepository) FindOrderByID(orderID string) (*model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored, ok := r.byID[orderID]
+	if !ok {
+		return nil, nil
+	}
+	order := *stored
+	return &order, nil
+}
+
+func (r *memoryOrderRepository) FindOrdersBySymbol(symbol string) ([]*model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []*model.Order
+	for _, stored := range r.orders {
+		if stored.Symbol == symbol {
+			order := *stored
+			orders = append(orders, &order)
+		}
+	}
+	return orders, nil
+}
+
+type memoryWalletRepository struct {
+	mu      sync.RWMutex
+	records []model.WalletInfoRep
+	nextID  int
+}
+
+// NewMemoryWalletRepository возвращает WalletRepository в памяти.
+func NewMemoryWalletRepository() WalletRepository {
+	return &memoryWalletRepository{}
+}
+
+func (r *memoryWalletRepository) InsertWalletInfo(info *model.WalletInfoRep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	info.ID = r.nextID
+	stored := *info
+	stored.CreatedAt = time.Now()
+	stored.UpdatedAt = stored.CreatedAt
+	r.records = append(r.records, stored)
+	return nil
+}
+
+func (r *memoryWalletRepository) UpdateWalletInfo(info *model.WalletInfoRep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.records {
+		rec := &r.records[i]
+		if rec.ID != info.ID {
+			continue
+		}
+		rec.Coin = info.Coin
+		rec.WalletBalance = info.WalletBalance
+		rec.TotalMarginBalance = info.TotalMarginBalance
+		rec.TotalWalletBalance = info.TotalWalletBalance
+		rec.RecordedAt = info.RecordedAt
+		rec.UpdatedAt = time.Now()
+		return nil
+	}
+	return nil
+}
+
+// latest возвращает последнюю по RecordedAt запись, подходящую под match.
+func (r *memoryWalletRepository) latest(match func(model.WalletInfoRep) bool) *model.WalletInfoRep {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var found *model.WalletInfoRep
+	for i := range r.records {
+		rec := r.records[i]
+		if match(rec) && (found == nil || !rec.RecordedAt.Before(found.RecordedAt)) {
+			found = &rec
+		}
+	}
+	return found
+}
+
+func (r *memoryWalletRepository) GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error) {
+	return r.latest(func(rec model.WalletInfoRep) bool { return rec.Coin == coin }), nil
+}
+
+func (r *memoryWalletRepository) GetLatestWalletBalance(walletBalance float64) (*model.WalletInfoRep, error) {
+	return r.latest(func(rec model.WalletInfoRep) bool { return rec.WalletBalance == walletBalance }), nil
+}
+
+func (r *memoryWalletRepository) SaveWalletInfo(info *model.WalletInfoRep) error {
+	return saveWalletInfo(r, info)
+}
+
+func (r *memoryWalletRepository) GetPeakWalletBalance(coin string, since time.Time) (float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	peak := 0.0
+	found := false
+	for _, rec := range r.records {
+		if rec.Coin == coin && !rec.RecordedAt.Before(since) && (!found || rec.WalletBalance > peak) {
+			peak = rec.WalletBalance
+			found = true
+		}
+	}
+	return peak, nil
+}
+
+type memoryPositionRepository struct {
+	mu         sync.RWMutex
+	positions  []*model.PositionRep
+	trades     []*model.ClosedTradeRep
+	executions map[string]memoryExecution
+}
+
+type memoryExecution struct {
+	symbol string
+	at     time.Time
+}
+
+// NewMemoryPositionRepository возвращает PositionRepository в памяти.
+func NewMemoryPositionRepository() PositionRepository {
+	return &memoryPositionRepository{}
+}
+
+func (r *memoryPositionRepository) InsertPosition(p *model.PositionRep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.UpdatedAt = time.Now()
+	p.ID = int64(len(r.positions) + 1)
+	r.positions = append(r.positions, clonePosition(p))
+	return nil
+}
+
+func (r *memoryPositionRepository) UpdatePosition(p *model.PositionRep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.UpdatedAt = time.Now()
+	if p.ID < 1 || p.ID > int64(len(r.positions)) {
+		return nil
+	}
+	stored := r.positions[p.ID-1]
+	stored.Size = p.Size
+	stored.EntryPrice = p.EntryPrice
+	stored.RealizedPnL = p.RealizedPnL
+	stored.Fees = p.Fees
+	stored.Status = p.Status
+	stored.ClosedAt = cloneTime(p.ClosedAt)
+	stored.LastFillAt = p.LastFillAt
+	stored.UpdatedAt = p.UpdatedAt
+	return nil
+}
+
+func (r *memoryPositionRepository) FindOpenPositions(symbol string) ([]*model.PositionRep, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var positions []*model.PositionRep
+	for _, p := range r.positions {
+		if p.Status == model.PositionStatusOpen && (symbol == "" || p.Symbol == symbol) {
+			positions = append(positions, clonePosition(p))
+		}
+	}
+	sort.SliceStable(positions, func(i, j int) bool { return positions[i].OpenedAt.Before(positions[j].OpenedAt) })
+	return positions, nil
+}
+
+func (r *memoryPositionRepository) LastFillTime(symbol string) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var last time.Time
+	for _, p := range r.positions {
+		if p.Symbol == symbol && p.LastFillAt.After(last) {
+			last = p.LastFillAt
+		}
+	}
+	return last, nil
+}
+
+func (r *memoryPositionRepository) InsertClosedTrade(t *model.ClosedTradeRep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.ID = int64(len(r.trades) + 1)
+	stored := *t
+	r.trades = append(r.trades, &stored)
+	return nil
+}
+
+func (r *memoryPositionRepository) FindClosedTrades(symbol string, from, to time.Time) ([]*model.ClosedTradeRep, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var trades []*model.ClosedTradeRep
+	for _, t := range r.trades {
+		if !t.ClosedAt.Before(from) && t.ClosedAt.Before(to) && (symbol == "" || t.Symbol == symbol) {
+			trade := *t
+			trades = append(trades, &trade)
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].ClosedAt.Before(trades[j].ClosedAt) })
+	return trades, nil
+}
+
+func (r *memoryPositionRepository) InsertExecution(execID, symbol string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.executions == nil {
+		r.executions = make(map[string]memoryExecution)
+	}
+	if _, ok := r.executions[execID]; !ok {
+		r.executions[execID] = memoryExecution{symbol: symbol, at: at}
+	}
+	return nil
+}
+
+func (r *memoryPositionRepository) FindExecutionIDs(symbol string, since time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ids []string
+	for id, e := range r.executions {
+		if e.symbol == symbol && !e.at.Before(since) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+type memoryRiskStateRepository struct {
+	mu    sync.RWMutex
+	state *model.RiskState
+}
+
+// NewMemoryRiskStateRepository возвращает RiskStateRepository в памяти.
+func NewMemoryRiskStateRepository() RiskStateRepository {
+	return &memoryRiskStateRepository{}
+}
+
+func (r *memoryRiskStateRepository) LoadRiskState() (*model.RiskState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.state == nil {
+		return nil, nil
+	}
+	return cloneRiskState(r.state), nil
+}
+
+func (r *memoryRiskStateRepository) SaveRiskState(s *model.RiskState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.UpdatedAt = time.Now()
+	r.state = cloneRiskState(s)
+	return nil
+}
+
+type memorySignalRepository struct {
+	mu      sync.RWMutex
+	signals []*model.SignalResult
+}
+
+// NewMemorySignalRepository возвращает SignalRepository в памяти.
+func NewMemorySignalRepository() SignalRepository {
+	return &memorySignalRepository{}
+}
+
+func (r *memorySignalRepository) InsertSignal(s *model.SignalResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.ID = int64(len(r.signals) + 1)
+	r.signals = append(r.signals, cloneSignal(s))
+	return nil
+}
+
+func (r *memorySignalRepository) FindSignals(symbol string, from, to time.Time) ([]*model.SignalResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var signals []*model.SignalResult
+	for _, s := range r.signals {
+		if !s.EvaluatedAt.Before(from) && s.EvaluatedAt.Before(to) && (symbol == "" || s.Symbol == symbol) {
+			signals = append(signals, cloneSignal(s))
+		}
+	}
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].EvaluatedAt.Before(signals[j].EvaluatedAt) })
+	return signals, nil
+}
+
+func clonePosition(p *model.PositionRep) *model.PositionRep {
+	c := *p
+	c.ClosedAt = cloneTime(p.ClosedAt)
+	return &c
+}
+
+func cloneRiskState(s *model.RiskState) *model.RiskState {
+	c := *s
+	c.HaltedAt = cloneTime(s.HaltedAt)
+	c.Until = cloneTime(s.Until)
+	return &c
+}
+
+func cloneSignal(s *model.SignalResult) *model.SignalResult {
+	c := *s
+	c.Passed = append([]string(nil), s.Passed...)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,233 @@
+// Package migrations применяет встроенные версионные SQL-миграции схемы БД.
+//
+// Миграция — пара файлов NNNN_name.up.sql и NNNN_name.down.sql; применённые версии
+// записываются в таблицу schema_migrations. Каждая миграция выполняется в отдельной
+// транзакции вместе с записью её версии.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Диалекты SQL со своим набором миграций (каталог с тем же именем).
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var versionTables = map[string]string{
+	Postgres: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		);
+	`,
+	SQLite: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		);
+	`,
+}
+
+// Migration — одна версия схемы.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status — состояние миграции в БД. AppliedAt пуст, если миграция не применена.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator применяет и откатывает миграции в DB. Создаётся через New.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration // по возрастанию Version
+
+	versionTable string
+}
+
+// New возвращает Migrator со встроенными миграциями диалекта dialect (Postgres или SQLite).
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	versionTable, ok := versionTables[dialect]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	list, err := Load(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: list, versionTable: versionTable}, nil
+}
+
+// Load читает миграции из каталога dir. У каждой версии должны быть оба файла, up и down.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			continue
+		}
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.(up|down).sql", name)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+func cutDirection(name string) (base, direction string, ok bool) {
+	if base, ok = strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok = strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// applied возвращает время применения каждой записанной версии.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	if _, err := m.DB.Exec(m.versionTable); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+	rows, err := m.DB.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("read schema_migrations: %w", err)
+		}
+		out[version] = at
+	}
+	return out, rows.Err()
+}
+
+// Up применяет все ещё не применённые миграции по возрастанию версии и возвращает их.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.Migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := m.exec(mig.Up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, time.Now().UTC())
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down откатывает steps последних применённых миграций и возвращает их в порядке отката.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.Migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.Migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.exec(mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version); err != nil {
+			return done, fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status возвращает состояние всех известных миграций по возрастанию версии.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(m.Migrations))
+	for _, mig := range m.Migrations {
+		s := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// exec выполняет скрипт миграции и запрос к schema_migrations в одной транзакции.
+func (m *Migrator) exec(script, record string, args ...interface{}) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,226 @@
+package migrations_test
+
+import (
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/repository/migrations"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+// EnvTestPostgresDSN — DSN пустой базы PostgreSQL для прогона миграций; без него проверяется только SQLite.
+const EnvTestPostgresDSN = "BYBIT_BOT_TEST_POSTGRES_DSN"
+
+var schemaTables = []string{"closed_trades", "executions", "orders", "positions", "risk_state", "schema_migrations", "signals", "wallet_info"}
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := repository.OpenDB(repository.DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tables(t *testing.T, db *sql.DB, dialect string) []string {
+	t.Helper()
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`
+	if dialect == migrations.Postgres {
+		query = `SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema()`
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("list tables: %v", err)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func versions(list []migrations.Migration) []int {
+	out := make([]int, len(list))
+	for i, m := range list {
+		out[i] = m.Version
+	}
+	return out
+}
+
+func pending(t *testing.T, m *migrations.Migrator) []int {
+	t.Helper()
+	status, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	var out []int
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			out = append(out, s.Version)
+		}
+	}
+	return out
+}
+
+// testUpDown прогоняет полный цикл: Up, повторный Up, Down одной версии, Up и Down до нуля.
+func testUpDown(t *testing.T, db *sql.DB, dialect string) {
+	m, err := migrations.New(db, dialect)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	all := versions(m.Migrations)
+	if len(all) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	if got := pending(t, m); !reflect.DeepEqual(got, all) {
+		t.Fatalf("pending on empty database = %v, want %v", got, all)
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, all) {
+		t.Fatalf("Up applied %v, want %v", got, all)
+	}
+	if got := tables(t, db, dialect); !reflect.DeepEqual(got, schemaTables) {
+		t.Fatalf("tables after Up = %v, want %v", got, schemaTables)
+	}
+	if got := pending(t, m); len(got) != 0 {
+		t.Fatalf("pending after Up = %v", got)
+	}
+
+	again, err := m.Up()
+	if err != nil {
+		t.Fatalf("repeated Up: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("repeated Up applied %v, want nothing", versions(again))
+	}
+
+	last := all[len(all)-1]
+	rolled, err := m.Down(1)
+	if err != nil {
+		t.Fatalf("Down(1): %v", err)
+	}
+	if got := versions(rolled); !reflect.DeepEqual(got, []int{last}) {
+		t.Fatalf("Down(1) rolled back %v, want [%d]", got, last)
+	}
+	if got := pending(t, m); !reflect.DeepEqual(got, []int{last}) {
+		t.Fatalf("pending after Down(1) = %v, want [%d]", got, last)
+	}
+	applied, err = m.Up()
+	if err != nil {
+		t.Fatalf("Up after Down(1): %v", err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int{last}) {
+		t.Fatalf("Up after Down(1) applied %v, want [%d]", got, last)
+	}
+
+	rolled, err = m.Down(len(all) + 10)
+	if err != nil {
+		t.Fatalf("Down to zero: %v", err)
+	}
+	if len(rolled) != len(all) || rolled[0].Version != last || rolled[len(rolled)-1].Version != all[0] {
+		t.Fatalf("Down to zero rolled back %v, want all versions newest first", versions(rolled))
+	}
+	if got := tables(t, db, dialect); !reflect.DeepEqual(got, []string{"schema_migrations"}) {
+		t.Fatalf("tables after Down to zero = %v, want only schema_migrations", got)
+	}
+	if got := pending(t, m); !reflect.DeepEqual(got, all) {
+		t.Fatalf("pending after Down to zero = %v, want %v", got, all)
+	}
+	if rolled, err := m.Down(1); err != nil || len(rolled) != 0 {
+		t.Fatalf("Down on empty schema = %v, %v; want nothing", versions(rolled), err)
+	}
+}
+
+func TestSQLiteUpDownStatus(t *testing.T) {
+	testUpDown(t, openSQLite(t), migrations.SQLite)
+}
+
+func TestPostgresUpDownStatus(t *testing.T) {
+	dsn := os.Getenv(EnvTestPostgresDSN)
+	if dsn == "" {
+		t.Skipf("%s not set", EnvTestPostgresDSN)
+	}
+	db, err := repository.OpenDB(repository.DriverPostgres, dsn)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	defer db.Close()
+	testUpDown(t, db, migrations.Postgres)
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	db := openSQLite(t)
+	m, err := migrations.New(db, migrations.SQLite)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	m.Migrations = []migrations.Migration{
+		{Version: 1, Name: "ok", Up: `CREATE TABLE a (id INTEGER);`, Down: `DROP TABLE a;`},
+		{Version: 2, Name: "broken", Up: `CREATE TABLE b (id INTEGER); CREATE TABLE broken (`, Down: `DROP TABLE b;`},
+	}
+
+	applied, err := m.Up()
+	if err == nil {
+		t.Fatal("Up succeeded with a broken migration")
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("Up applied %v before failing, want [1]", got)
+	}
+	if got := pending(t, m); !reflect.DeepEqual(got, []int{2}) {
+		t.Fatalf("pending after failed Up = %v, want [2]", got)
+	}
+	if got := tables(t, db, migrations.SQLite); !reflect.DeepEqual(got, []string{"a", "schema_migrations"}) {
+		t.Fatalf("tables after failed Up = %v, want the broken migration rolled back", got)
+	}
+}
+
+func TestLoadRejectsIncompleteMigrations(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"m/0001_init.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad name": {
+			"m/init.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/init.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"conflicting names": {
+			"m/0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+			"m/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := migrations.Load(fsys, "m"); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+
+	list, err := migrations.Load(fstest.MapFS{
+		"m/0002_b.up.sql":   {Data: []byte("B")},
+		"m/0002_b.down.sql": {Data: []byte("-B")},
+		"m/0001_a.up.sql":   {Data: []byte("A")},
+		"m/0001_a.down.sql": {Data: []byte("-A")},
+		"m/README.md":       {Data: []byte("ignored")},
+	}, "m")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []migrations.Migration{{Version: 1, Name: "a", Up: "A", Down: "-A"}, {Version: 2, Name: "b", Up: "B", Down: "-B"}}
+	if !reflect.DeepEqual(list, want) {
+		t.Fatalf("Load = %+v, want %+v", list, want)
+	}
+}
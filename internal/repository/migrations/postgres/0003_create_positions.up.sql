@@ -0,0 +1,34 @@
+-- Позиции и закрытые сделки (repository.PositionRepository).
+CREATE TABLE IF NOT EXISTS positions (
+	id           BIGSERIAL PRIMARY KEY,
+	symbol       TEXT NOT NULL,
+	side         TEXT NOT NULL,
+	size         DOUBLE PRECISION NOT NULL,
+	entry_price  DOUBLE PRECISION NOT NULL,
+	realized_pnl DOUBLE PRECISION NOT NULL DEFAULT 0,
+	fees         DOUBLE PRECISION NOT NULL DEFAULT 0,
+	status       TEXT NOT NULL,
+	opened_at    TIMESTAMPTZ NOT NULL,
+	closed_at    TIMESTAMPTZ,
+	last_fill_at TIMESTAMPTZ NOT NULL,
+	updated_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS positions_symbol_status_idx ON positions (symbol, status);
+
+CREATE TABLE IF NOT EXISTS closed_trades (
+	id          BIGSERIAL PRIMARY KEY,
+	position_id BIGINT NOT NULL REFERENCES positions (id),
+	symbol      TEXT NOT NULL,
+	side        TEXT NOT NULL,
+	qty         DOUBLE PRECISION NOT NULL,
+	entry_price DOUBLE PRECISION NOT NULL,
+	exit_price  DOUBLE PRECISION NOT NULL,
+	gross_pnl   DOUBLE PRECISION NOT NULL,
+	fees        DOUBLE PRECISION NOT NULL,
+	pnl         DOUBLE PRECISION NOT NULL,
+	reason      TEXT NOT NULL,
+	order_id    TEXT NOT NULL,
+	opened_at   TIMESTAMPTZ NOT NULL,
+	closed_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS closed_trades_symbol_closed_at_idx ON closed_trades (symbol, closed_at);
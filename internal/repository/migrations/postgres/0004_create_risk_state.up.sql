@@ -0,0 +1,9 @@
+-- Состояние риск-контроля с единственной строкой id = 1 (repository.RiskStateRepository).
+CREATE TABLE IF NOT EXISTS risk_state (
+	id         INT PRIMARY KEY CHECK (id = 1),
+	halted     BOOLEAN NOT NULL,
+	reason     TEXT NOT NULL,
+	halted_at  TIMESTAMPTZ,
+	until      TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL
+);
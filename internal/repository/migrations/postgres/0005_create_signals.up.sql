@@ -0,0 +1,26 @@
+-- Журнал проверок сигналов стратегий (repository.SignalRepository). passed_checks — пройденные
+-- проверки через запятую, failed_check — первая непройденная (пусто для подтверждённого сигнала),
+-- order_id — ордер, размещённый по сигналу (пусто, если ордера нет).
+CREATE TABLE IF NOT EXISTS signals (
+	id            BIGSERIAL PRIMARY KEY,
+	source        TEXT NOT NULL,
+	strategy      TEXT NOT NULL,
+	symbol        TEXT NOT NULL,
+	side          TEXT NOT NULL,
+	passed_checks TEXT NOT NULL DEFAULT '',
+	failed_check  TEXT NOT NULL DEFAULT '',
+	price         DOUBLE PRECISION NOT NULL,
+	ema_fast      DOUBLE PRECISION NOT NULL DEFAULT 0,
+	ema_slow      DOUBLE PRECISION NOT NULL DEFAULT 0,
+	rsi           DOUBLE PRECISION NOT NULL DEFAULT 0,
+	volume_ratio  DOUBLE PRECISION NOT NULL DEFAULT 0,
+	atr           DOUBLE PRECISION NOT NULL DEFAULT 0,
+	sma_fast      DOUBLE PRECISION NOT NULL DEFAULT 0,
+	sma_slow      DOUBLE PRECISION NOT NULL DEFAULT 0,
+	order_id      TEXT NOT NULL DEFAULT '',
+	kline_start   TIMESTAMPTZ NOT NULL,
+	evaluated_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS signals_symbol_evaluated_at_idx ON signals (symbol, evaluated_at);
+CREATE INDEX IF NOT EXISTS signals_failed_check_idx ON signals (failed_check);
+CREATE INDEX IF NOT EXISTS signals_order_id_idx ON signals (order_id);
@@ -0,0 +1,8 @@
+-- Учтённые исполнения (repository.PositionRepository): по ним positions.Service не учитывает
+-- повторно исполнения, совпадающие по времени с последним учтённым, после перезапуска.
+CREATE TABLE IF NOT EXISTS executions (
+	exec_id   TEXT PRIMARY KEY,
+	symbol    TEXT NOT NULL,
+	exec_time TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS executions_symbol_exec_time_idx ON executions (symbol, exec_time);
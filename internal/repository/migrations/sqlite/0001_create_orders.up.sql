@@ -0,0 +1,16 @@
+-- Ордера бота (repository.OrderRepository).
+CREATE TABLE IF NOT EXISTS orders (
+	order_id    TEXT PRIMARY KEY,
+	symbol      TEXT NOT NULL,
+	side        TEXT NOT NULL,
+	order_type  TEXT NOT NULL,
+	price       DOUBLE PRECISION NOT NULL,
+	quantity    DOUBLE PRECISION NOT NULL,
+	stop_loss   DOUBLE PRECISION NOT NULL DEFAULT 0,
+	take_profit DOUBLE PRECISION NOT NULL DEFAULT 0,
+	status      TEXT NOT NULL,
+	created_at  TIMESTAMP NOT NULL,
+	updated_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orders_symbol_idx ON orders (symbol);
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders (status);
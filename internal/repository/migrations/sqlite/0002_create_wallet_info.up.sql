@@ -0,0 +1,13 @@
+-- Снимки баланса кошелька (repository.WalletRepository).
+CREATE TABLE IF NOT EXISTS wallet_info (
+	id                   INTEGER PRIMARY KEY AUTOINCREMENT,
+	coin                 TEXT NOT NULL,
+	wallet_balance       DOUBLE PRECISION NOT NULL,
+	total_margin_balance DOUBLE PRECISION NOT NULL DEFAULT 0,
+	total_wallet_balance DOUBLE PRECISION NOT NULL DEFAULT 0,
+	recorded_at          TIMESTAMP NOT NULL,
+	created_at           TIMESTAMP NOT NULL,
+	updated_at           TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS wallet_info_recorded_at_idx ON wallet_info (recorded_at);
+CREATE INDEX IF NOT EXISTS wallet_info_coin_recorded_at_idx ON wallet_info (coin, recorded_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS closed_trades;
+DROP TABLE IF EXISTS positions;
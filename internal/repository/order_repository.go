@@ -15,12 +15,12 @@ type OrderRepository interface {
 	FindOrdersBySymbol(symbol string) ([]*model.Order, error)
 }
 
-// orderRepository — реализация OrderRepository.
+// orderRepository — реализация OrderRepository на SQL (PostgreSQL или SQLite); время пишется в UTC.
 type orderRepository struct {
 	db *sql.DB
 }
 
-// NewOrderRepository возвращает новую реализацию OrderRepository.
+// NewOrderRepository возвращает реализацию OrderRepository для PostgreSQL или SQLite.
 func NewOrderRepository(db *sql.DB) OrderRepository {
 	return &orderRepository{
 		db: db,
@@ -39,7 +39,7 @@ func (r *orderRepository) InsertOrder(order *model.Order) error {
 	order.UpdatedAt = now
 
 	_, err := r.db.Exec(query, order.OrderID, order.Symbol, order.Side, order.OrderType,
-		order.Price, order.Quantity, order.StopLoss, order.TakeProfit, order.Status, order.CreatedAt, order.UpdatedAt)
+		order.Price, order.Quantity, order.StopLoss, order.TakeProfit, order.Status, order.CreatedAt.UTC(), order.UpdatedAt.UTC())
 	if err != nil {
 		return fmt.Errorf("InsertOrder: %w", err)
 	}
@@ -55,7 +55,7 @@ func (r *orderRepository) UpdateOrder(order *model.Order) error {
 	`
 	order.UpdatedAt = time.Now()
 	_, err := r.db.Exec(query, order.Symbol, order.Side, order.OrderType, order.Price,
-		order.Quantity, order.StopLoss, order.TakeProfit, order.Status, order.UpdatedAt, order.OrderID)
+		order.Quantity, order.StopLoss, order.TakeProfit, order.Status, order.UpdatedAt.UTC(), order.OrderID)
 	if err != nil {
 		return fmt.Errorf("UpdateOrder: %w", err)
 	}
@@ -83,12 +83,13 @@ func (r *orderRepository) FindOrderByID(orderID string) (*model.Order, error) {
 	return &order, nil
 }
 
-// FindOrdersBySymbol возвращает список заказов для указанной торговой пары.
+// FindOrdersBySymbol возвращает список заказов для указанной торговой пары в порядке создания.
 func (r *orderRepository) FindOrdersBySymbol(symbol string) ([]*model.Order, error) {
 	query := `
 		SELECT order_id, symbol, side, order_type, price, quantity, stop_loss, take_profit, status, created_at, updated_at
 		FROM orders
 		WHERE symbol = $1
+		ORDER BY created_at
 	`
 	rows, err := r.db.Query(query, symbol)
 	if err != nil {
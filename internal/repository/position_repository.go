@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"bybit-bot/internal/model"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PositionRepository описывает хранение позиций и закрытых сделок.
+type PositionRepository interface {
+	InsertPosition(p *model.PositionRep) error
+	UpdatePosition(p *model.PositionRep) error
+	// FindOpenPositions возвращает открытые позиции символа; пустой symbol — по всем символам.
+	FindOpenPositions(symbol string) ([]*model.PositionRep, error)
+	// LastFillTime возвращает время последнего учтённого исполнения по символу (нулевое, если их не было).
+	LastFillTime(symbol string) (time.Time, error)
+	InsertClosedTrade(t *model.ClosedTradeRep) error
+	// FindClosedTrades возвращает сделки, закрытые в [from, to); пустой symbol — по всем символам.
+	FindClosedTrades(symbol string, from, to time.Time) ([]*model.ClosedTradeRep, error)
+	// InsertExecution отмечает исполнение учтённым; повторная отметка того же execID не ошибка.
+	InsertExecution(execID, symbol string, at time.Time) error
+	// FindExecutionIDs возвращает идентификаторы учтённых исполнений символа не раньше since.
+	FindExecutionIDs(symbol string, since time.Time) ([]string, error)
+}
+
+type positionRepository struct {
+	db *sql.DB
+}
+
+// NewPositionRepository возвращает реализацию PositionRepository для PostgreSQL или SQLite.
+func NewPositionRepository(db *sql.DB) PositionRepository {
+	return &positionRepository{db: db}
+}
+
+// InsertPosition вставляет позицию и заполняет её ID.
+func (r *positionRepository) InsertPosition(p *model.PositionRep) error {
+	query := `
+		INSERT INTO positions
+		(symbol, side, size, entry_price, realized_pnl, fees, status, opened_at, closed_at, last_fill_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	p.UpdatedAt = time.Now()
+	err := r.db.QueryRow(query, p.Symbol, p.Side, p.Size, p.EntryPrice, p.RealizedPnL, p.Fees, p.Status,
+		p.OpenedAt.UTC(), utcPtr(p.ClosedAt), p.LastFillAt.UTC(), p.UpdatedAt.UTC()).Scan(&p.ID)
+	if err != nil {
+		return fmt.Errorf("InsertPosition: %w", err)
+	}
+	return nil
+}
+
+// UpdatePosition сохраняет изменения позиции по ID.
+func (r *positionRepository) UpdatePosition(p *model.PositionRep) error {
+	query := `
+		UPDATE positions
+		SET size = $1, entry_price = $2, realized_pnl = $3, fees = $4, status = $5,
+		    closed_at = $6, last_fill_at = $7, updated_at = $8
+		WHERE id = $9
+	`
+	p.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, p.Size, p.EntryPrice, p.RealizedPnL, p.Fees, p.Status,
+		utcPtr(p.ClosedAt), p.LastFillAt.UTC(), p.UpdatedAt.UTC(), p.ID)
+	if err != nil {
+		return fmt.Errorf("UpdatePosition: %w", err)
+	}
+	return nil
+}
+
+func (r *positionRepository) FindOpenPositions(symbol string) ([]*model.PositionRep, error) {
+	query := `
+		SELECT id, symbol, side, size, entry_price, realized_pnl, fees, status, opened_at, closed_at, last_fill_at, updated_at
+		FROM positions
+		WHERE status = $1 AND ($2 = '' OR symbol = $2)
+		ORDER BY opened_at
+	`
+	rows, err := r.db.Query(query, model.PositionStatusOpen, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("FindOpenPositions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []*model.PositionRep
+	for rows.Next() {
+		var p model.PositionRep
+		var closedAt sql.NullTime
+		err := rows.Scan(&p.ID, &p.Symbol, &p.Side, &p.Size, &p.EntryPrice, &p.RealizedPnL, &p.Fees,
+			&p.Status, &p.OpenedAt, &closedAt, &p.LastFillAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("FindOpenPositions: %w", err)
+		}
+		if closedAt.Valid {
+			p.ClosedAt = &closedAt.Time
+		}
+		positions = append(positions, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindOpenPositions: %w", err)
+	}
+	return positions, nil
+}
+
+func (r *positionRepository) LastFillTime(symbol string) (time.Time, error) {
+	// ORDER BY вместо MAX: в SQLite агрегат теряет тип столбца и не читается как время.
+	var last time.Time
+	err := r.db.QueryRow(`SELECT last_fill_at FROM positions WHERE symbol = $1 ORDER BY last_fill_at DESC LIMIT 1`, symbol).
+		Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LastFillTime: %w", err)
+	}
+	return last, nil
+}
+
+// InsertClosedTrade вставляет закрытую сделку и заполняет её ID.
+func (r *positionRepository) InsertClosedTrade(t *model.ClosedTradeRep) error {
+	query := `
+		INSERT INTO closed_trades
+		(position_id, symbol, side, qty, entry_price, exit_price, gross_pnl, fees, pnl, reason, order_id, opened_at, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, t.PositionID, t.Symbol, t.Side, t.Qty, t.EntryPrice, t.ExitPrice,
+		t.GrossPnL, t.Fees, t.PnL, t.Reason, t.OrderID, t.OpenedAt.UTC(), t.ClosedAt.UTC()).Scan(&t.ID)
+	if err != nil {
+		return fmt.Errorf("InsertClosedTrade: %w", err)
+	}
+	return nil
+}
+
+func (r *positionRepository) FindClosedTrades(symbol string, from, to time.Time) ([]*model.ClosedTradeRep, error) {
+	query := `
+		SELECT id, position_id, symbol, side, qty, entry_price, exit_price, gross_pnl, fees, pnl, reason, order_id, opened_at, closed_at
+		FROM closed_trades
+		WHERE closed_at >= $1 AND closed_at < $2 AND ($3 = '' OR symbol = $3)
+		ORDER BY closed_at
+	`
+	rows, err := r.db.Query(query, from.UTC(), to.UTC(), symbol)
+	if err != nil {
+		return nil, fmt.Errorf("FindClosedTrades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []*model.ClosedTradeRep
+	for rows.Next() {
+		var t model.ClosedTradeRep
+		err := rows.Scan(&t.ID, &t.PositionID, &t.Symbol, &t.Side, &t.Qty, &t.EntryPrice, &t.ExitPrice,
+			&t.GrossPnL, &t.Fees, &t.PnL, &t.Reason, &t.OrderID, &t.OpenedAt, &t.ClosedAt)
+		if err != nil {
+			return nil, fmt.Errorf("FindClosedTrades: %w", err)
+		}
+		trades = append(trades, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindClosedTrades: %w", err)
+	}
+	return trades, nil
+}
+
+// utcPtr приводит необязательное время к UTC. Время пишется в UTC, чтобы в SQLite,
+// где оно хранится строкой, сравнение и сортировка совпадали с порядком времени.
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+func (r *positionRepository) InsertExecution(execID, symbol string, at time.Time) error {
+	query := `
+		INSERT INTO executions (exec_id, symbol, exec_time)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (exec_id) DO NOTHING
+	`
+	if _, err := r.db.Exec(query, execID, symbol, at.UTC()); err != nil {
+		return fmt.Errorf("InsertExecution: %w", err)
+	}
+	return nil
+}
+
+func (r *positionRepository) FindExecutionIDs(symbol string, since time.Time) ([]string, error) {
+	rows, err := r.db.Query(`SELECT exec_id FROM executions WHERE symbol = $1 AND exec_time >= $2`, symbol, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("FindExecutionIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("FindExecutionIDs: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindExecutionIDs: %w", err)
+	}
+	return ids, nil
+}
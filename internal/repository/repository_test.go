@@ -0,0 +1,283 @@
+package repository_test
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/repository/migrations"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// EnvTestPostgresDSN — DSN базы PostgreSQL для контрактных тестов; схема в ней пересоздаётся.
+const EnvTestPostgresDSN = "BYBIT_BOT_TEST_POSTGRES_DSN"
+
+var contractStart = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// openStore возвращает пустое хранилище; каждый вызов — новое.
+type openStore func(t *testing.T) *repository.Store
+
+func TestMemoryRepositories(t *testing.T) {
+	testRepositories(t, func(t *testing.T) *repository.Store {
+		store, err := repository.Open(repository.DriverMemory, "")
+		if err != nil {
+			t.Fatalf("open memory store: %v", err)
+		}
+		return store
+	})
+}
+
+func TestSQLiteRepositories(t *testing.T) {
+	testRepositories(t, func(t *testing.T) *repository.Store {
+		store, err := repository.Open(repository.DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+		if err != nil {
+			t.Fatalf("open sqlite store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		migrate(t, store, migrations.SQLite)
+		return store
+	})
+}
+
+func TestPostgresRepositories(t *testing.T) {
+	dsn := os.Getenv(EnvTestPostgresDSN)
+	if dsn == "" {
+		t.Skipf("%s not set", EnvTestPostgresDSN)
+	}
+	testRepositories(t, func(t *testing.T) *repository.Store {
+		store, err := repository.Open(repository.DriverPostgres, dsn)
+		if err != nil {
+			t.Fatalf("open postgres store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		migrate(t, store, migrations.Postgres)
+		return store
+	})
+}
+
+// migrate пересоздаёт схему: откатывает все миграции и применяет заново.
+func migrate(t *testing.T, store *repository.Store, dialect string) {
+	t.Helper()
+	m, err := migrations.New(store.DB, dialect)
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if _, err := m.Down(len(m.Migrations)); err != nil {
+		t.Fatalf("migrations down: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrations up: %v", err)
+	}
+}
+
+// testRepositories проверяет общий для всех хранилищ контракт репозиториев.
+func testRepositories(t *testing.T, open openStore) {
+	t.Run("Orders", func(t *testing.T) { testOrders(t, open(t).Orders) })
+	t.Run("Wallet", func(t *testing.T) { testWallet(t, open(t).Wallet) })
+	t.Run("ClosedTrades", func(t *testing.T) { testClosedTrades(t, open(t).Positions) })
+	t.Run("Executions", func(t *testing.T) { testExecutions(t, open(t).Positions) })
+}
+
+func orderIDs(orders []*model.Order) []string {
+	var ids []string
+	for _, o := range orders {
+		ids = append(ids, o.OrderID)
+	}
+	return ids
+}
+
+func testOrders(t *testing.T, repo repository.OrderRepository) {
+	// Идентификаторы не по алфавиту: порядок выдачи — порядок создания.
+	for _, o := range []*model.Order{
+		{OrderID: "c", Symbol: "BTCUSDT", Side: "Buy", OrderType: "limit", Price: 100, Quantity: 1, Status: model.OrderStatusOpen},
+		{OrderID: "a", Symbol: "ETHUSDT", Side: "Sell", OrderType: "limit", Price: 10, Quantity: 2, Status: model.OrderStatusOpen},
+		{OrderID: "b", Symbol: "BTCUSDT", Side: "Sell", OrderType: "limit", Price: 110, Quantity: 3, StopLoss: 120, TakeProfit: 90, Status: model.OrderStatusOpen},
+	} {
+		if err := repo.InsertOrder(o); err != nil {
+			t.Fatalf("InsertOrder %s: %v", o.OrderID, err)
+		}
+		time.Sleep(time.Millisecond) // различимое время создания в любом хранилище
+	}
+
+	duplicate := &model.Order{OrderID: "c", Symbol: "BTCUSDT", Side: "Sell", OrderType: "limit", Price: 1, Quantity: 1, Status: model.OrderStatusOpen}
+	if err := repo.InsertOrder(duplicate); err == nil {
+		t.Fatal("duplicate InsertOrder succeeded")
+	}
+	stored, err := repo.FindOrderByID("c")
+	if err != nil || stored == nil {
+		t.Fatalf("FindOrderByID = %v, %v", stored, err)
+	}
+	if stored.Side != "Buy" || stored.Price != 100 {
+		t.Fatalf("duplicate insert changed the order: %+v", stored)
+	}
+
+	orders, err := repo.FindOrdersBySymbol("BTCUSDT")
+	if err != nil {
+		t.Fatalf("FindOrdersBySymbol: %v", err)
+	}
+	if got := orderIDs(orders); !reflect.DeepEqual(got, []string{"c", "b"}) {
+		t.Fatalf("FindOrdersBySymbol = %v, want [c b] in creation order", got)
+	}
+	if b := orders[1]; b.StopLoss != 120 || b.TakeProfit != 90 || b.Quantity != 3 || b.Side != "Sell" {
+		t.Fatalf("order b read back as %+v", b)
+	}
+	if orders, err := repo.FindOrdersBySymbol("SOLUSDT"); err != nil || len(orders) != 0 {
+		t.Fatalf("FindOrdersBySymbol for an unknown symbol = %v, %v", orderIDs(orders), err)
+	}
+	if missing, err := repo.FindOrderByID("missing"); missing != nil || err != nil {
+		t.Fatalf("FindOrderByID for a missing order = %+v, %v; want nil, nil", missing, err)
+	}
+
+	createdAt := stored.CreatedAt
+	stored.Status = model.OrderStatusFilled
+	stored.Price = 101
+	if err := repo.UpdateOrder(stored); err != nil {
+		t.Fatalf("UpdateOrder: %v", err)
+	}
+	updated, err := repo.FindOrderByID("c")
+	if err != nil {
+		t.Fatalf("FindOrderByID: %v", err)
+	}
+	if updated.Status != model.OrderStatusFilled || updated.Price != 101 || !updated.CreatedAt.Equal(createdAt) {
+		t.Fatalf("updated order %+v, want filled at 101 created at %s", updated, createdAt)
+	}
+}
+
+func testWallet(t *testing.T, repo repository.WalletRepository) {
+	if latest, err := repo.GetLatestWalletInfo("USDT"); latest != nil || err != nil {
+		t.Fatalf("GetLatestWalletInfo on an empty repository = %+v, %v", latest, err)
+	}
+
+	first := &model.WalletInfoRep{Coin: "USDT", WalletBalance: 1000}
+	if err := repo.SaveWalletInfo(first); err != nil {
+		t.Fatalf("SaveWalletInfo: %v", err)
+	}
+	// Тот же баланс обновляет запись, другой — добавляет новую.
+	same := &model.WalletInfoRep{Coin: "USDT", WalletBalance: 1000, TotalMarginBalance: 1005}
+	if err := repo.SaveWalletInfo(same); err != nil {
+		t.Fatalf("SaveWalletInfo with the same balance: %v", err)
+	}
+	if same.ID != first.ID {
+		t.Fatalf("same balance saved as record %d, want update of %d", same.ID, first.ID)
+	}
+	changed := &model.WalletInfoRep{Coin: "USDT", WalletBalance: 990}
+	if err := repo.SaveWalletInfo(changed); err != nil {
+		t.Fatalf("SaveWalletInfo with a new balance: %v", err)
+	}
+	if changed.ID == first.ID {
+		t.Fatalf("new balance updated record %d, want a new record", first.ID)
+	}
+	updated, err := repo.GetLatestWalletBalance(1000)
+	if err != nil || updated == nil {
+		t.Fatalf("GetLatestWalletBalance = %v, %v", updated, err)
+	}
+	if updated.ID != first.ID || updated.TotalMarginBalance != 1005 {
+		t.Fatalf("updated record %+v, want record %d with margin balance 1005", updated, first.ID)
+	}
+	latest, err := repo.GetLatestWalletInfo("USDT")
+	if err != nil || latest == nil || latest.ID != changed.ID || latest.WalletBalance != 990 {
+		t.Fatalf("GetLatestWalletInfo = %+v, %v; want record %d with 990", latest, err, changed.ID)
+	}
+
+	for i, balance := range []float64{1500, 1200, 1300} {
+		info := &model.WalletInfoRep{Coin: "BTC", WalletBalance: balance, RecordedAt: contractStart.Add(time.Duration(i) * time.Hour)}
+		if err := repo.InsertWalletInfo(info); err != nil {
+			t.Fatalf("InsertWalletInfo: %v", err)
+		}
+	}
+	peaks := []struct {
+		since time.Time
+		want  float64
+	}{
+		{time.Time{}, 1500},
+		{contractStart, 1500},
+		{contractStart.Add(time.Hour), 1300},
+		{contractStart.Add(90 * time.Minute), 1300},
+		{contractStart.Add(3 * time.Hour), 0},
+	}
+	for _, p := range peaks {
+		got, err := repo.GetPeakWalletBalance("BTC", p.since)
+		if err != nil {
+			t.Fatalf("GetPeakWalletBalance since %s: %v", p.since, err)
+		}
+		if got != p.want {
+			t.Fatalf("GetPeakWalletBalance since %s = %v, want %v", p.since, got, p.want)
+		}
+	}
+	if got, err := repo.GetPeakWalletBalance("ETH", time.Time{}); got != 0 || err != nil {
+		t.Fatalf("GetPeakWalletBalance for a coin without records = %v, %v", got, err)
+	}
+}
+
+func testClosedTrades(t *testing.T, repo repository.PositionRepository) {
+	pos := &model.PositionRep{Symbol: "BTCUSDT", Side: "long", Size: 1, EntryPrice: 100, Status: model.PositionStatusOpen,
+		OpenedAt: contractStart, LastFillAt: contractStart}
+	if err := repo.InsertPosition(pos); err != nil {
+		t.Fatalf("InsertPosition: %v", err)
+	}
+	// Вставка не по порядку закрытия: выдача упорядочена по closed_at.
+	for _, tr := range []struct {
+		symbol string
+		offset time.Duration
+	}{{"BTCUSDT", 2 * time.Hour}, {"ETHUSDT", time.Hour}, {"BTCUSDT", 0}, {"BTCUSDT", time.Hour}} {
+		trade := &model.ClosedTradeRep{PositionID: pos.ID, Symbol: tr.symbol, Side: "long", Qty: 1, EntryPrice: 100, ExitPrice: 101,
+			GrossPnL: 1, PnL: 1, Reason: model.CloseReasonTakeProfit, OpenedAt: contractStart, ClosedAt: contractStart.Add(tr.offset)}
+		if err := repo.InsertClosedTrade(trade); err != nil {
+			t.Fatalf("InsertClosedTrade: %v", err)
+		}
+	}
+
+	ranges := []struct {
+		symbol   string
+		from, to time.Duration
+		want     []string // символ@смещение в часах
+	}{
+		{"BTCUSDT", 0, 3 * time.Hour, []string{"BTCUSDT@0", "BTCUSDT@1", "BTCUSDT@2"}},
+		{"BTCUSDT", time.Hour, 2 * time.Hour, []string{"BTCUSDT@1"}},
+		{"", time.Hour, 3 * time.Hour, []string{"BTCUSDT@1", "ETHUSDT@1", "BTCUSDT@2"}},
+		{"ETHUSDT", 2 * time.Hour, 3 * time.Hour, nil},
+	}
+	for _, r := range ranges {
+		trades, err := repo.FindClosedTrades(r.symbol, contractStart.Add(r.from), contractStart.Add(r.to))
+		if err != nil {
+			t.Fatalf("FindClosedTrades: %v", err)
+		}
+		var got []string
+		for i, tr := range trades {
+			if i > 0 && tr.ClosedAt.Before(trades[i-1].ClosedAt) {
+				t.Fatalf("FindClosedTrades(%q) not ordered by closed_at", r.symbol)
+			}
+			got = append(got, fmt.Sprintf("%s@%.0f", tr.Symbol, tr.ClosedAt.Sub(contractStart).Hours()))
+		}
+		// Порядок сделок с одинаковым closed_at не задан.
+		sort.Strings(got)
+		want := append([]string(nil), r.want...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("FindClosedTrades(%q, +%s, +%s) = %v, want %v", r.symbol, r.from, r.to, got, want)
+		}
+	}
+}
+
+func testExecutions(t *testing.T, repo repository.PositionRepository) {
+	for _, e := range []struct {
+		id     string
+		symbol string
+		offset time.Duration
+	}{{"a", "BTCUSDT", 0}, {"b", "BTCUSDT", time.Millisecond}, {"b", "BTCUSDT", time.Hour}, {"c", "ETHUSDT", time.Millisecond}} {
+		if err := repo.InsertExecution(e.id, e.symbol, contractStart.Add(e.offset)); err != nil {
+			t.Fatalf("InsertExecution %s: %v", e.id, err)
+		}
+	}
+	ids, err := repo.FindExecutionIDs("BTCUSDT", contractStart.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("FindExecutionIDs: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"b"}) {
+		t.Fatalf("FindExecutionIDs = %v, want [b]", ids)
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"bybit-bot/internal/model"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RiskStateRepository хранит состояние остановки торговли между перезапусками.
+type RiskStateRepository interface {
+	// LoadRiskState возвращает сохранённое состояние или nil, если его ещё нет.
+	LoadRiskState() (*model.RiskState, error)
+	SaveRiskState(s *model.RiskState) error
+}
+
+type riskStateRepository struct {
+	db *sql.DB
+}
+
+// NewRiskStateRepository возвращает реализацию RiskStateRepository для PostgreSQL или SQLite.
+func NewRiskStateRepository(db *sql.DB) RiskStateRepository {
+	return &riskStateRepository{db: db}
+}
+
+func (r *riskStateRepository) LoadRiskState() (*model.RiskState, error) {
+	var s model.RiskState
+	var haltedAt, until sql.NullTime
+	err := r.db.QueryRow(`SELECT halted, reason, halted_at, until, updated_at FROM risk_state WHERE id = 1`).
+		Scan(&s.Halted, &s.Reason, &haltedAt, &until, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("LoadRiskState: %w", err)
+	}
+	if haltedAt.Valid {
+		s.HaltedAt = &haltedAt.Time
+	}
+	if until.Valid {
+		s.Until = &until.Time
+	}
+	return &s, nil
+}
+
+func (r *riskStateRepository) SaveRiskState(s *model.RiskState) error {
+	query := `
+		INSERT INTO risk_state (id, halted, reason, halted_at, until, updated_at)
+		VALUES (1, $1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE
+		SET halted = EXCLUDED.halted, reason = EXCLUDED.reason, halted_at = EXCLUDED.halted_at,
+		    until = EXCLUDED.until, updated_at = EXCLUDED.updated_at
+	`
+	s.UpdatedAt = time.Now()
+	if _, err := r.db.Exec(query, s.Halted, s.Reason, s.HaltedAt, s.Until, s.UpdatedAt); err != nil {
+		return fmt.Errorf("SaveRiskState: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"bybit-bot/internal/model"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SignalRepository описывает журнал проверок сигналов стратегий.
+type SignalRepository interface {
+	InsertSignal(s *model.SignalResult) error
+	// FindSignals возвращает проверки, выполненные в [from, to); пустой symbol — по всем символам.
+	FindSignals(symbol string, from, to time.Time) ([]*model.SignalResult, error)
+}
+
+type signalRepository struct {
+	db *sql.DB
+}
+
+// NewSignalRepository возвращает реализацию SignalRepository для PostgreSQL или SQLite.
+func NewSignalRepository(db *sql.DB) SignalRepository {
+	return &signalRepository{db: db}
+}
+
+// InsertSignal вставляет результат проверки и заполняет его ID.
+func (r *signalRepository) InsertSignal(s *model.SignalResult) error {
+	query := `
+		INSERT INTO signals
+		(source, strategy, symbol, side, passed_checks, failed_check, price, ema_fast, ema_slow, rsi,
+		 volume_ratio, atr, sma_fast, sma_slow, order_id, kline_start, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, s.Source, s.Strategy, s.Symbol, s.Side, strings.Join(s.Passed, ","), s.Failed,
+		s.Price, s.EMAFast, s.EMASlow, s.RSI, s.VolumeRatio, s.ATR, s.SMAFast, s.SMASlow, s.OrderID,
+		s.KlineStart.UTC(), s.EvaluatedAt.UTC()).Scan(&s.ID)
+	if err != nil {
+		return fmt.Errorf("InsertSignal: %w", err)
+	}
+	return nil
+}
+
+func (r *signalRepository) FindSignals(symbol string, from, to time.Time) ([]*model.SignalResult, error) {
+	query := `
+		SELECT id, source, strategy, symbol, side, passed_checks, failed_check, price, ema_fast, ema_slow, rsi,
+		       volume_ratio, atr, sma_fast, sma_slow, order_id, kline_start, evaluated_at
+		FROM signals
+		WHERE evaluated_at >= $1 AND evaluated_at < $2 AND ($3 = '' OR symbol = $3)
+		ORDER BY evaluated_at, id
+	`
+	rows, err := r.db.Query(query, from.UTC(), to.UTC(), symbol)
+	if err != nil {
+		return nil, fmt.Errorf("FindSignals: %w", err)
+	}
+	defer rows.Close()
+
+	var signals []*model.SignalResult
+	for rows.Next() {
+		var s model.SignalResult
+		var passed string
+		err := rows.Scan(&s.ID, &s.Source, &s.Strategy, &s.Symbol, &s.Side, &passed, &s.Failed, &s.Price,
+			&s.EMAFast, &s.EMASlow, &s.RSI, &s.VolumeRatio, &s.ATR, &s.SMAFast, &s.SMASlow, &s.OrderID,
+			&s.KlineStart, &s.EvaluatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("FindSignals: %w", err)
+		}
+		if passed != "" {
+			s.Passed = strings.Split(passed, ",")
+		}
+		signals = append(signals, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindSignals: %w", err)
+	}
+	return signals, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Хранилища, которые умеет открывать Open (совпадают со значениями database.driver).
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// sqliteParams включают внешние ключи и ожидание блокировки вместо ошибки "database is locked".
+const sqliteParams = "_foreign_keys=on&_busy_timeout=5000"
+
+// Store — репозитории одного хранилища.
+type Store struct {
+	DB        *sql.DB // nil для хранилища в памяти
+	Orders    OrderRepository
+	Wallet    WalletRepository
+	Positions PositionRepository
+	RiskState RiskStateRepository
+	Signals   SignalRepository
+}
+
+// Open открывает хранилище driver. Схему SQL-базы нужно привести к актуальной версии
+// миграциями (migrations.New(store.DB, driver)) до работы с репозиториями.
+func Open(driver, dsn string) (*Store, error) {
+	if driver == DriverMemory {
+		return &Store{
+			Orders:    NewMemoryOrderRepository(),
+			Wallet:    NewMemoryWalletRepository(),
+			Positions: NewMemoryPositionRepository(),
+			RiskState: NewMemoryRiskStateRepository(),
+			Signals:   NewMemorySignalRepository(),
+		}, nil
+	}
+	db, err := OpenDB(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		DB:        db,
+		Orders:    NewOrderRepository(db),
+		Wallet:    NewWalletRepository(db),
+		Positions: NewPositionRepository(db),
+		RiskState: NewRiskStateRepository(db),
+		Signals:   NewSignalRepository(db),
+	}, nil
+}
+
+// Close закрывает соединение с базой, если оно есть.
+func (s *Store) Close() error {
+	if s.DB == nil {
+		return nil
+	}
+	return s.DB.Close()
+}
+
+// OpenDB открывает SQL-базу PostgreSQL или SQLite и проверяет соединение. Для SQLite
+// создаётся каталог файла базы, а пул ограничен одним соединением: запись в SQLite
+// всё равно последовательна, а общий пул исключает ошибки блокировки между соединениями.
+func OpenDB(driver, dsn string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+	switch driver {
+	case DriverPostgres:
+		db, err = sql.Open("postgres", dsn)
+	case DriverSQLite:
+		if !strings.HasPrefix(dsn, "file:") && dsn != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(dsn), 0o755); err != nil {
+				return nil, fmt.Errorf("create sqlite directory: %w", err)
+			}
+		}
+		if !strings.Contains(dsn, "?") {
+			dsn += "?" + sqliteParams
+		}
+		db, err = sql.Open("sqlite3", dsn)
+		if err == nil {
+			db.SetMaxOpenConns(1)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", driver, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping %s: %w", driver, err)
+	}
+	return db, nil
+}
@@ -7,18 +7,36 @@ import (
 	"time"
 )
 
-type WalletRepository struct {
+// WalletRepository хранит снимки баланса кошелька.
+type WalletRepository interface {
+	InsertWalletInfo(info *model.WalletInfoRep) error
+	// UpdateWalletInfo обновляет существующую запись баланса по идентификатору.
+	UpdateWalletInfo(info *model.WalletInfoRep) error
+	// GetLatestWalletInfo возвращает последнюю запись баланса монеты или nil, если записей нет.
+	GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error)
+	// GetLatestWalletBalance возвращает последнюю запись с балансом walletBalance или nil.
+	GetLatestWalletBalance(walletBalance float64) (*model.WalletInfoRep, error)
+	// SaveWalletInfo обновляет время последней записи с тем же балансом, а если её нет — добавляет новую.
+	SaveWalletInfo(info *model.WalletInfoRep) error
+	// GetPeakWalletBalance возвращает максимальный баланс монеты, записанный начиная с since
+	// (0, если записей нет).
+	GetPeakWalletBalance(coin string, since time.Time) (float64, error)
+}
+
+// walletRepository — реализация WalletRepository на SQL (PostgreSQL или SQLite); время пишется в UTC.
+type walletRepository struct {
 	db *sql.DB
 }
 
-func NewWalletRepository(db *sql.DB) *WalletRepository {
-	return &WalletRepository{db: db}
+// NewWalletRepository возвращает реализацию WalletRepository для PostgreSQL или SQLite.
+func NewWalletRepository(db *sql.DB) WalletRepository {
+	return &walletRepository{db: db}
 }
 
-func (r *WalletRepository) InsertWalletInfo(info *model.WalletInfoRep) error {
+func (r *walletRepository) InsertWalletInfo(info *model.WalletInfoRep) error {
 	query := `
 		INSERT INTO wallet_info (coin, wallet_balance, total_margin_balance, total_wallet_balance, recorded_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
 		RETURNING id
 	`
 	err := r.db.QueryRow(query,
@@ -26,7 +44,8 @@ func (r *WalletRepository) InsertWalletInfo(info *model.WalletInfoRep) error {
 		info.WalletBalance,
 		info.TotalMarginBalance,
 		info.TotalWalletBalance,
-		info.RecordedAt,
+		info.RecordedAt.UTC(),
+		time.Now().UTC(),
 	).Scan(&info.ID)
 	if err != nil {
 		return fmt.Errorf("failed to insert wallet info: %w", err)
@@ -34,20 +53,20 @@ func (r *WalletRepository) InsertWalletInfo(info *model.WalletInfoRep) error {
 	return nil
 }
 
-// UpdateWalletInfo обновляет существующую запись баланса по идентификатору.
-func (r *WalletRepository) UpdateWalletInfo(info *model.WalletInfoRep) error {
+func (r *walletRepository) UpdateWalletInfo(info *model.WalletInfoRep) error {
 	query := `
 		UPDATE wallet_info
 		SET coin = $1, wallet_balance = $2, total_margin_balance = $3,
-		    total_wallet_balance = $4, recorded_at = $5, updated_at = NOW()
-		WHERE id = $6
+		    total_wallet_balance = $4, recorded_at = $5, updated_at = $6
+		WHERE id = $7
 	`
 	_, err := r.db.Exec(query,
 		info.Coin,
 		info.WalletBalance,
 		info.TotalMarginBalance,
 		info.TotalWalletBalance,
-		info.RecordedAt,
+		info.RecordedAt.UTC(),
+		time.Now().UTC(),
 		info.ID,
 	)
 	if err != nil {
@@ -56,8 +75,7 @@ func (r *WalletRepository) UpdateWalletInfo(info *model.WalletInfoRep) error {
 	return nil
 }
 
-// GetLatestWalletInfo возвращает последнюю запись баланса для заданной валюты.
-func (r *WalletRepository) GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error) {
+func (r *walletRepository) GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error) {
 	query := `
 		SELECT id, coin, wallet_balance, total_margin_balance, total_wallet_balance, recorded_at, created_at, updated_at
 		FROM wallet_info
@@ -86,7 +104,7 @@ func (r *WalletRepository) GetLatestWalletInfo(coin string) (*model.WalletInfoRe
 	return &info, nil
 }
 
-func (r *WalletRepository) GetLatestWalletBalance(walletBalance float64) (*model.WalletInfoRep, error) {
+func (r *walletRepository) GetLatestWalletBalance(walletBalance float64) (*model.WalletInfoRep, error) {
 	query := `
 		SELECT id, coin, wallet_balance, total_margin_balance, total_wallet_balance, recorded_at, created_at, updated_at
 		FROM wallet_info
@@ -115,7 +133,12 @@ func (r *WalletRepository) GetLatestWalletBalance(walletBalance float64) (*model
 	return &info, nil
 }
 
-func (r *WalletRepository) SaveWalletInfo(info *model.WalletInfoRep) error {
+func (r *walletRepository) SaveWalletInfo(info *model.WalletInfoRep) error {
+	return saveWalletInfo(r, info)
+}
+
+// saveWalletInfo — общая для реализаций логика SaveWalletInfo.
+func saveWalletInfo(r WalletRepository, info *model.WalletInfoRep) error {
 	existing, err := r.GetLatestWalletBalance(info.WalletBalance)
 	if err != nil {
 		return fmt.Errorf("failed to get latest wallet Balance: %w", err)
@@ -128,3 +151,13 @@ func (r *WalletRepository) SaveWalletInfo(info *model.WalletInfoRep) error {
 		return r.UpdateWalletInfo(info)
 	}
 }
+
+func (r *walletRepository) GetPeakWalletBalance(coin string, since time.Time) (float64, error) {
+	var peak sql.NullFloat64
+	err := r.db.QueryRow(`SELECT MAX(wallet_balance) FROM wallet_info WHERE coin = $1 AND recorded_at >= $2`, coin, since.UTC()).
+		Scan(&peak)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get peak wallet balance: %w", err)
+	}
+	return peak.Float64, nil
+}
@@ -0,0 +1,68 @@
+package event
+
+import "time"
+
+// ConnectionState описывает состояние WebSocket-соединения.
+type ConnectionState string
+
+const (
+	StateConnected    ConnectionState = "connected"
+	StateReconnecting ConnectionState = "reconnecting"
+	StateClosed       ConnectionState = "closed"
+)
+
+// ConnectionStatus — снимок состояния соединения.
+// Since — момент перехода в текущее состояние, LastMessageAt — время последнего полученного сообщения.
+type ConnectionStatus struct {
+	State         ConnectionState
+	Since         time.Time
+	LastMessageAt time.Time
+}
+
+// StaleSince возвращает момент, с которого кеши перестали обновляться.
+// Для живого соединения возвращает нулевое время.
+func (s ConnectionStatus) StaleSince() time.Time {
+	if s.State == StateConnected {
+		return time.Time{}
+	}
+	if !s.LastMessageAt.IsZero() {
+		return s.LastMessageAt
+	}
+	return s.Since
+}
+
+// Status возвращает текущее состояние соединения.
+func (s *stream) Status() ConnectionStatus {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.status
+}
+
+// IsHealthy сообщает, что соединение активно и последнее сообщение пришло не раньше maxAge назад.
+// Стратегии должны проверять его перед принятием решений по данным из кешей.
+func (s *stream) IsHealthy(maxAge time.Duration) bool {
+	st := s.Status()
+	if st.State != StateConnected {
+		return false
+	}
+	if maxAge > 0 && !st.LastMessageAt.IsZero() && time.Since(st.LastMessageAt) > maxAge {
+		return false
+	}
+	return true
+}
+
+func (s *stream) setState(state ConnectionState) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.status.State == state {
+		return
+	}
+	s.status.State = state
+	s.status.Since = time.Now()
+}
+
+func (s *stream) touch() {
+	s.stateMu.Lock()
+	s.status.LastMessageAt = time.Now()
+	s.stateMu.Unlock()
+}
@@ -0,0 +1,113 @@
+package event
+
+import (
+	"bybit-bot/internal/model"
+	"fmt"
+	"sort"
+)
+
+// localOrderbook — локальная копия ордербука, которая поддерживается по snapshot и delta сообщениям.
+// Bids всегда отсортированы по убыванию цены, Asks — по возрастанию.
+type localOrderbook struct {
+	symbol   string
+	bids     []model.PriceLevel
+	asks     []model.PriceLevel
+	updateID int64
+	seq      int64
+	ts       int64
+	cts      int64
+}
+
+// errOrderbookGap возвращается при нарушении последовательности обновлений.
+type errOrderbookGap struct {
+	topic    string
+	expected int64
+	got      int64
+}
+
+func (e *errOrderbookGap) Error() string {
+	return fmt.Sprintf("orderbook %s: gap in updates, expected u=%d, got u=%d", e.topic, e.expected, e.got)
+}
+
+func newLocalOrderbook(msg *model.OrderbookMessage) *localOrderbook {
+	ob := &localOrderbook{}
+	ob.applySnapshot(msg)
+	return ob
+}
+
+func (ob *localOrderbook) applySnapshot(msg *model.OrderbookMessage) {
+	ob.symbol = msg.Data.Symbol
+	ob.bids = ob.bids[:0]
+	ob.asks = ob.asks[:0]
+	for _, lvl := range msg.Data.Bids {
+		ob.bids = upsertLevel(ob.bids, lvl, true)
+	}
+	for _, lvl := range msg.Data.Asks {
+		ob.asks = upsertLevel(ob.asks, lvl, false)
+	}
+	ob.updateID = msg.Data.UpdateID
+	ob.seq = msg.Data.Seq
+	ob.ts = msg.Ts
+	ob.cts = msg.Cts
+}
+
+// applyDelta применяет инкрементальное обновление. Размер 0 удаляет уровень.
+// Если Update ID не следует непосредственно за предыдущим или seq уменьшился, возвращается errOrderbookGap.
+func (ob *localOrderbook) applyDelta(msg *model.OrderbookMessage) error {
+	if msg.Data.UpdateID != ob.updateID+1 || (msg.Data.Seq != 0 && msg.Data.Seq < ob.seq) {
+		return &errOrderbookGap{topic: msg.Topic, expected: ob.updateID + 1, got: msg.Data.UpdateID}
+	}
+	for _, lvl := range msg.Data.Bids {
+		ob.bids = upsertLevel(ob.bids, lvl, true)
+	}
+	for _, lvl := range msg.Data.Asks {
+		ob.asks = upsertLevel(ob.asks, lvl, false)
+	}
+	ob.updateID = msg.Data.UpdateID
+	ob.seq = msg.Data.Seq
+	ob.ts = msg.Ts
+	ob.cts = msg.Cts
+	return nil
+}
+
+// snapshot возвращает копию ордербука, безопасную для использования вне блокировки.
+func (ob *localOrderbook) snapshot() *model.OrderbookData {
+	data := &model.OrderbookData{
+		Symbol:   ob.symbol,
+		Bids:     make([]model.PriceLevel, len(ob.bids)),
+		Asks:     make([]model.PriceLevel, len(ob.asks)),
+		UpdateID: ob.updateID,
+		Seq:      ob.seq,
+		Ts:       ob.ts,
+		Cts:      ob.cts,
+	}
+	copy(data.Bids, ob.bids)
+	copy(data.Asks, ob.asks)
+	return data
+}
+
+// upsertLevel вставляет, обновляет или удаляет ценовой уровень, сохраняя сортировку.
+func upsertLevel(levels []model.PriceLevel, lvl model.PriceLevel, desc bool) []model.PriceLevel {
+	i := sort.Search(len(levels), func(i int) bool {
+		if desc {
+			return levels[i].Price <= lvl.Price
+		}
+		return levels[i].Price >= lvl.Price
+	})
+	found := i < len(levels) && levels[i].Price == lvl.Price
+
+	switch {
+	case lvl.Size == 0 && found:
+		return append(levels[:i], levels[i+1:]...)
+	case lvl.Size == 0:
+		return levels
+	case found:
+		levels[i].Size = lvl.Size
+		return levels
+	}
+
+	levels = append(levels, model.PriceLevel{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = lvl
+	return levels
+}
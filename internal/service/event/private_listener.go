@@ -0,0 +1,222 @@
+package event
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	PrivateTopicOrder     = "order"
+	PrivateTopicExecution = "execution"
+	PrivateTopicPosition  = "position"
+	PrivateTopicWallet    = "wallet"
+
+	authTimeout = 10 * time.Second
+)
+
+// PrivateTopics — топики приватного потока, на которые подписывается бот.
+var PrivateTopics = []string{PrivateTopicOrder, PrivateTopicExecution, PrivateTopicPosition, PrivateTopicWallet}
+
+// PrivateListener слушает приватный поток Bybit (wss://stream.bybit.com/v5/private):
+// обновления ордеров, исполнения, позиции и кошелёк. Баланс сразу сохраняется в репозитории,
+// остальные события передаются подписчикам через On* колбэки (статусы ордеров ведёт orders.Manager).
+type PrivateListener struct {
+	*stream
+
+	apiKey    string
+	apiSecret string
+
+	WalletRepository repository.WalletRepository
+
+	OnOrder     func(update model.OrderUpdate)
+	OnExecution func(update model.ExecutionUpdate)
+	OnPosition  func(update model.PositionUpdate)
+	OnWallet    func(update model.WalletUpdate)
+
+	positions map[string]model.PositionUpdate
+	mu        sync.RWMutex
+}
+
+// NewPrivateListener подключается к приватному потоку и проходит аутентификацию.
+// После каждого переподключения аутентификация повторяется автоматически.
+func NewPrivateListener(urlStr, apiKey, apiSecret string) (*PrivateListener, error) {
+	p := &PrivateListener{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		positions: make(map[string]model.PositionUpdate),
+	}
+	st, err := newStream(urlStr, nil, p.handleMessage)
+	if err != nil {
+		return nil, err
+	}
+	p.stream = st
+	if err := p.authenticate(); err != nil {
+		_ = st.Close()
+		return nil, err
+	}
+	st.onConnect = p.authenticate
+	return p, nil
+}
+
+// authenticate отправляет op=auth и синхронно ждёт ответа. Подпись — HMAC-SHA256
+// от строки "GET/realtime{expires}" с секретом API.
+func (p *PrivateListener) authenticate() error {
+	expires := time.Now().Add(authTimeout).UnixMilli()
+	mac := hmac.New(sha256.New, []byte(p.apiSecret))
+	mac.Write([]byte("GET/realtime" + strconv.FormatInt(expires, 10)))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	msg, err := json.Marshal(map[string]interface{}{
+		"op":   "auth",
+		"args": []interface{}{p.apiKey, expires, signature},
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.writeMessage(msg); err != nil {
+		return fmt.Errorf("failed to send auth: %w", err)
+	}
+
+	reply, err := p.readMessage(authTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to read auth response: %w", err)
+	}
+	var resp struct {
+		Success bool   `json:"success"`
+		RetMsg  string `json:"ret_msg"`
+		Op      string `json:"op"`
+	}
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return fmt.Errorf("failed to parse auth response: %w", err)
+	}
+	if resp.Op != "auth" || !resp.Success {
+		return fmt.Errorf("auth rejected: %s", resp.RetMsg)
+	}
+	log.Printf("Аутентификация в приватном потоке Bybit выполнена")
+	return nil
+}
+
+// Subscribe подписывается на все приватные топики.
+func (p *PrivateListener) Subscribe() error {
+	return p.SubscribeChannels(PrivateTopics)
+}
+
+// GetPosition возвращает последнее известное состояние позиции по символу.
+func (p *PrivateListener) GetPosition(symbol string) (model.PositionUpdate, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pos, ok := p.positions[symbol]
+	return pos, ok
+}
+
+func (p *PrivateListener) handleMessage(message []byte) {
+	var msg model.PrivateMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("[Приватный поток] Ошибка парсинга сообщения: %v", err)
+		return
+	}
+	if msg.Topic == "" {
+		return
+	}
+
+	var err error
+	switch msg.Topic {
+	case PrivateTopicOrder:
+		err = p.handleOrders(msg.Data)
+	case PrivateTopicExecution:
+		err = p.handleExecutions(msg.Data)
+	case PrivateTopicPosition:
+		err = p.handlePositions(msg.Data)
+	case PrivateTopicWallet:
+		err = p.handleWallet(msg.Data)
+	default:
+		log.Printf("[Приватный поток] Неизвестный топик: %s", msg.Topic)
+	}
+	if err != nil {
+		log.Printf("[Приватный поток] Ошибка обработки %s: %v", msg.Topic, err)
+	}
+}
+
+func (p *PrivateListener) handleOrders(data json.RawMessage) error {
+	var updates []model.OrderUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		log.Printf("[Приватный поток] Ордер %s %s %s: статус=%s, исполнено=%v из %v",
+			u.OrderID, u.Symbol, u.Side, u.OrderStatus, u.CumExecQty, u.Qty)
+		if p.OnOrder != nil {
+			p.OnOrder(u)
+		}
+	}
+	return nil
+}
+
+func (p *PrivateListener) handleExecutions(data json.RawMessage) error {
+	var updates []model.ExecutionUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		log.Printf("[Приватный поток] Исполнение %s %s %s: %v по %v, комиссия %v",
+			u.OrderID, u.Symbol, u.Side, u.ExecQty, u.ExecPrice, u.ExecFee)
+		if p.OnExecution != nil {
+			p.OnExecution(u)
+		}
+	}
+	return nil
+}
+
+func (p *PrivateListener) handlePositions(data json.RawMessage) error {
+	var updates []model.PositionUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		p.mu.Lock()
+		p.positions[u.Symbol] = u
+		p.mu.Unlock()
+		if p.OnPosition != nil {
+			p.OnPosition(u)
+		}
+	}
+	return nil
+}
+
+func (p *PrivateListener) handleWallet(data json.RawMessage) error {
+	var updates []model.WalletUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if p.WalletRepository != nil {
+			for _, c := range u.Coin {
+				if c.Coin != "USDT" {
+					continue
+				}
+				info := &model.WalletInfoRep{
+					Coin:               c.Coin,
+					WalletBalance:      c.WalletBalance.Float64(),
+					TotalMarginBalance: u.TotalMarginBalance.Float64(),
+					TotalWalletBalance: u.TotalWalletBalance.Float64(),
+				}
+				if err := p.WalletRepository.SaveWalletInfo(info); err != nil {
+					log.Printf("[Приватный поток] Ошибка сохранения баланса: %v", err)
+				}
+			}
+		}
+		if p.OnWallet != nil {
+			p.OnWallet(u)
+		}
+	}
+	return nil
+}
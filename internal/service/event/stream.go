@@ -0,0 +1,329 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Параметры поддержания соединения по умолчанию.
+// Bybit рекомендует отправлять ping каждые 20 секунд.
+const (
+	DefaultPingInterval = 20 * time.Second
+	DefaultReadTimeout  = 60 * time.Second
+	DefaultMinBackoff   = 1 * time.Second
+	DefaultMaxBackoff   = 60 * time.Second
+	writeTimeout        = 10 * time.Second
+)
+
+var ErrListenerClosed = errors.New("ws listener closed")
+
+// stream — WebSocket-соединение с Bybit под надзором: ping, read deadline,
+// переподключение с экспоненциальной задержкой и повторная подписка на каналы.
+// Разбор сообщений делегируется в handle.
+type stream struct {
+	url    string
+	header http.Header
+	dialer *websocket.Dialer
+
+	wsConn  *websocket.Conn
+	writeMu sync.Mutex
+
+	channels []string // все каналы, на которые была оформлена подписка
+	status   ConnectionStatus
+	closed   bool
+	stateMu  sync.RWMutex
+
+	PingInterval time.Duration
+	ReadTimeout  time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+
+	handle       func(message []byte)
+	onConnect    func() error // вызывается после каждого переподключения до повторной подписки
+	onDisconnect func()
+
+	listen sync.Once
+	stop   chan struct{} // закрывается в Close и прерывает ожидание переподключения
+	done   chan struct{}
+}
+
+func newStream(urlStr string, header http.Header, handle func([]byte)) (*stream, error) {
+	s := &stream{
+		url:          urlStr,
+		header:       header,
+		dialer:       websocket.DefaultDialer,
+		PingInterval: DefaultPingInterval,
+		ReadTimeout:  DefaultReadTimeout,
+		MinBackoff:   DefaultMinBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		handle:       handle,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	s.wsConn = conn
+	s.setState(StateConnected)
+	log.Printf("Подключение к WebSocket установлено: %s", urlStr)
+	return s, nil
+}
+
+func (s *stream) dial() (*websocket.Conn, error) {
+	conn, resp, err := s.dialer.Dial(s.url, s.header)
+	if err != nil {
+		log.Printf("Ошибка подключения к WebSocket: %v", err)
+		if resp != nil {
+			log.Printf("HTTP статус: %s", resp.Status)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// SubscribeChannels отправляет запрос на подписку сразу на несколько каналов.
+// Каналы запоминаются и автоматически переподписываются после переподключения.
+func (s *stream) SubscribeChannels(channels []string) error {
+	s.stateMu.Lock()
+	for _, ch := range channels {
+		if !containsString(s.channels, ch) {
+			s.channels = append(s.channels, ch)
+		}
+	}
+	s.stateMu.Unlock()
+
+	return s.sendOp("subscribe", channels)
+}
+
+// Channels возвращает список каналов, на которые оформлена подписка.
+func (s *stream) Channels() []string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	out := make([]string, len(s.channels))
+	copy(out, s.channels)
+	return out
+}
+
+// resubscribe переподписывается на топик, чтобы получить свежий snapshot.
+func (s *stream) resubscribe(topic string) {
+	if err := s.sendOp("unsubscribe", []string{topic}); err != nil {
+		return
+	}
+	_ = s.sendOp("subscribe", []string{topic})
+}
+
+func (s *stream) sendOp(op string, args []string) error {
+	subMsg := map[string]interface{}{
+		"op": op,
+	}
+	if args != nil {
+		subMsg["args"] = args
+	}
+	msg, err := json.Marshal(subMsg)
+	if err != nil {
+		return err
+	}
+	if op == "subscribe" || op == "unsubscribe" {
+		log.Printf("Отправка запроса %s: %s", op, string(msg))
+	}
+	if err := s.writeMessage(msg); err != nil {
+		log.Printf("Ошибка отправки запроса %s: %v", op, err)
+		return err
+	}
+	return nil
+}
+
+func (s *stream) writeMessage(msg []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.wsConn == nil {
+		return websocket.ErrCloseSent
+	}
+	_ = s.wsConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.wsConn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// readMessage синхронно читает одно сообщение. Используется только до запуска ListenAll
+// или внутри onConnect, когда цикл чтения ещё не работает.
+func (s *stream) readMessage(timeout time.Duration) ([]byte, error) {
+	conn := s.currentConn()
+	if conn == nil {
+		return nil, ErrListenerClosed
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	_, message, err := conn.ReadMessage()
+	return message, err
+}
+
+// ListenAll запускает прослушивание WebSocket.
+// При обрыве соединения слушатель переподключается с экспоненциальной задержкой
+// и заново подписывается на все ранее запрошенные каналы.
+// Цикл чтения запускается один раз, повторные вызовы ничего не делают.
+func (s *stream) ListenAll() {
+	s.listen.Do(func() { go s.run() })
+}
+
+// run — цикл чтения с переподключением; завершается после Close.
+func (s *stream) run() {
+	defer close(s.done)
+	backoff := s.MinBackoff
+	for {
+		conn := s.currentConn()
+		if conn != nil {
+			s.readLoop(conn)
+		}
+		if s.isClosed() {
+			return
+		}
+
+		s.setState(StateReconnecting)
+		if s.onDisconnect != nil {
+			s.onDisconnect()
+		}
+
+		for {
+			log.Printf("Переподключение к WebSocket через %s", backoff)
+			if !s.wait(backoff) {
+				return
+			}
+			if s.reconnect() {
+				break
+			}
+			backoff *= 2
+			if backoff > s.MaxBackoff {
+				backoff = s.MaxBackoff
+			}
+		}
+		backoff = s.MinBackoff
+		s.setState(StateConnected)
+		log.Printf("Соединение с WebSocket восстановлено: %s", s.url)
+
+		if channels := s.Channels(); len(channels) > 0 {
+			if err := s.sendOp("subscribe", channels); err != nil {
+				log.Printf("Ошибка повторной подписки на каналы: %v", err)
+			}
+		}
+	}
+}
+
+// wait выжидает паузу перед переподключением. Возвращает false, если слушатель закрыт через Close.
+func (s *stream) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *stream) reconnect() bool {
+	newConn, err := s.dial()
+	if err != nil {
+		return false
+	}
+	s.writeMu.Lock()
+	s.wsConn = newConn
+	s.writeMu.Unlock()
+	// Close мог быть вызван, пока шло подключение: новое соединение уже никто не закроет.
+	if s.isClosed() {
+		_ = newConn.Close()
+		return false
+	}
+
+	if s.onConnect != nil {
+		if err := s.onConnect(); err != nil {
+			log.Printf("Ошибка инициализации соединения: %v", err)
+			_ = newConn.Close()
+			return false
+		}
+	}
+	return true
+}
+
+// readLoop читает сообщения из одного соединения до первой ошибки.
+func (s *stream) readLoop(conn *websocket.Conn) {
+	stopPing := make(chan struct{})
+	defer close(stopPing)
+	go s.pingLoop(stopPing)
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if !s.isClosed() {
+				log.Printf("Ошибка чтения из WebSocket: %v", err)
+			}
+			_ = conn.Close()
+			return
+		}
+		s.touch()
+		s.handle(message)
+	}
+}
+
+// pingLoop периодически отправляет Bybit операцию ping, чтобы сервер не закрыл соединение.
+func (s *stream) pingLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.sendOp("ping", nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Done закрывается, когда слушатель окончательно остановлен через Close.
+func (s *stream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *stream) Close() error {
+	log.Printf("Закрытие WebSocket-соединения")
+	s.stateMu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.stateMu.Unlock()
+	s.setState(StateClosed)
+
+	conn := s.currentConn()
+	if conn == nil {
+		return ErrListenerClosed
+	}
+	return conn.Close()
+}
+
+func (s *stream) currentConn() *websocket.Conn {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.wsConn
+}
+
+func (s *stream) isClosed() bool {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.closed
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
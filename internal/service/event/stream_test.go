@@ -0,0 +1,223 @@
+package event
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dropServer — локальный WebSocket-сервер, который обрывает первое соединение,
+// как только получил подписку на dropAfter каналов.
+type dropServer struct {
+	*httptest.Server
+	dropAfter int
+
+	mu    sync.Mutex
+	conns int
+	subCh chan []string // подписки, полученные после переподключения
+}
+
+func newDropServer(t *testing.T, dropAfter int) *dropServer {
+	srv := &dropServer{dropAfter: dropAfter, subCh: make(chan []string, 8)}
+	upgrader := websocket.Upgrader{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		srv.mu.Lock()
+		srv.conns++
+		n := srv.conns
+		srv.mu.Unlock()
+
+		subscribed := 0
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req struct {
+				Op   string   `json:"op"`
+				Args []string `json:"args"`
+			}
+			if err := json.Unmarshal(msg, &req); err != nil || req.Op != "subscribe" {
+				continue
+			}
+			subscribed += len(req.Args)
+			if n == 1 {
+				if subscribed >= srv.dropAfter {
+					return
+				}
+				continue
+			}
+			srv.subCh <- req.Args
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"topic":"kline.1.BTCUSDT","data":[]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *dropServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func waitState(t *testing.T, s *stream, want ConnectionState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Status().State != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", s.Status().State, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamReconnectResubscribesAllChannels(t *testing.T) {
+	srv := newDropServer(t, 3)
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	st, err := newStream(srv.wsURL(), nil, func(msg []byte) {
+		mu.Lock()
+		received = append(received, string(msg))
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("newStream: %v", err)
+	}
+	st.MinBackoff = 10 * time.Millisecond
+	st.MaxBackoff = 50 * time.Millisecond
+
+	disconnected := make(chan ConnectionStatus, 1)
+	st.onDisconnect = func() { disconnected <- st.Status() }
+
+	if got := st.Status().State; got != StateConnected {
+		t.Fatalf("initial state = %s, want %s", got, StateConnected)
+	}
+	if !st.IsHealthy(time.Minute) {
+		t.Fatal("fresh connection is not healthy")
+	}
+
+	st.ListenAll()
+	st.ListenAll() // повторный вызов не должен запускать второй цикл чтения
+	if err := st.SubscribeChannels([]string{"orderbook.50.BTCUSDT", "kline.1.BTCUSDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := st.SubscribeChannels([]string{"kline.1.BTCUSDT", "orderbook.50.ETHUSDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	select {
+	case status := <-disconnected:
+		if status.State != StateReconnecting {
+			t.Fatalf("state on disconnect = %s, want %s", status.State, StateReconnecting)
+		}
+		if status.StaleSince().IsZero() {
+			t.Fatal("StaleSince is zero while reconnecting")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection drop was not detected")
+	}
+
+	want := []string{"orderbook.50.BTCUSDT", "kline.1.BTCUSDT", "orderbook.50.ETHUSDT"}
+	select {
+	case got := <-srv.subCh:
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("resubscribed to %v, want %v", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channels were not resubscribed after reconnect")
+	}
+
+	waitState(t, st, StateConnected)
+	if !st.IsHealthy(time.Minute) {
+		t.Fatal("reconnected stream is not healthy")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no messages handled after reconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 2 {
+		t.Fatalf("server saw %d connections, want 2", conns)
+	}
+
+	if err := st.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	select {
+	case <-st.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener did not stop after Close")
+	}
+	if got := st.Status().State; got != StateClosed {
+		t.Fatalf("state after Close = %s, want %s", got, StateClosed)
+	}
+	if st.IsHealthy(0) {
+		t.Fatal("closed stream reports healthy")
+	}
+}
+
+func TestStreamCloseInterruptsBackoff(t *testing.T) {
+	srv := newDropServer(t, 1)
+
+	st, err := newStream(srv.wsURL(), nil, func([]byte) {})
+	if err != nil {
+		t.Fatalf("newStream: %v", err)
+	}
+	st.MinBackoff = time.Hour
+	st.MaxBackoff = time.Hour
+
+	disconnected := make(chan struct{})
+	st.onDisconnect = func() { close(disconnected) }
+
+	st.ListenAll()
+	if err := st.SubscribeChannels([]string{"kline.1.BTCUSDT"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	select {
+	case <-disconnected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection drop was not detected")
+	}
+	if st.IsHealthy(0) {
+		t.Fatal("reconnecting stream reports healthy")
+	}
+
+	start := time.Now()
+	_ = st.Close()
+	select {
+	case <-st.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not interrupt reconnect backoff")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %s", elapsed)
+	}
+	if got := st.Status().State; got != StateClosed {
+		t.Fatalf("state after Close = %s, want %s", got, StateClosed)
+	}
+}
@@ -0,0 +1,27 @@
+package event
+
+import "strings"
+
+// OrderbookTopic возвращает топик ордербука глубины 50 для символа.
+func OrderbookTopic(symbol string) string {
+	return "orderbook.50." + symbol
+}
+
+// KlineTopic возвращает топик свечей интервала interval (trading.kline_interval) для символа.
+func KlineTopic(interval, symbol string) string {
+	return "kline." + interval + "." + symbol
+}
+
+// topicSymbol возвращает символ из топика вида "kline.1.BTCUSDT".
+func topicSymbol(topic string) string {
+	return topic[strings.LastIndex(topic, ".")+1:]
+}
+
+// topicInterval возвращает интервал из топика вида "kline.1.BTCUSDT".
+func topicInterval(topic string) string {
+	parts := strings.Split(topic, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
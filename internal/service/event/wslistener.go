@@ -3,152 +3,200 @@ package event
 import (
 	"bybit-bot/internal/model"
 	"encoding/json"
-	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
-	"sort"
 	"strings"
 	"sync"
 )
 
+// WSListener слушает публичный поток Bybit и поддерживает кеши ордербуков и свечей.
+// Управление соединением (ping, переподключение, переподписка) реализовано во встроенном stream.
 type WSListener struct {
-	wsConn         *websocket.Conn
-	orderBookCache map[string]*model.OrderbookData
+	*stream
+
+	orderBookCache map[string]*localOrderbook
+	resyncing      map[string]bool              // топики, для которых запрошен новый snapshot
 	KlineCache     map[string][]model.KlineData // Кэшируем срезы свечей
+	klineHandlers  []func(model.KlineData)
 	mu             sync.RWMutex
-	done           chan struct{}
 }
 
 func NewWSListener(urlStr string, header http.Header) (*WSListener, error) {
-	conn, resp, err := websocket.DefaultDialer.Dial(urlStr, header)
+	w := &WSListener{
+		orderBookCache: make(map[string]*localOrderbook),
+		resyncing:      make(map[string]bool),
+		KlineCache:     make(map[string][]model.KlineData),
+	}
+	st, err := newStream(urlStr, header, w.handleMessage)
 	if err != nil {
-		log.Printf("Ошибка подключения к WebSocket: %v", err)
-		if resp != nil {
-			log.Printf("HTTP статус: %s", resp.Status)
-		}
 		return nil, err
 	}
-	log.Printf("Подключение к WebSocket установлено: %s", urlStr)
-	return &WSListener{
-		wsConn:         conn,
-		orderBookCache: make(map[string]*model.OrderbookData),
-		KlineCache:     make(map[string][]model.KlineData),
-		done:           make(chan struct{}),
-	}, nil
+	st.onDisconnect = w.resetOrderbooks
+	w.stream = st
+	return w, nil
 }
 
-// SubscribeChannels отправляет запрос на подписку сразу на несколько каналов.
-func (w *WSListener) SubscribeChannels(channels []string) error {
-	subMsg := map[string]interface{}{
-		"op":   "subscribe",
-		"args": channels,
+func (w *WSListener) handleMessage(message []byte) {
+	var temp struct {
+		Topic string `json:"topic"`
+		Op    string `json:"op"`
 	}
-	msg, err := json.Marshal(subMsg)
-	if err != nil {
-		return err
+	if err := json.Unmarshal(message, &temp); err != nil {
+		log.Printf("Ошибка парсинга topic: %v", err)
+		return
 	}
-	log.Printf("Отправка запроса на подписку: %s", string(msg))
-	if err := w.wsConn.WriteMessage(websocket.TextMessage, msg); err != nil {
-		log.Printf("Ошибка отправки запроса на подписку: %v", err)
-		return err
+	if temp.Topic == "" {
+		// Ответы на служебные операции (ping/subscribe) не содержат topic.
+		if temp.Op == "" {
+			log.Printf("Сообщение не содержит topic")
+		}
+		return
 	}
-	return nil
-}
 
-// ListenAll запускает прослушивание WebSocket и обновляет кеши, используя топики в качестве ключей.
-func (w *WSListener) ListenAll() {
-	go func() {
-		for {
-			_, message, err := w.wsConn.ReadMessage()
-			if err != nil {
-				log.Printf("Ошибка чтения из WebSocket: %v", err)
-				break
-			}
+	if strings.HasPrefix(temp.Topic, "orderbook") {
+		var obMsg model.OrderbookMessage
+		if err := json.Unmarshal(message, &obMsg); err != nil {
+			log.Printf("Ошибка парсинга orderbook сообщения: %v", err)
+			return
+		}
+		if err := w.applyOrderbook(&obMsg); err != nil {
+			log.Printf("Ошибка применения обновления ордербука: %v. Запрашиваем новый snapshot", err)
+			w.resubscribe(temp.Topic)
+		}
+		return
+	}
 
-			var temp struct {
-				Topic string `json:"topic"`
-			}
-			if err := json.Unmarshal(message, &temp); err != nil {
-				log.Printf("Ошибка парсинга topic: %v", err)
-				continue
-			}
-			if temp.Topic == "" {
-				log.Printf("Сообщение не содержит topic")
-				continue
+	if strings.HasPrefix(temp.Topic, "kline") {
+		var klMsg model.KlineMessage
+		if err := json.Unmarshal(message, &klMsg); err != nil {
+			log.Printf("Ошибка парсинга kline сообщения: %v", err)
+			return
+		}
+		if len(klMsg.Data) == 0 {
+			log.Printf("Kline сообщение не содержит данных")
+			return
+		}
+
+		incoming := klMsg.Data[0]
+		if incoming.Symbol == "" {
+			incoming.Symbol = topicSymbol(temp.Topic)
+		}
+		if incoming.Interval == "" {
+			incoming.Interval = topicInterval(temp.Topic)
+		}
+		handlers := w.storeKline(temp.Topic, incoming)
+		if incoming.Confirm {
+			for _, h := range handlers {
+				h(incoming)
 			}
+		}
+	}
+}
 
-			w.mu.Lock()
-			func() {
-				defer w.mu.Unlock()
-
-				if strings.HasPrefix(temp.Topic, "orderbook") {
-					var obMsg model.OrderbookMessage
-					if err := json.Unmarshal(message, &obMsg); err != nil {
-						log.Printf("Ошибка парсинга orderbook сообщения: %v", err)
-						return
-					}
-					if obMsg.Type != "snapshot" {
-						return
-					}
-					w.orderBookCache[temp.Topic] = &obMsg.Data
-
-				} else if strings.HasPrefix(temp.Topic, "kline") {
-					var klMsg model.KlineMessage
-					if err := json.Unmarshal(message, &klMsg); err != nil {
-						log.Printf("Ошибка парсинга kline сообщения: %v", err)
-						return
-					}
-					if len(klMsg.Data) == 0 {
-						log.Printf("Kline сообщение не содержит данных")
-						return
-					}
-
-					incoming := klMsg.Data[0]
-					existing := w.KlineCache[temp.Topic]
-
-					if len(existing) > 0 {
-						last := existing[len(existing)-1]
-						if last.Start == incoming.Start {
-							existing[len(existing)-1] = incoming
-							w.KlineCache[temp.Topic] = existing
-							log.Printf("Обновлена незакрытая свеча (start=%d) для топика %s. Всего свечей: %d", incoming.Start, temp.Topic, len(existing))
-							return
-						}
-					}
-					existing = append(existing, incoming)
-					w.KlineCache[temp.Topic] = existing
-					log.Printf("Добавлена новая свеча (start=%d, confirm=%v) для топика %s. Всего свечей: %d", incoming.Start, incoming.Confirm, temp.Topic, len(existing))
-				}
-			}()
+// storeKline обновляет кеш свечей топика и возвращает обработчики закрытых свечей.
+func (w *WSListener) storeKline(topic string, incoming model.KlineData) []func(model.KlineData) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	existing := w.KlineCache[topic]
+	if len(existing) > 0 {
+		last := existing[len(existing)-1]
+		if last.Start == incoming.Start {
+			existing[len(existing)-1] = incoming
+			w.KlineCache[topic] = existing
+			log.Printf("Обновлена незакрытая свеча (start=%d) для топика %s. Всего свечей: %d", incoming.Start, topic, len(existing))
+			return w.klineHandlers
 		}
-		close(w.done)
-	}()
+	}
+	existing = append(existing, incoming)
+	w.KlineCache[topic] = existing
+	log.Printf("Добавлена новая свеча (start=%d, confirm=%v) для топика %s. Всего свечей: %d", incoming.Start, incoming.Confirm, topic, len(existing))
+	return w.klineHandlers
 }
 
+// OnClosedKline добавляет обработчик закрытых свечей (confirm=true) всех топиков свечей.
+// Symbol и Interval свечи заполняются из топика, если их нет в сообщении. Обработчики
+// вызываются из цикла чтения потока вне блокировки кеша, поэтому не должны надолго блокироваться.
+func (w *WSListener) OnClosedKline(handler func(model.KlineData)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.klineHandlers = append(w.klineHandlers, handler)
+}
+
+// applyOrderbook обновляет локальный ордербук топика. Сообщение типа snapshot (или delta с u=1,
+// что означает перезапуск сервиса на стороне Bybit) полностью заменяет книгу.
+func (w *WSListener) applyOrderbook(msg *model.OrderbookMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	book, ok := w.orderBookCache[msg.Topic]
+	if msg.Type == "snapshot" || msg.Data.UpdateID == 1 {
+		if !ok {
+			w.orderBookCache[msg.Topic] = newLocalOrderbook(msg)
+		} else {
+			book.applySnapshot(msg)
+		}
+		delete(w.resyncing, msg.Topic)
+		return nil
+	}
+	if !ok {
+		// delta до snapshot: если переподписка уже запрошена, просто ждём snapshot
+		if w.resyncing[msg.Topic] {
+			return nil
+		}
+		w.resyncing[msg.Topic] = true
+		return &errOrderbookGap{topic: msg.Topic, got: msg.Data.UpdateID}
+	}
+	if err := book.applyDelta(msg); err != nil {
+		delete(w.orderBookCache, msg.Topic)
+		w.resyncing[msg.Topic] = true
+		return err
+	}
+	return nil
+}
+
+// resetOrderbooks сбрасывает ордербуки: после переподключения Bybit пришлёт новый snapshot,
+// а до этого момента старые данные использовать нельзя.
+func (w *WSListener) resetOrderbooks() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.orderBookCache = make(map[string]*localOrderbook)
+	w.resyncing = make(map[string]bool)
+}
+
+// GetOrderbookByTopic возвращает копию локального ордербука. Уровни уже отсортированы:
+// Bids по убыванию цены, Asks по возрастанию. Поля Ts/Cts позволяют отбросить устаревшие данные.
 func (w *WSListener) GetOrderbookByTopic(topic string) (*model.OrderbookData, bool) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
-	data, ok := w.orderBookCache[topic]
+	book, ok := w.orderBookCache[topic]
 	if !ok {
 		return nil, false
 	}
-	sortedData := model.OrderbookData{
-		Symbol: data.Symbol,
-		Bids:   make([]model.PriceLevel, len(data.Bids)),
-		Asks:   make([]model.PriceLevel, len(data.Asks)),
-	}
-	copy(sortedData.Bids, data.Bids)
-	copy(sortedData.Asks, data.Asks)
-
-	sort.SliceStable(sortedData.Bids, func(i, j int) bool {
-		return sortedData.Bids[i].Price > sortedData.Bids[j].Price
-	})
-
-	sort.SliceStable(sortedData.Asks, func(i, j int) bool {
-		return sortedData.Asks[i].Price < sortedData.Asks[j].Price
-	})
+	return book.snapshot(), true
+}
 
-	return &sortedData, true
+// LastPrice возвращает текущую цену символа: середину спреда локального ордербука,
+// а если его нет — цену закрытия самой свежей свечи символа из любого топика свечей.
+func (w *WSListener) LastPrice(symbol string) (float64, bool) {
+	if ob, ok := w.GetOrderbookByTopic(OrderbookTopic(symbol)); ok && len(ob.Bids) > 0 && len(ob.Asks) > 0 {
+		return (ob.Bids[0].Price + ob.Asks[0].Price) / 2, true
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	var last *model.KlineData
+	for topic, klines := range w.KlineCache {
+		if len(klines) == 0 || topicSymbol(topic) != symbol {
+			continue
+		}
+		if k := &klines[len(klines)-1]; last == nil || k.Start > last.Start {
+			last = k
+		}
+	}
+	if last == nil {
+		return 0, false
+	}
+	return last.Close, true
 }
 
 // GetKlineByTopic возвращает кешированные данные свечей по топику.
@@ -167,8 +215,3 @@ func (w *WSListener) GetKlinesByTopic(topic string) ([]model.KlineData, bool) {
 	klines, ok := w.KlineCache[topic]
 	return klines, ok
 }
-
-func (w *WSListener) Close() error {
-	log.Printf("Закрытие WebSocket-соединения")
-	return w.wsConn.Close()
-}
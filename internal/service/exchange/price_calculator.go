@@ -1,47 +1,164 @@
 package exchange
 
 import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/interfaces"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/repository"
 	"bybit-bot/internal/service/event"
+	"bybit-bot/internal/utils"
+	"errors"
+	"fmt"
 	"log"
 	"math"
+	"strings"
 )
 
+// defaultQuantityStep — шаг объёма, если лимиты инструмента его не задают.
+const defaultQuantityStep = 0.001
+
 type PriceCalculatorInterface interface {
 	CalculateBuy(tradeLimit model.TradeLimits) model.BuyPrice
 	CalculateSell(tradeLimit model.TradeLimits, order model.Order) (float64, error)
 }
 
+// PriceCalculator рассчитывает объём позиции в режиме Sizing.Mode. Во всех режимах
+// маржа позиции с комиссией входа не превышает MaxPositionPercent капитала, а объём
+// округляется вниз до шага инструмента и ограничивается его MinQuantity/MaxQuantity.
 type PriceCalculator struct {
-	OrderRepository  repository.OrderRepository
-	WSListener       *event.WSListener
-	WalletRepository *repository.WalletRepository
+	OrderRepository    repository.OrderRepository
+	WSListener         *event.WSListener
+	WalletRepository   repository.WalletRepository
+	Balance            interfaces.BalanceSource // если задан, используется вместо WalletRepository (симуляция)
+	MaxPositionPercent float64                  // доля баланса на позицию, по умолчанию 10%
+	Sizing             config.SizingConfig      // пустой Mode — percent_equity
 }
 
-func (pc *PriceCalculator) CalculateQuantity(symbol string, entryPrice float64) float64 {
-	balance, err := pc.WalletRepository.GetLatestWalletInfo("USDT")
+func (pc *PriceCalculator) CalculateQuantity(req model.SizingRequest) (model.SizingResult, error) {
+	s := pc.Sizing
+	res := model.SizingResult{Mode: s.Mode}
+	if res.Mode == "" {
+		res.Mode = model.SizingPercentEquity
+	}
+	if req.EntryPrice <= 0 {
+		return res, fmt.Errorf("invalid entry price %v", req.EntryPrice)
+	}
+	equity, err := pc.availableBalance("USDT")
 	if err != nil {
-		log.Printf("[Риск-менеджмент] Ошибка получения баланса: %v", err)
-		return 0
+		return res, fmt.Errorf("get balance: %w", err)
+	}
+	if equity <= 0 {
+		return res, fmt.Errorf("no available balance: %.2f USDT", equity)
+	}
+	res.Equity = equity
+
+	maxPositionPercent := pc.MaxPositionPercent
+	if maxPositionPercent <= 0 {
+		maxPositionPercent = 0.10
+	}
+	leverage := math.Max(s.Leverage, 1)
+	fee := s.FeeRate
+	// Маржа и комиссия входа: notional/leverage + notional*fee <= equity*maxPositionPercent.
+	maxNotional := equity * maxPositionPercent / (1/leverage + fee)
+
+	var stopDistance float64
+	if req.StopLoss > 0 {
+		stopDistance = math.Abs(req.EntryPrice - req.StopLoss)
+	}
+	steps := []string{fmt.Sprintf("капитал %.2f USDT", equity)}
+
+	var quantity float64
+	switch res.Mode {
+	case model.SizingFixedNotional:
+		quantity = s.FixedNotional / req.EntryPrice
+		steps = append(steps, fmt.Sprintf("фиксированная стоимость %.2f USDT", s.FixedNotional))
+	case model.SizingPercentEquity:
+		quantity = maxNotional / req.EntryPrice
+		steps = append(steps, fmt.Sprintf("маржа с комиссией входа %.2f%% капитала, плечо %.0f", maxPositionPercent*100, leverage))
+	case model.SizingFixedFractional:
+		if stopDistance <= 0 {
+			return res, errors.New("fixed_fractional sizing requires a stop loss")
+		}
+		quantity = equity * s.RiskPercent / pc.lossPerUnit(req.EntryPrice, stopDistance, fee)
+		steps = append(steps, fmt.Sprintf("риск %.2f%% капитала до стопа %.4f (%.3f%%)",
+			s.RiskPercent*100, req.StopLoss, stopDistance/req.EntryPrice*100))
+	case model.SizingATR:
+		if req.ATR <= 0 {
+			return res, errors.New("atr sizing requires ATR")
+		}
+		stopDistance = req.ATR * s.ATRMultiplier
+		quantity = equity * s.RiskPercent / pc.lossPerUnit(req.EntryPrice, stopDistance, fee)
+		steps = append(steps, fmt.Sprintf("риск %.2f%% капитала на %.2f ATR (ATR %.4f, стоп %.3f%%)",
+			s.RiskPercent*100, s.ATRMultiplier, req.ATR, stopDistance/req.EntryPrice*100))
+	case model.SizingKelly:
+		if stopDistance <= 0 {
+			return res, errors.New("kelly sizing requires a stop loss")
+		}
+		kelly := s.KellyWinRate - (1-s.KellyWinRate)/s.KellyPayoff
+		if kelly <= 0 {
+			res.Explanation = strings.Join(append(steps, fmt.Sprintf("Келли %.4f: преимущества нет, объём 0", kelly)), "; ")
+			return res, nil
+		}
+		fraction := math.Min(kelly*s.KellyFraction, s.KellyCap)
+		quantity = equity * fraction / pc.lossPerUnit(req.EntryPrice, stopDistance, fee)
+		steps = append(steps, fmt.Sprintf("Келли %.4f x %.2f, риск %.2f%% капитала (предел %.2f%%)",
+			kelly, s.KellyFraction, fraction*100, s.KellyCap*100))
+	default:
+		return res, fmt.Errorf("unknown sizing mode %q", res.Mode)
+	}
+
+	if quantity*req.EntryPrice > maxNotional*(1+1e-9) {
+		quantity = maxNotional / req.EntryPrice
+		steps = append(steps, fmt.Sprintf("ограничено маржой %.2f%% капитала: %.2f USDT", maxPositionPercent*100, maxNotional))
+	}
+	if limit := req.Limits.MaxQuantity; limit > 0 && quantity > limit {
+		quantity = limit
+		steps = append(steps, fmt.Sprintf("ограничено максимальным объёмом %v", limit))
+	}
+	step := req.Limits.StepSize
+	if step <= 0 {
+		step = req.Limits.MinQuantity
+	}
+	if step <= 0 {
+		step = defaultQuantityStep
+	}
+	quantity = (&utils.Formatter{}).FloorToStep(quantity, step)
+	if quantity < req.Limits.MinQuantity || quantity <= 0 {
+		steps = append(steps, fmt.Sprintf("объём меньше минимального %v, вход пропущен", req.Limits.MinQuantity))
+		quantity = 0
 	}
 
-	maxPositionPercent := 0.10
-	positionSize := balance.WalletBalance * maxPositionPercent
-	quantity := positionSize / entryPrice
+	res.Quantity = quantity
+	res.Notional = quantity * req.EntryPrice
+	if stopDistance > 0 {
+		res.RiskAmount = quantity * pc.lossPerUnit(req.EntryPrice, stopDistance, fee)
+	}
+	steps = append(steps, fmt.Sprintf("объём %v (шаг %v), стоимость %.2f USDT", quantity, step, res.Notional))
+	if res.RiskAmount > 0 {
+		steps = append(steps, fmt.Sprintf("риск %.2f USDT (%.2f%% капитала)", res.RiskAmount, res.RiskAmount/equity*100))
+	}
+	res.Explanation = strings.Join(steps, "; ")
 
-	quantityRounded := math.Round(quantity*1000) / 1000
+	log.Printf("[Риск-менеджмент] %s %s (%s): %s", req.Symbol, req.Side, res.Mode, res.Explanation)
+	return res, nil
+}
 
-	log.Printf("[Риск-менеджмент] %s:\n"+
-		"  Баланс: %.2f USDT\n"+
-		"  Цена входа: %.2f\n"+
-		"  Объем позиции: %.4f BTC\n"+
-		"  Стоимость позиции: %.2f USDT",
-		symbol,
-		balance.WalletBalance,
-		entryPrice,
-		quantityRounded,
-		quantityRounded*entryPrice)
+// lossPerUnit — убыток на единицу объёма при выходе по стопу на расстоянии stopDistance
+// с комиссиями входа и выхода (комиссия выхода — по худшей для обеих сторон цене стопа).
+func (pc *PriceCalculator) lossPerUnit(entry, stopDistance, fee float64) float64 {
+	return stopDistance + (2*entry+stopDistance)*fee
+}
 
-	return quantityRounded
+func (pc *PriceCalculator) availableBalance(coin string) (float64, error) {
+	if pc.Balance != nil {
+		return pc.Balance.AvailableBalance(coin)
+	}
+	info, err := pc.WalletRepository.GetLatestWalletInfo(coin)
+	if err != nil {
+		return 0, err
+	}
+	if info == nil {
+		return 0, fmt.Errorf("no wallet info for %s", coin)
+	}
+	return info.WalletBalance, nil
 }
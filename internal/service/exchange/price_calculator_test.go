@@ -0,0 +1,113 @@
+package exchange
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/model"
+	"math"
+	"strings"
+	"testing"
+)
+
+// fixedBalance — источник баланса с постоянной суммой USDT.
+type fixedBalance float64
+
+func (b fixedBalance) AvailableBalance(string) (float64, error) { return float64(b), nil }
+
+func TestCalculateQuantity(t *testing.T) {
+	limits := model.TradeLimits{Symbol: "BTCUSDT", MinQuantity: 0.01, StepSize: 0.01}
+	// Капитал 10000 USDT, маржа позиции — до 10%, вход 100, стоп 98.
+	request := model.SizingRequest{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, StopLoss: 98, Limits: limits}
+
+	tests := []struct {
+		name    string
+		sizing  config.SizingConfig
+		change  func(*model.SizingRequest)
+		want    float64
+		wantErr bool
+		explain string // фрагмент объяснения
+	}{
+		{name: "fixed notional", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 500}, want: 5},
+		{name: "fixed notional capped by margin", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 5000},
+			want: 10, explain: "ограничено маржой"},
+		{name: "fixed notional floored to the step", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 123.456}, want: 1.23},
+		{name: "coarse step", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 199},
+			change: func(r *model.SizingRequest) { r.Limits.StepSize = 0.5 }, want: 1.5},
+		{name: "below the minimum quantity is skipped", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 0.5},
+			want: 0, explain: "вход пропущен"},
+		{name: "above the maximum quantity is capped", sizing: config.SizingConfig{Mode: model.SizingFixedNotional, FixedNotional: 500},
+			change: func(r *model.SizingRequest) { r.Limits.MaxQuantity = 3 }, want: 3, explain: "максимальным объёмом"},
+		{name: "percent equity by default", want: 10},
+		{name: "percent equity with leverage and fee", sizing: config.SizingConfig{Mode: model.SizingPercentEquity, Leverage: 5, FeeRate: 0.001},
+			// 1000 / (1/5 + 0.001) = 4975.12 USDT
+			want: 49.75},
+		{name: "fixed fractional", sizing: config.SizingConfig{Mode: model.SizingFixedFractional, RiskPercent: 0.01, Leverage: 10}, want: 50},
+		{name: "fixed fractional with fees", sizing: config.SizingConfig{Mode: model.SizingFixedFractional, RiskPercent: 0.01, Leverage: 10, FeeRate: 0.001},
+			// 100 / (2 + 202*0.001)
+			want: 45.41},
+		{name: "fixed fractional capped by margin", sizing: config.SizingConfig{Mode: model.SizingFixedFractional, RiskPercent: 0.01},
+			want: 10, explain: "ограничено маржой"},
+		{name: "fixed fractional without a stop", sizing: config.SizingConfig{Mode: model.SizingFixedFractional, RiskPercent: 0.01},
+			change: func(r *model.SizingRequest) { r.StopLoss = 0 }, wantErr: true},
+		{name: "atr", sizing: config.SizingConfig{Mode: model.SizingATR, RiskPercent: 0.01, ATRMultiplier: 2, Leverage: 10},
+			change: func(r *model.SizingRequest) { r.ATR = 1.5 }, want: 33.33},
+		{name: "atr without ATR", sizing: config.SizingConfig{Mode: model.SizingATR, RiskPercent: 0.01, ATRMultiplier: 2}, wantErr: true},
+		{name: "kelly capped", sizing: config.SizingConfig{Mode: model.SizingKelly, KellyWinRate: 0.6, KellyPayoff: 2, KellyFraction: 0.5, KellyCap: 0.01, Leverage: 10},
+			// Келли 0.4 x 0.5 = 0.2, предел 1% капитала: 100 / 2
+			want: 50},
+		{name: "kelly fraction", sizing: config.SizingConfig{Mode: model.SizingKelly, KellyWinRate: 0.55, KellyPayoff: 1, KellyFraction: 0.05, KellyCap: 0.1, Leverage: 10},
+			// Келли 0.1 x 0.05 = 0.5% капитала: 50 / 2
+			want: 25},
+		{name: "kelly without an edge", sizing: config.SizingConfig{Mode: model.SizingKelly, KellyWinRate: 0.3, KellyPayoff: 1, KellyFraction: 0.5, KellyCap: 0.1},
+			want: 0, explain: "преимущества нет"},
+		{name: "kelly at zero edge", sizing: config.SizingConfig{Mode: model.SizingKelly, KellyWinRate: 0.5, KellyPayoff: 1, KellyFraction: 0.5, KellyCap: 0.1},
+			want: 0, explain: "преимущества нет"},
+		{name: "kelly without a stop", sizing: config.SizingConfig{Mode: model.SizingKelly, KellyWinRate: 0.6, KellyPayoff: 2},
+			change: func(r *model.SizingRequest) { r.StopLoss = 0 }, wantErr: true},
+		{name: "unknown mode", sizing: config.SizingConfig{Mode: "martingale"}, wantErr: true},
+		{name: "invalid entry price", change: func(r *model.SizingRequest) { r.EntryPrice = 0 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := &PriceCalculator{Balance: fixedBalance(10000), Sizing: tt.sizing}
+			req := request
+			if tt.change != nil {
+				tt.change(&req)
+			}
+			res, err := pc.CalculateQuantity(req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CalculateQuantity = %+v, want an error", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CalculateQuantity: %v", err)
+			}
+			if math.Abs(res.Quantity-tt.want) > 1e-9 {
+				t.Fatalf("quantity %v, want %v (%s)", res.Quantity, tt.want, res.Explanation)
+			}
+			if math.Abs(res.Notional-res.Quantity*req.EntryPrice) > 1e-9 {
+				t.Fatalf("notional %v for quantity %v", res.Notional, res.Quantity)
+			}
+			if tt.explain != "" && !strings.Contains(res.Explanation, tt.explain) {
+				t.Fatalf("explanation %q does not mention %q", res.Explanation, tt.explain)
+			}
+		})
+	}
+}
+
+func TestCalculateQuantityRiskAmount(t *testing.T) {
+	pc := &PriceCalculator{
+		Balance: fixedBalance(10000),
+		Sizing:  config.SizingConfig{Mode: model.SizingFixedFractional, RiskPercent: 0.01, Leverage: 10, FeeRate: 0.001},
+	}
+	res, err := pc.CalculateQuantity(model.SizingRequest{Symbol: "BTCUSDT", Side: "short", EntryPrice: 100, StopLoss: 102,
+		Limits: model.TradeLimits{MinQuantity: 0.01, StepSize: 0.01}})
+	if err != nil {
+		t.Fatalf("CalculateQuantity: %v", err)
+	}
+	// Объём округлён вниз, поэтому риск не превышает 1% капитала.
+	if want := res.Quantity * (2 + 202*0.001); math.Abs(res.RiskAmount-want) > 1e-9 || res.RiskAmount > 100 {
+		t.Fatalf("risk %v, want %v within 100 USDT", res.RiskAmount, want)
+	}
+}
@@ -2,8 +2,12 @@ package exchange
 
 import (
 	"bybit-bot/internal/model"
+	"fmt"
+	"github.com/markcheno/go-talib"
 	"github.com/thrasher-corp/gocryptotrader/exchanges/order"
 	"log"
+	"math"
+	"strings"
 )
 
 const (
@@ -12,6 +16,7 @@ const (
 	riskRewardRatio = 2.0
 	minSLPercent    = 0.001
 	maxSLPercent    = 0.01
+	swingLookback   = 10
 
 	takerFee = 0.0010  // 0.10 %
 	makerFee = 0.00036 // 0.036 %
@@ -41,35 +46,184 @@ func RiskManagement(stopLoss, takeProfit float64) order.RiskManagementModes {
 
 // CalculateSLTP рассчитывает скорректированные SL и TP с учётом комиссий
 func CalculateSLTP(side string, entry float64, klines []model.KlineData) (sl, tp float64) {
-	const (
-		slPercent = 0.006 // 0.6%
-		tpPercent = 0.015 // 1.5%
-	)
+	return CalculateSLTPWithParams(side, entry, klines, model.DefaultSLTPParams())
+}
 
-	var entryNet float64
-	if side == "long" {
-		entryNet = entry * (1 + takerFee)
+// CalculateSLTPWithParams — CalculateSLTP с заданной политикой SL/TP.
+func CalculateSLTPWithParams(side string, entry float64, klines []model.KlineData, p model.SLTPParams) (sl, tp float64) {
+	r := PlanSLTP(side, entry, klines, p)
+	return r.StopLoss, r.TakeProfit
+}
+
+// PlanSLTP рассчитывает SL и TP по политике p.Mode и возвращает их с обоснованием.
+// fixed_percent сохраняет прежний расчёт (feeAdjustedLevels); в остальных режимах SL стоит
+// на рассчитанном уровне, а TP отодвигается так, чтобы R:R после комиссий был равен
+// RiskReward (netRiskRewardLevels). Если ATR или свечей недостаточно, atr и structure
+// переходят на фиксированное расстояние StopLossPercent. Нулевые параметры заменяются
+// значениями по умолчанию пакета.
+func PlanSLTP(side string, entry float64, klines []model.KlineData, p model.SLTPParams) model.SLTPResult {
+	p = withSLTPDefaults(p)
+	res := model.SLTPResult{Mode: p.Mode}
+
+	slPercent := p.StopLossPercent
+	tpPercent := p.TakeProfitPercent
+	var reason string
+	switch p.Mode {
+	case model.SLTPFixedPercent:
+		reason = fmt.Sprintf("SL %.3f%%, TP %.3f%%", slPercent*100, tpPercent*100)
+	case model.SLTPRiskReward:
+		tpPercent = slPercent * p.RiskReward
+		reason = fmt.Sprintf("SL %.3f%%, TP %.1fR", slPercent*100, p.RiskReward)
+	case model.SLTPATR:
+		if atr := LastATR(klines, p.ATRPeriod); atr > 0 && entry > 0 {
+			raw := atr * p.ATRMultiplier / entry
+			slPercent = clamp(raw, p.MinSLPercent, p.MaxSLPercent)
+			reason = fmt.Sprintf("ATR(%d)=%.4f x %.2f = %.3f%%%s", p.ATRPeriod, atr, p.ATRMultiplier, raw*100,
+				clampNote(raw, slPercent))
+		} else {
+			reason = fmt.Sprintf("ATR(%d) недоступен, SL %.3f%%", p.ATRPeriod, slPercent*100)
+		}
+		tpPercent = slPercent * p.RiskReward
+		reason += fmt.Sprintf(", TP %.1fR", p.RiskReward)
+	case model.SLTPStructure:
+		if level, ok := swingLevel(side, klines, p.SwingLookback); ok && entry > 0 {
+			var raw float64
+			if side == "short" {
+				level *= 1 + p.SwingBuffer
+				raw = (level - entry) / entry
+			} else {
+				level *= 1 - p.SwingBuffer
+				raw = (entry - level) / entry
+			}
+			slPercent = clamp(raw, p.MinSLPercent, p.MaxSLPercent)
+			reason = fmt.Sprintf("экстремум %d свечей с отступом %.3f%%: %.8f (%.3f%%)%s",
+				p.SwingLookback, p.SwingBuffer*100, level, raw*100, clampNote(raw, slPercent))
+		} else {
+			reason = fmt.Sprintf("экстремум %d свечей недоступен, SL %.3f%%", p.SwingLookback, slPercent*100)
+		}
+		tpPercent = slPercent * p.RiskReward
+		reason += fmt.Sprintf(", TP %.1fR", p.RiskReward)
+	}
+	res.StopPercent = slPercent
+
+	if side != "long" && side != "short" {
+		res.StopLoss, res.TakeProfit = entry, entry
+		res.Rationale = fmt.Sprintf("неизвестный side=%q", side)
+		log.Printf("[RM] Неизвестный side='%s'", side)
+		return res
+	}
+	if p.Mode == model.SLTPFixedPercent {
+		res.StopLoss, res.TakeProfit = feeAdjustedLevels(side, entry, slPercent, tpPercent)
 	} else {
-		entryNet = entry * (1 - takerFee)
+		res.StopLoss, res.TakeProfit = netRiskRewardLevels(side, entry, slPercent, p.RiskReward)
 	}
 
-	switch side {
-	case "long":
-		sl = entryNet * (1 - slPercent) / (1 - makerFee)
-		tp = entryNet * (1 + tpPercent) / (1 - makerFee)
-		log.Printf("[LONG] entry=%.8f, entryNet=%.8f, SL=%.8f, TP=%.8f", entry, entryNet, sl, tp)
+	// Итог сделки с комиссией тейкера на входе и мейкера на выходе.
+	reward := math.Abs(res.TakeProfit-entry) - entry*takerFee - res.TakeProfit*makerFee
+	risk := math.Abs(entry-res.StopLoss) + entry*takerFee + res.StopLoss*makerFee
+	if risk > 0 {
+		res.NetRiskReward = reward / risk
+	}
+	res.Rationale = fmt.Sprintf("%s: %s; с комиссиями R:R %.2f", p.Mode, reason, res.NetRiskReward)
+	log.Printf("[%s] entry=%.8f, SL=%.8f, TP=%.8f (%s)",
+		strings.ToUpper(side), entry, res.StopLoss, res.TakeProfit, res.Rationale)
+	return res
+}
 
-	case "short":
-		sl = entryNet * (1 + slPercent) / (1 - makerFee)
-		tp = entryNet * (1 - tpPercent) / (1 - makerFee)
-		log.Printf("[SHORT] entry=%.8f, entryNet=%.8f, SL=%.8f, TP=%.8f", entry, entryNet, sl, tp)
+// feeAdjustedLevels откладывает расстояния SL и TP от цены входа с комиссией тейкера
+// и пересчитывает их с учётом комиссии мейкера на выходе.
+func feeAdjustedLevels(side string, entry, slPercent, tpPercent float64) (sl, tp float64) {
+	if side == "long" {
+		entryNet := entry * (1 + takerFee)
+		return entryNet * (1 - slPercent) / (1 - makerFee), entryNet * (1 + tpPercent) / (1 - makerFee)
+	}
+	entryNet := entry * (1 - takerFee)
+	return entryNet * (1 + slPercent) / (1 - makerFee), entryNet * (1 - tpPercent) / (1 - makerFee)
+}
 
-	default:
-		sl, tp = entry, entry
-		log.Printf("[RM] Неизвестный side='%s'", side)
+// netRiskRewardLevels ставит SL точно на расстоянии slPercent от входа, а TP — так, чтобы
+// прибыль после комиссий входа и выхода была ровно riskReward убытков по стопу с комиссиями.
+func netRiskRewardLevels(side string, entry, slPercent, riskReward float64) (sl, tp float64) {
+	if side == "long" {
+		sl = entry * (1 - slPercent)
+		risk := entry - sl + entry*takerFee + sl*makerFee
+		return sl, (entry*(1+takerFee) + riskReward*risk) / (1 - makerFee)
+	}
+	sl = entry * (1 + slPercent)
+	risk := sl - entry + entry*takerFee + sl*makerFee
+	return sl, (entry*(1-takerFee) - riskReward*risk) / (1 + makerFee)
+}
+
+func withSLTPDefaults(p model.SLTPParams) model.SLTPParams {
+	if p.Mode == "" {
+		p.Mode = model.SLTPFixedPercent
+	}
+	if p.RiskReward <= 0 {
+		p.RiskReward = riskRewardRatio
+	}
+	if p.ATRPeriod <= 0 {
+		p.ATRPeriod = atrPeriod
+	}
+	if p.ATRMultiplier <= 0 {
+		p.ATRMultiplier = stopLossATRMul
+	}
+	if p.MinSLPercent <= 0 {
+		p.MinSLPercent = minSLPercent
+	}
+	if p.MaxSLPercent <= 0 {
+		p.MaxSLPercent = maxSLPercent
+	}
+	if p.SwingLookback <= 0 {
+		p.SwingLookback = swingLookback
+	}
+	return p
+}
+
+// swingLevel возвращает минимум Low (long) или максимум High (short) последних lookback свечей.
+func swingLevel(side string, klines []model.KlineData, lookback int) (float64, bool) {
+	if len(klines) < lookback || lookback <= 0 {
+		return 0, false
 	}
+	window := klines[len(klines)-lookback:]
+	level := window[0].Low
+	if side == "short" {
+		level = window[0].High
+	}
+	for _, k := range window[1:] {
+		if side == "short" {
+			level = math.Max(level, k.High)
+		} else {
+			level = math.Min(level, k.Low)
+		}
+	}
+	return level, level > 0
+}
+
+func clampNote(raw, clamped float64) string {
+	if raw == clamped {
+		return ""
+	}
+	return fmt.Sprintf(", ограничено до %.3f%%", clamped*100)
+}
 
-	return
+// LastATR возвращает последнее значение ATR(period) по свечам или 0, если свечей недостаточно.
+func LastATR(klines []model.KlineData, period int) float64 {
+	if period <= 0 || len(klines) <= period {
+		return 0
+	}
+	highs := make([]float64, len(klines))
+	lows := make([]float64, len(klines))
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		highs[i] = k.High
+		lows[i] = k.Low
+		closes[i] = k.Close
+	}
+	atr := talib.Atr(highs, lows, closes, period)
+	if len(atr) == 0 {
+		return 0
+	}
+	return atr[len(atr)-1]
 }
 
 func clamp(x, min, max float64) float64 {
@@ -18,8 +18,8 @@ type MarketDataService struct {
 	client *bybit_connector.Client
 }
 
-func NewMarketDataService() *MarketDataService {
-	client := bybit_connector.NewBybitHttpClient("Cv6vQhpZDnSFROonKx", "aIJarBdglaBBDx7VHFFW9x0lKWEF4ez7mupL", bybit_connector.WithBaseURL("/api/v1/wallet-balance"))
+func NewMarketDataService(apiKey, apiSecret string) *MarketDataService {
+	client := bybit_connector.NewBybitHttpClient(apiKey, apiSecret, bybit_connector.WithBaseURL("/api/v1/wallet-balance"))
 	return &MarketDataService{client: client}
 }
 
@@ -1,35 +1,65 @@
 package marketdata
 
 import (
-	"bybit-bot/internal/client"
+	"bybit-bot/internal/interfaces"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/repository"
-	"log"
+	"bybit-bot/internal/service/event"
+	"fmt"
 	"time"
 )
 
 type ByBitExecutor struct {
-	API  *client.ByBit
-	Repo repository.OrderRepository
+	API             interfaces.Exchange
+	Repo            repository.OrderRepository
+	PrivateListener *event.PrivateListener // необязателен: при наличии открытые ордера берутся из БД
 }
 
-func (e *ByBitExecutor) PlaceLimitOrder(symbol, side string, price, qty, stopLoss, takeProfit float64) error {
-	resp, err := e.API.CreateOrderViaPlaceOrderFuture(symbol, side, "limit", price, qty, stopLoss, takeProfit)
+func (e *ByBitExecutor) PlaceLimitOrder(symbol, side string, price, qty, stopLoss, takeProfit float64) (string, error) {
+	orderID, err := e.API.PlaceOrder(symbol, side, "limit", price, qty, stopLoss, takeProfit)
 	if err != nil {
-		log.Printf("Ошибка размещения %s ордера для %s: %v, с ценой %f", side, symbol, err, price)
-		return nil
+		return "", fmt.Errorf("place %s order for %s at %f: %w", side, symbol, price, err)
 	}
 	rec := &model.Order{
-		OrderID:   resp.OrderID,
-		Symbol:    symbol,
-		Side:      side,
-		OrderType: "limit",
-		Price:     price,
-		Quantity:  qty,
-		StopLoss:  stopLoss,
-		Status:    "open",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		OrderID:    orderID,
+		Symbol:     symbol,
+		Side:       side,
+		OrderType:  "limit",
+		Price:      price,
+		Quantity:   qty,
+		StopLoss:   stopLoss,
+		TakeProfit: takeProfit,
+		Status:     model.OrderStatusOpen,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
-	return e.Repo.InsertOrder(rec)
+	return orderID, e.Repo.InsertOrder(rec)
+}
+
+// CountOpenOrders возвращает число открытых ордеров по символу. Если приватный поток активен,
+// статусы в БД актуальны и REST-запрос не нужен; открытая позиция считается как ордер,
+// так же как её условные TP/SL ордера в ответе REST.
+func (e *ByBitExecutor) CountOpenOrders(category, symbol string) (int, error) {
+	if e.PrivateListener != nil && e.PrivateListener.IsHealthy(0) {
+		orders, err := e.Repo.FindOrdersBySymbol(symbol)
+		if err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, o := range orders {
+			if o.Status == model.OrderStatusOpen || o.Status == model.OrderStatusPartiallyFilled {
+				count++
+			}
+		}
+		if pos, ok := e.PrivateListener.GetPosition(symbol); ok && pos.Size.Float64() > 0 {
+			count++
+		}
+		return count, nil
+	}
+
+	openOrders, err := e.API.ListOpenOrders(category, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return len(openOrders), nil
 }
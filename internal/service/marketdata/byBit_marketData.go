@@ -1,15 +1,16 @@
 package marketdata
 
 import (
-	"bybit-bot/internal/client"
+	"bybit-bot/internal/interfaces"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/service/event"
 	"log"
+	"sort"
 	"time"
 )
 
 type ByBitMarketData struct {
-	Client     *client.ByBit
+	Client     interfaces.Exchange
 	WSListener *event.WSListener
 }
 
@@ -41,7 +42,19 @@ func (m *ByBitMarketData) GetRecentKlines(symbol, interval string, required int)
 		return nil, false
 	}
 
-	closed := raw[:len(raw)-1]
+	// Биржа отдаёт свечи от новых к старым, и самая новая обычно ещё формируется:
+	// стратегии получают только закрытые свечи по возрастанию времени.
+	sort.Slice(raw, func(i, j int) bool { return raw[i].Start < raw[j].Start })
+	closed := make([]model.KlineData, 0, len(raw))
+	for _, k := range raw {
+		if !k.Confirm {
+			continue
+		}
+		if k.Symbol == "" {
+			k.Symbol = symbol
+		}
+		closed = append(closed, k)
+	}
 	if len(closed) < required {
 		log.Printf("[Маркет-данные] ПРЕДУПРЕЖДЕНИЕ: недостаточно закрытых свечей, есть: %d, требуется: %d", len(closed), required)
 		return nil, false
@@ -49,10 +62,6 @@ func (m *ByBitMarketData) GetRecentKlines(symbol, interval string, required int)
 
 	bars := closed[len(closed)-required:]
 
-	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
-		bars[i], bars[j] = bars[j], bars[i]
-	}
-
 	if len(bars) > 0 {
 		firstTime := time.Unix(bars[0].Start, 0).Format("2006-01-02 15:04")
 		lastTime := time.Unix(bars[len(bars)-1].Start, 0).Format("2006-01-02 15:04")
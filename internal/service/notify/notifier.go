@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notifier доставляет оператору важные события бота.
+type Notifier interface {
+	Notify(text string)
+}
+
+// Log пишет уведомления в журнал.
+type Log struct{}
+
+func (Log) Notify(text string) {
+	log.Printf("[Уведомление] %s", text)
+}
+
+// Webhook отправляет уведомления POST-запросом с JSON {"text": ...} (Slack, Mattermost,
+// прокси для Telegram и т.п.) и дублирует их в журнал. Отправка асинхронная, чтобы
+// уведомление не задерживало вызывающего; ошибки доставки только логируются.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *Webhook) Notify(text string) {
+	Log{}.Notify(text)
+	go func() {
+		if err := w.send(text); err != nil {
+			log.Printf("[Уведомление] Ошибка отправки webhook: %v", err)
+		}
+	}()
+}
+
+func (w *Webhook) send(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package orders
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/event"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthChecker — источник обновлений ордеров (приватный поток).
+type healthChecker interface {
+	IsHealthy(maxAge time.Duration) bool
+}
+
+// tracked — данные сопровождения ордера, которые не хранятся в таблице orders.
+type tracked struct {
+	placedAt time.Time // время размещения или последней перестановки
+	reprices int
+}
+
+// Manager ведёт ордера бота по жизненному циклу open -> partially_filled -> filled/cancelled/rejected
+// и сохраняет каждый переход через OrderRepository.UpdateOrder. Обновления приходят из приватного потока
+// (OnOrder), а при его недоступности статусы запрашиваются через REST. Лимитные ордера, не исполненные
+// за Policy.Timeout или от которых лучшая цена ушла дальше Policy.MaxPriceDrift, переставляются
+// на лучшую цену стакана или отменяются.
+type Manager struct {
+	API       interfaces.OrderAmender
+	Repo      repository.OrderRepository
+	Orderbook interfaces.OrderbookSource // необязателен: без него перестановка и проверка ухода цены невозможны
+	Stream    healthChecker              // необязателен: без него статусы всегда сверяются через REST
+	Category  string
+	Symbols   []string
+	Policy    config.OrdersConfig
+
+	mu      sync.Mutex
+	tracked map[string]*tracked
+	now     func() time.Time
+}
+
+func NewManager(api interfaces.OrderAmender, repo repository.OrderRepository, category string, symbols []string, policy config.OrdersConfig) *Manager {
+	return &Manager{
+		API:      api,
+		Repo:     repo,
+		Category: category,
+		Symbols:  symbols,
+		Policy:   policy,
+		tracked:  make(map[string]*tracked),
+		now:      time.Now,
+	}
+}
+
+// Run проверяет активные ордера с периодом Policy.CheckInterval до отмены ctx.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.Policy.CheckInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// OnOrder применяет обновление ордера из приватного потока. Ордера, созданные не ботом
+// (например, сработавшие SL/TP), в таблице отсутствуют и пропускаются.
+func (m *Manager) OnOrder(u model.OrderUpdate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, err := m.Repo.FindOrderByID(u.OrderID)
+	if err != nil {
+		log.Printf("[Ордера] Ошибка чтения ордера %s: %v", u.OrderID, err)
+		return
+	}
+	if order == nil {
+		return
+	}
+	if err := m.apply(order, u); err != nil {
+		log.Printf("[Ордера] Ошибка сохранения ордера %s: %v", u.OrderID, err)
+	}
+}
+
+// Check один раз проходит по активным ордерам всех символов.
+func (m *Manager) Check() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	streamOK := m.Stream != nil && m.Stream.IsHealthy(0)
+	for _, symbol := range m.Symbols {
+		orders, err := m.Repo.FindOrdersBySymbol(symbol)
+		if err != nil {
+			log.Printf("[Ордера] Ошибка чтения ордеров %s: %v", symbol, err)
+			continue
+		}
+		for _, order := range orders {
+			if !model.IsActiveOrderStatus(order.Status) {
+				continue
+			}
+			if !streamOK && !m.refresh(order) {
+				continue
+			}
+			if model.IsActiveOrderStatus(order.Status) {
+				m.enforce(order)
+			}
+		}
+	}
+}
+
+// refresh сверяет статус ордера с биржей. Возвращает false, если состояние неизвестно.
+func (m *Manager) refresh(order *model.Order) bool {
+	u, err := m.API.GetOrderStatus(m.Category, order.Symbol, order.OrderID)
+	if err != nil {
+		log.Printf("[Ордера] Ошибка запроса статуса %s: %v", order.OrderID, err)
+		return false
+	}
+	if u == nil {
+		log.Printf("[Ордера] Ордер %s %s не найден на бирже", order.OrderID, order.Symbol)
+		return false
+	}
+	if err := m.apply(order, *u); err != nil {
+		log.Printf("[Ордера] Ошибка сохранения ордера %s: %v", order.OrderID, err)
+	}
+	return true
+}
+
+// apply переводит ордер в статус из обновления биржи, если такой переход допустим.
+func (m *Manager) apply(order *model.Order, u model.OrderUpdate) error {
+	status := model.OrderStatusFromBybit(u.OrderStatus)
+	changed := false
+	if status != "" && model.CanTransitionOrder(order.Status, status) {
+		log.Printf("[Ордера] %s %s: %s -> %s (исполнено %v из %v)",
+			order.OrderID, order.Symbol, order.Status, status, u.CumExecQty, u.Qty)
+		order.Status = status
+		changed = true
+	}
+	if price := u.Price.Float64(); price > 0 && price != order.Price && model.IsActiveOrderStatus(order.Status) {
+		order.Price = price
+		changed = true
+	}
+	if u.StopLoss > 0 && u.StopLoss.Float64() != order.StopLoss {
+		order.StopLoss = u.StopLoss.Float64()
+		changed = true
+	}
+	if u.TakeProfit > 0 && u.TakeProfit.Float64() != order.TakeProfit {
+		order.TakeProfit = u.TakeProfit.Float64()
+		changed = true
+	}
+	if model.IsFinalOrderStatus(order.Status) {
+		delete(m.tracked, order.OrderID)
+	}
+	if !changed {
+		return nil
+	}
+	return m.Repo.UpdateOrder(order)
+}
+
+// enforce переставляет или отменяет ордер, если истёк таймаут или цена ушла от лимита.
+func (m *Manager) enforce(order *model.Order) {
+	t, ok := m.tracked[order.OrderID]
+	if !ok {
+		t = &tracked{placedAt: order.CreatedAt}
+		m.tracked[order.OrderID] = t
+	}
+	now := m.now()
+
+	best, haveBook := m.bestPrice(order)
+	expired := m.Policy.Timeout.Duration > 0 && now.Sub(t.placedAt) >= m.Policy.Timeout.Duration
+	drifted := haveBook && m.Policy.MaxPriceDrift > 0 && drift(order, best) > m.Policy.MaxPriceDrift
+	if !expired && !drifted {
+		return
+	}
+	reason := "таймаут"
+	if drifted {
+		reason = "уход цены"
+	}
+
+	if m.Policy.Action == config.OrderActionReprice && haveBook && t.reprices < m.Policy.MaxReprices && best != order.Price {
+		if err := m.API.AmendOrder(m.Category, order.Symbol, order.OrderID, best, 0); err != nil {
+			log.Printf("[Ордера] Не удалось переставить %s (%s): %v", order.OrderID, reason, err)
+			m.refresh(order)
+			return
+		}
+		log.Printf("[Ордера] %s %s переставлен (%s): %.8f -> %.8f, перестановка %d из %d",
+			order.OrderID, order.Symbol, reason, order.Price, best, t.reprices+1, m.Policy.MaxReprices)
+		order.Price = best
+		t.placedAt = now
+		t.reprices++
+		if err := m.Repo.UpdateOrder(order); err != nil {
+			log.Printf("[Ордера] Ошибка сохранения ордера %s: %v", order.OrderID, err)
+		}
+		return
+	}
+
+	if err := m.API.CancelOrder(m.Category, order.Symbol, order.OrderID); err != nil {
+		// Ордер мог исполниться между проверкой и отменой: состояние уточняется на бирже.
+		log.Printf("[Ордера] Не удалось отменить %s (%s): %v", order.OrderID, reason, err)
+		m.refresh(order)
+		return
+	}
+	log.Printf("[Ордера] %s %s отменён (%s): %s -> %s", order.OrderID, order.Symbol, reason, order.Status, model.OrderStatusCancelled)
+	order.Status = model.OrderStatusCancelled
+	delete(m.tracked, order.OrderID)
+	if err := m.Repo.UpdateOrder(order); err != nil {
+		log.Printf("[Ордера] Ошибка сохранения ордера %s: %v", order.OrderID, err)
+	}
+}
+
+// bestPrice возвращает лучшую цену своей стороны стакана: bid для покупки, ask для продажи.
+func (m *Manager) bestPrice(order *model.Order) (float64, bool) {
+	if m.Orderbook == nil {
+		return 0, false
+	}
+	ob, ok := m.Orderbook.GetOrderbookByTopic(event.OrderbookTopic(order.Symbol))
+	if !ok || ob == nil {
+		return 0, false
+	}
+	if isBuy(order.Side) {
+		if len(ob.Bids) == 0 {
+			return 0, false
+		}
+		return ob.Bids[0].Price, true
+	}
+	if len(ob.Asks) == 0 {
+		return 0, false
+	}
+	return ob.Asks[0].Price, true
+}
+
+// drift — доля, на которую лучшая цена ушла от лимита в неблагоприятную сторону:
+// вверх для покупки, вниз для продажи.
+func drift(order *model.Order, best float64) float64 {
+	if order.Price <= 0 {
+		return 0
+	}
+	if isBuy(order.Side) {
+		return (best - order.Price) / order.Price
+	}
+	return (order.Price - best) / order.Price
+}
+
+func isBuy(side string) bool {
+	return strings.EqualFold(side, "buy")
+}
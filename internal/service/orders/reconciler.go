@@ -0,0 +1,251 @@
+package orders
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/positions"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// Виды расхождений между БД и биржей.
+const (
+	DiscrepancyStaleStatus      = "stale_status"        // локальный статус отстал от биржи
+	DiscrepancyMissingOrder     = "missing_on_exchange" // активный локально ордер не найден на бирже
+	DiscrepancyOrphanOrder      = "orphan_order"        // ордер на бирже, которого нет в БД
+	DiscrepancyOrphanPosition   = "orphan_position"     // позиция на бирже, которой нет локально
+	DiscrepancyStalePosition    = "stale_position"      // локальная позиция, которой уже нет на бирже
+	DiscrepancyPositionMismatch = "position_mismatch"   // сторона или объём позиции различаются
+)
+
+// Действия, выполненные по расхождению.
+const (
+	ActionFixed   = "fixed"
+	ActionAdopted = "adopted"
+	ActionClosed  = "closed"
+	ActionFlagged = "flagged" // требует ручной проверки
+)
+
+// ExchangeState — состояние аккаунта на бирже (client.ByBit).
+type ExchangeState interface {
+	ListOpenOrders(category, symbol string) ([]model.OrderUpdate, error)
+	GetOrderStatus(category, symbol, orderID string) (*model.OrderUpdate, error)
+	GetPositions(category, symbol string) ([]model.PositionUpdate, error)
+	GetExecutions(category, symbol string, since time.Time) ([]model.ExecutionUpdate, error)
+}
+
+// Discrepancy — одно найденное расхождение.
+type Discrepancy struct {
+	Kind     string `json:"kind"`
+	Symbol   string `json:"symbol"`
+	OrderID  string `json:"order_id,omitempty"`
+	Local    string `json:"local"`
+	Exchange string `json:"exchange"`
+	Action   string `json:"action"`
+}
+
+// ReconcileReport — итог одной сверки.
+type ReconcileReport struct {
+	StartedAt     time.Time     `json:"started_at"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Errors        []string      `json:"errors"`
+}
+
+func (r *ReconcileReport) add(d Discrepancy) {
+	r.Discrepancies = append(r.Discrepancies, d)
+	log.Printf("[Сверка] %s %s %s: локально %q, на бирже %q -> %s", d.Kind, d.Symbol, d.OrderID, d.Local, d.Exchange, d.Action)
+}
+
+func (r *ReconcileReport) fail(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	r.Errors = append(r.Errors, msg)
+	log.Printf("[Сверка] Ошибка: %s", msg)
+}
+
+// Reconciler сверяет ордера и позиции в БД с биржей: исправляет отставшие статусы через Manager,
+// догружает исполнения в positions.Service, принимает (AdoptOrphans) или помечает ордера
+// и позиции, о которых бот не знает. Условные TP/SL ордера биржи не сверяются.
+// Принятые ордера дальше сопровождаются Manager наравне с собственными.
+type Reconciler struct {
+	API          ExchangeState
+	Orders       *Manager
+	Repo         repository.OrderRepository
+	Positions    *positions.Service // необязателен: без него исполнения и позиции не сверяются
+	Category     string
+	Symbols      []string
+	AdoptOrphans bool
+	Interval     time.Duration // период сверки в Run; 0 — только однократно при запуске
+}
+
+// Run повторяет сверку с периодом Interval до отмены ctx. Сверку при запуске выполняет Reconcile.
+func (r *Reconciler) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			r.Reconcile()
+		}
+	}
+}
+
+// Reconcile выполняет одну сверку по всем символам.
+func (r *Reconciler) Reconcile() *ReconcileReport {
+	report := &ReconcileReport{StartedAt: time.Now()}
+	for _, symbol := range r.Symbols {
+		r.reconcileOrders(report, symbol)
+		if r.Positions != nil {
+			if err := r.Positions.Sync(r.API, r.Category, symbol); err != nil {
+				report.fail("executions %s: %v", symbol, err)
+			}
+			r.reconcilePosition(report, symbol)
+		}
+	}
+	report.FinishedAt = time.Now()
+	log.Printf("[Сверка] Завершена за %s: расхождений %d, ошибок %d",
+		report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond), len(report.Discrepancies), len(report.Errors))
+	return report
+}
+
+func (r *Reconciler) reconcileOrders(report *ReconcileReport, symbol string) {
+	exchangeOrders, err := r.API.ListOpenOrders(r.Category, symbol)
+	if err != nil {
+		report.fail("open orders %s: %v", symbol, err)
+		return
+	}
+	open := make(map[string]model.OrderUpdate, len(exchangeOrders))
+	for _, u := range exchangeOrders {
+		if u.StopOrderType == "" {
+			open[u.OrderID] = u
+		}
+	}
+
+	local, err := r.Repo.FindOrdersBySymbol(symbol)
+	if err != nil {
+		report.fail("local orders %s: %v", symbol, err)
+		return
+	}
+	known := make(map[string]bool, len(local))
+	for _, order := range local {
+		known[order.OrderID] = true
+		if !model.IsActiveOrderStatus(order.Status) {
+			continue
+		}
+		u, ok := open[order.OrderID]
+		if !ok {
+			found, err := r.API.GetOrderStatus(r.Category, symbol, order.OrderID)
+			if err != nil {
+				report.fail("order %s: %v", order.OrderID, err)
+				continue
+			}
+			if found == nil {
+				report.add(Discrepancy{Kind: DiscrepancyMissingOrder, Symbol: symbol, OrderID: order.OrderID,
+					Local: order.Status, Action: ActionFlagged})
+				continue
+			}
+			u = *found
+		}
+		status := model.OrderStatusFromBybit(u.OrderStatus)
+		if status == "" || status == order.Status {
+			continue
+		}
+		r.Orders.OnOrder(u)
+		report.add(Discrepancy{Kind: DiscrepancyStaleStatus, Symbol: symbol, OrderID: order.OrderID,
+			Local: order.Status, Exchange: u.OrderStatus, Action: ActionFixed})
+	}
+
+	for id, u := range open {
+		if known[id] {
+			continue
+		}
+		d := Discrepancy{Kind: DiscrepancyOrphanOrder, Symbol: symbol, OrderID: id,
+			Exchange: fmt.Sprintf("%s %s %v @ %v", u.OrderStatus, u.Side, u.Qty, u.Price), Action: ActionFlagged}
+		if r.AdoptOrphans && !u.ReduceOnly {
+			if err := r.Repo.InsertOrder(&model.Order{
+				OrderID:    u.OrderID,
+				Symbol:     u.Symbol,
+				Side:       strings.ToLower(u.Side),
+				OrderType:  strings.ToLower(u.OrderType),
+				Price:      u.Price.Float64(),
+				Quantity:   u.Qty.Float64(),
+				StopLoss:   u.StopLoss.Float64(),
+				TakeProfit: u.TakeProfit.Float64(),
+				Status:     model.OrderStatusFromBybit(u.OrderStatus),
+			}); err != nil {
+				report.fail("adopt order %s: %v", id, err)
+			} else {
+				d.Action = ActionAdopted
+			}
+		}
+		report.add(d)
+	}
+}
+
+func (r *Reconciler) reconcilePosition(report *ReconcileReport, symbol string) {
+	exchangePositions, err := r.API.GetPositions(r.Category, symbol)
+	if err != nil {
+		report.fail("positions %s: %v", symbol, err)
+		return
+	}
+	var ex *model.PositionUpdate
+	for i := range exchangePositions {
+		if exchangePositions[i].Symbol == symbol && exchangePositions[i].Size > 0 {
+			ex = &exchangePositions[i]
+			break
+		}
+	}
+	local, haveLocal := r.Positions.Position(symbol)
+
+	switch {
+	case ex == nil && !haveLocal:
+		return
+	case ex == nil:
+		price := local.EntryPrice
+		if open := r.Positions.OpenPositions(symbol); len(open) > 0 && open[0].MarkPrice > 0 {
+			price = open[0].MarkPrice
+		}
+		d := Discrepancy{Kind: DiscrepancyStalePosition, Symbol: symbol,
+			Local: fmt.Sprintf("%s %v @ %v", local.Side, local.Size, local.EntryPrice), Action: ActionClosed}
+		if err := r.Positions.ForceClose(symbol, price, time.Now()); err != nil {
+			report.fail("close position %s: %v", symbol, err)
+			d.Action = ActionFlagged
+		}
+		report.add(d)
+	case !haveLocal:
+		side := positionSide(ex.Side)
+		d := Discrepancy{Kind: DiscrepancyOrphanPosition, Symbol: symbol,
+			Exchange: fmt.Sprintf("%s %v @ %v", side, ex.Size, ex.EntryPrice), Action: ActionFlagged}
+		if r.AdoptOrphans {
+			if err := r.Positions.Adopt(symbol, side, ex.Size.Float64(), ex.EntryPrice.Float64(), time.Now()); err != nil {
+				report.fail("adopt position %s: %v", symbol, err)
+			} else {
+				d.Action = ActionAdopted
+			}
+		}
+		report.add(d)
+	default:
+		side := positionSide(ex.Side)
+		if side != local.Side || math.Abs(ex.Size.Float64()-local.Size) > 1e-9 {
+			report.add(Discrepancy{Kind: DiscrepancyPositionMismatch, Symbol: symbol,
+				Local:    fmt.Sprintf("%s %v @ %v", local.Side, local.Size, local.EntryPrice),
+				Exchange: fmt.Sprintf("%s %v @ %v", side, ex.Size, ex.EntryPrice), Action: ActionFlagged})
+		}
+	}
+}
+
+func positionSide(bybitSide string) string {
+	if strings.EqualFold(bybitSide, "sell") {
+		return "short"
+	}
+	return "long"
+}
@@ -0,0 +1,267 @@
+package paper
+
+import (
+	"bybit-bot/internal/client"
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/event"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	coin = "USDT"
+
+	// walletSnapshotInterval — как часто сохраняется снимок кошелька без изменения баланса,
+	// чтобы риск-контроль видел нереализованный PnL.
+	walletSnapshotInterval = time.Minute
+)
+
+// MarketData — публичные REST-данные биржи, которым не нужны ключи API (client.ByBit).
+type MarketData interface {
+	interfaces.LimitsProvider
+	GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error)
+	GetDepth(symbol, category string, limit int64) (*model.OrderbookData, error)
+	GetTickers(category, symbol string) ([]model.WSTickerPrice, error)
+}
+
+// WalletStore хранит снимки виртуального кошелька.
+type WalletStore interface {
+	GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error)
+	SaveWalletInfo(info *model.WalletInfoRep) error
+}
+
+// Exchange — бумажная торговля на живых данных. Ордера, позиции, комиссии и SL/TP ведёт
+// локальная client.Simulated, котировки для неё берутся из живого ордербука, а свечи,
+// тикеры и лимиты инструментов — из Market. Exchange реализует interfaces.Exchange и
+// interfaces.Executor, поэтому заменяет client.ByBit и ByBitExecutor без изменений в
+// стратегиях, роутере, риск-контроле и сопровождении.
+//
+// Баланс кошелька сохраняется в Wallet и восстанавливается при запуске; открытые ордера
+// и позиции живут только в памяти и при перезапуске теряются (сверка пометит их в БД).
+type Exchange struct {
+	*client.Simulated
+	Market    MarketData
+	Orderbook interfaces.OrderbookSource
+	Wallet    WalletStore
+	Repo      repository.OrderRepository
+	Category  string
+	Symbols   []string
+	Interval  time.Duration
+
+	// OnOrder и OnExecution получают обновления асинхронно из Run, как из приватного потока
+	// Bybit: обработчики могут вызывать методы Exchange, не рискуя взаимной блокировкой.
+	OnOrder     func(model.OrderUpdate)
+	OnExecution func(model.ExecutionUpdate)
+
+	mu           sync.Mutex
+	queue        []func()
+	wake         chan struct{}
+	lastBalance  float64
+	lastSnapshot time.Time
+}
+
+var (
+	_ interfaces.Exchange = (*Exchange)(nil)
+	_ interfaces.Executor = (*Exchange)(nil)
+)
+
+// New создаёт бумажную биржу. Баланс берётся из последнего снимка кошелька в Wallet,
+// а если его нет — из cfg.InitialBalance; лимиты символов загружаются из market.
+func New(market MarketData, orderbook interfaces.OrderbookSource, wallet WalletStore, repo repository.OrderRepository,
+	category string, symbols []string, cfg config.PaperConfig) (*Exchange, error) {
+	balance := cfg.InitialBalance
+	latest, err := wallet.GetLatestWalletInfo(coin)
+	if err != nil {
+		return nil, fmt.Errorf("load paper wallet: %w", err)
+	}
+	if latest != nil {
+		balance = latest.WalletBalance
+	}
+
+	sim := client.NewSimulated(balance)
+	sim.Category = category
+	sim.MakerFee = cfg.MakerFee
+	sim.TakerFee = cfg.TakerFee
+	sim.Leverage = cfg.Leverage
+	// Идентификаторы уникальны между запусками: ордера прошлых сессий остаются в БД.
+	sim.IDPrefix = fmt.Sprintf("paper-%d", time.Now().UnixMilli())
+	for _, symbol := range symbols {
+		limits, err := market.GetTradeLimitsViaInstruments(category, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("load trade limits for %s: %w", symbol, err)
+		}
+		sim.SetLimits(symbol, limits)
+	}
+
+	e := &Exchange{
+		Simulated: sim,
+		Market:    market,
+		Orderbook: orderbook,
+		Wallet:    wallet,
+		Repo:      repo,
+		Category:  category,
+		Symbols:   symbols,
+		Interval:  cfg.Interval.Duration,
+		wake:      make(chan struct{}, 1),
+	}
+	sim.OnOrder = func(u model.OrderUpdate) {
+		e.enqueue(func() {
+			if e.OnOrder != nil {
+				e.OnOrder(u)
+			}
+		})
+	}
+	sim.OnExecution = func(u model.ExecutionUpdate) {
+		e.enqueue(func() {
+			if e.OnExecution != nil {
+				e.OnExecution(u)
+			}
+		})
+	}
+	log.Printf("[Paper] Виртуальный кошелёк: %.2f %s, комиссии maker %.4f%%, taker %.4f%%",
+		balance, coin, cfg.MakerFee*100, cfg.TakerFee*100)
+	return e, nil
+}
+
+func (e *Exchange) enqueue(fn func()) {
+	e.mu.Lock()
+	e.queue = append(e.queue, fn)
+	e.mu.Unlock()
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Exchange) dispatch() {
+	e.mu.Lock()
+	queue := e.queue
+	e.queue = nil
+	e.mu.Unlock()
+	for _, fn := range queue {
+		fn()
+	}
+}
+
+// Run каждые Interval сопоставляет ордера с лучшими ценами ордербука, доставляет
+// обновления ордеров и исполнений и сохраняет снимки кошелька до отмены ctx.
+func (e *Exchange) Run(ctx context.Context) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	e.snapshot(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			e.dispatch()
+			return ctx.Err()
+		case <-e.wake:
+			e.dispatch()
+		case now := <-ticker.C:
+			e.Sync(now)
+			e.dispatch()
+		}
+	}
+}
+
+// Sync один раз передаёт симуляции котировки всех символов и сохраняет снимок кошелька.
+// Пока поток ордербука недоступен, котировки не обновляются и ордера не исполняются.
+func (e *Exchange) Sync(now time.Time) {
+	if e.Orderbook.IsHealthy(0) {
+		for _, symbol := range e.Symbols {
+			ob, ok := e.Orderbook.GetOrderbookByTopic(event.OrderbookTopic(symbol))
+			if !ok || len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+				continue
+			}
+			e.Quote(symbol, now, ob.Bids[0].Price, ob.Asks[0].Price)
+		}
+	}
+	e.snapshot(now)
+}
+
+// snapshot сохраняет кошелёк при изменении баланса и не реже walletSnapshotInterval.
+func (e *Exchange) snapshot(now time.Time) {
+	info, err := e.GetWalletInfo(coin)
+	if err != nil {
+		log.Printf("[Paper] Ошибка расчёта кошелька: %v", err)
+		return
+	}
+	if !e.lastSnapshot.IsZero() && info.WalletBalance == e.lastBalance && now.Sub(e.lastSnapshot) < walletSnapshotInterval {
+		return
+	}
+	info.RecordedAt, info.CreatedAt, info.UpdatedAt = now, now, now
+	if err := e.Wallet.SaveWalletInfo(info); err != nil {
+		log.Printf("[Paper] Ошибка сохранения кошелька: %v", err)
+		return
+	}
+	e.lastBalance = info.WalletBalance
+	e.lastSnapshot = now
+}
+
+func (e *Exchange) GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error) {
+	return e.Market.GetKlines(symbol, interval, limit)
+}
+
+func (e *Exchange) GetDepth(symbol, category string, limit int64) (*model.OrderbookData, error) {
+	return e.Market.GetDepth(symbol, category, limit)
+}
+
+func (e *Exchange) GetTickers(category, symbol string) ([]model.WSTickerPrice, error) {
+	return e.Market.GetTickers(category, symbol)
+}
+
+func (e *Exchange) GetTradeLimitsViaInstruments(category, symbol string) (model.TradeLimits, error) {
+	return e.Market.GetTradeLimitsViaInstruments(category, symbol)
+}
+
+// PlaceLimitOrder размещает лимитный ордер в симуляции и сохраняет его в Repo, как ByBitExecutor.
+func (e *Exchange) PlaceLimitOrder(symbol, side string, price, qty, stopLoss, takeProfit float64) (string, error) {
+	orderID, err := e.PlaceOrder(symbol, side, "limit", price, qty, stopLoss, takeProfit)
+	if err != nil {
+		return "", fmt.Errorf("place %s order for %s at %f: %w", side, symbol, price, err)
+	}
+	rec := &model.Order{
+		OrderID:    orderID,
+		Symbol:     symbol,
+		Side:       side,
+		OrderType:  "limit",
+		Price:      price,
+		Quantity:   qty,
+		StopLoss:   stopLoss,
+		TakeProfit: takeProfit,
+		Status:     model.OrderStatusOpen,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	return orderID, e.Repo.InsertOrder(rec)
+}
+
+// CountOpenOrders считает открытые ордера символа и открытую позицию как один ордер,
+// так же как условные TP/SL ордера позиции в ответе Bybit.
+func (e *Exchange) CountOpenOrders(category, symbol string) (int, error) {
+	open, err := e.ListOpenOrders(category, symbol)
+	if err != nil {
+		return 0, err
+	}
+	count := len(open)
+	positions, err := e.GetPositions(category, symbol)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range positions {
+		if p.Size.Float64() > 0 {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,376 @@
+package positions
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sizeEpsilon — остаток объёма, который считается нулевым (погрешность float).
+const sizeEpsilon = 1e-9
+
+// maxSeenExecutions ограничивает память под идентификаторы учтённых исполнений: сверх него
+// забываются самые старые.
+const maxSeenExecutions = 10000
+
+// PriceSource отдаёт текущую цену символа (event.WSListener).
+type PriceSource interface {
+	LastPrice(symbol string) (float64, bool)
+}
+
+// ExecutionSource загружает исполнения через REST (client.ByBit).
+type ExecutionSource interface {
+	GetExecutions(category, symbol string, since time.Time) ([]model.ExecutionUpdate, error)
+}
+
+// OpenPosition — открытая позиция с нереализованным результатом по текущей цене.
+type OpenPosition struct {
+	model.PositionRep
+	MarkPrice     float64 `json:"mark_price"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// Service восстанавливает позиции по исполнениям (one-way режим: одна позиция на символ).
+// Исполнения в сторону позиции увеличивают её и пересчитывают среднюю цену входа,
+// встречные — закрывают её полностью или частично, фиксируя сделку с реализованным PnL
+// и комиссиями; избыток встречного исполнения открывает позицию в обратную сторону.
+type Service struct {
+	Repo   repository.PositionRepository
+	Prices PriceSource // необязателен: без него нереализованный PnL не считается
+	// OnClose вызывается после сохранения каждой закрытой сделки. Вызов выполняется
+	// под блокировкой сервиса, поэтому обработчик не должен обращаться к Service.
+	OnClose func(model.ClosedTradeRep)
+
+	mu        sync.Mutex
+	open      map[string]*model.PositionRep
+	seen      map[string]struct{}
+	seenOrder []string // seen в порядке учёта, для вытеснения старых
+}
+
+func NewService(repo repository.PositionRepository, prices PriceSource) *Service {
+	return &Service{
+		Repo:   repo,
+		Prices: prices,
+		open:   make(map[string]*model.PositionRep),
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// Load загружает открытые позиции из репозитория.
+func (s *Service) Load() error {
+	positions, err := s.Repo.FindOpenPositions("")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range positions {
+		s.open[p.Symbol] = p
+	}
+	log.Printf("[Позиции] Загружено открытых позиций: %d", len(positions))
+	return nil
+}
+
+// Sync догружает через REST исполнения, пропущенные с момента последнего учтённого. Запрос
+// включает миллисекунду последнего исполнения: учтённые в ней отсеиваются по ExecID из Repo,
+// в том числе после перезапуска. Если по символу ещё нет истории, синхронизация не выполняется: без исполнений входа
+// старые закрытия превратились бы в ложные позиции.
+func (s *Service) Sync(api ExecutionSource, category, symbol string) error {
+	last, err := s.Repo.LastFillTime(symbol)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		log.Printf("[Позиции] %s: история исполнений пуста, синхронизация пропущена", symbol)
+		return nil
+	}
+	executions, err := api.GetExecutions(category, symbol, last)
+	if err != nil {
+		return err
+	}
+	ids, err := s.Repo.FindExecutionIDs(symbol, last)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+	applied := 0
+	for _, e := range executions {
+		at := e.ExecTime.Time()
+		if at.Before(last) || known[e.ExecID] || (e.ExecID == "" && !at.After(last)) {
+			continue
+		}
+		if err := s.Apply(e); err != nil {
+			return err
+		}
+		applied++
+	}
+	log.Printf("[Позиции] %s: синхронизировано исполнений: %d", symbol, applied)
+	return nil
+}
+
+// OnExecution — обработчик исполнений приватного потока.
+func (s *Service) OnExecution(e model.ExecutionUpdate) {
+	if err := s.Apply(e); err != nil {
+		log.Printf("[Позиции] Ошибка учёта исполнения %s: %v", e.ExecID, err)
+	}
+}
+
+// Apply учитывает исполнение. Повторно пришедшие исполнения (по ExecID) пропускаются;
+// исполнение считается учтённым только после успешной записи в Repo.
+func (s *Service) Apply(e model.ExecutionUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.ExecID == "" {
+		return s.apply(e)
+	}
+	if _, ok := s.seen[e.ExecID]; ok {
+		return nil
+	}
+	if err := s.apply(e); err != nil {
+		return err
+	}
+	s.markSeen(e.ExecID)
+	return s.Repo.InsertExecution(e.ExecID, e.Symbol, e.ExecTime.Time())
+}
+
+// markSeen запоминает ExecID, вытесняя самые старые сверх maxSeenExecutions.
+func (s *Service) markSeen(execID string) {
+	s.seen[execID] = struct{}{}
+	s.seenOrder = append(s.seenOrder, execID)
+	for len(s.seenOrder) > maxSeenExecutions {
+		delete(s.seen, s.seenOrder[0])
+		s.seenOrder = s.seenOrder[1:]
+	}
+}
+
+// apply изменяет позицию по исполнению и сохраняет её; вызывается под s.mu.
+func (s *Service) apply(e model.ExecutionUpdate) error {
+	at := e.ExecTime.Time()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	pos := s.open[e.Symbol]
+
+	switch e.ExecType {
+	case "Funding":
+		// Фандинг не меняет объём, но входит в издержки позиции.
+		if pos == nil {
+			return nil
+		}
+		pos.Fees += e.ExecFee.Float64()
+		pos.LastFillAt = at
+		return s.Repo.UpdatePosition(pos)
+	case "", "Trade", "AdlTrade", "BustTrade":
+	default:
+		return nil
+	}
+
+	qty := e.ExecQty.Float64()
+	price := e.ExecPrice.Float64()
+	if qty <= 0 || price <= 0 {
+		return nil
+	}
+	side := "long"
+	if strings.EqualFold(e.Side, "sell") {
+		side = "short"
+	}
+	fee := e.ExecFee.Float64()
+
+	if pos != nil && pos.Side == side {
+		pos.EntryPrice = (pos.EntryPrice*pos.Size + price*qty) / (pos.Size + qty)
+		pos.Size += qty
+		pos.Fees += fee
+		pos.LastFillAt = at
+		return s.Repo.UpdatePosition(pos)
+	}
+
+	remaining := qty
+	if pos != nil {
+		closeQty := math.Min(qty, pos.Size)
+		exitFee := fee * closeQty / qty
+		reason := model.CloseReasonFromStopOrderType(e.StopOrderType)
+		if err := s.close(pos, closeQty, price, exitFee, reason, e.OrderID, at); err != nil {
+			return err
+		}
+		remaining -= closeQty
+	}
+	if remaining <= sizeEpsilon {
+		return nil
+	}
+
+	opened := &model.PositionRep{
+		Symbol:     e.Symbol,
+		Side:       side,
+		Size:       remaining,
+		EntryPrice: price,
+		Fees:       fee * remaining / qty,
+		Status:     model.PositionStatusOpen,
+		OpenedAt:   at,
+		LastFillAt: at,
+	}
+	if err := s.Repo.InsertPosition(opened); err != nil {
+		return err
+	}
+	s.open[e.Symbol] = opened
+	log.Printf("[Позиции] Открыта %s %s: %v по %v", opened.Side, opened.Symbol, opened.Size, opened.EntryPrice)
+	return nil
+}
+
+// close закрывает qty позиции по цене price и сохраняет сделку. На сделку относится
+// пропорциональная доля комиссий входа.
+func (s *Service) close(pos *model.PositionRep, qty, price, exitFee float64, reason, orderID string, at time.Time) error {
+	entryFee := pos.Fees * qty / pos.Size
+	gross := (price - pos.EntryPrice) * qty * pos.Direction()
+	trade := &model.ClosedTradeRep{
+		PositionID: pos.ID,
+		Symbol:     pos.Symbol,
+		Side:       pos.Side,
+		Qty:        qty,
+		EntryPrice: pos.EntryPrice,
+		ExitPrice:  price,
+		GrossPnL:   gross,
+		Fees:       entryFee + exitFee,
+		PnL:        gross - entryFee - exitFee,
+		Reason:     reason,
+		OrderID:    orderID,
+		OpenedAt:   pos.OpenedAt,
+		ClosedAt:   at,
+	}
+
+	pos.Size -= qty
+	pos.Fees -= entryFee
+	pos.RealizedPnL += trade.PnL
+	pos.LastFillAt = at
+	if pos.Size <= sizeEpsilon {
+		pos.Size = 0
+		pos.Status = model.PositionStatusClosed
+		pos.ClosedAt = &at
+		delete(s.open, pos.Symbol)
+	}
+	if err := s.Repo.UpdatePosition(pos); err != nil {
+		return err
+	}
+	if err := s.Repo.InsertClosedTrade(trade); err != nil {
+		return err
+	}
+	log.Printf("[Позиции] Закрыто %v %s %s (%s): %v -> %v, PnL %.4f, комиссии %.4f",
+		qty, pos.Side, pos.Symbol, trade.Reason, trade.EntryPrice, trade.ExitPrice, trade.PnL, trade.Fees)
+	if s.OnClose != nil {
+		s.OnClose(*trade)
+	}
+	return nil
+}
+
+// Position возвращает копию открытой позиции символа.
+func (s *Service) Position(symbol string) (model.PositionRep, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.open[symbol]
+	if !ok {
+		return model.PositionRep{}, false
+	}
+	return *p, true
+}
+
+// Adopt заводит позицию, найденную на бирже без учтённых исполнений (например, открытую вручную
+// или пока бот был остановлен). Комиссии входа неизвестны и не учитываются.
+func (s *Service) Adopt(symbol, side string, size, entryPrice float64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.open[symbol]; ok {
+		return fmt.Errorf("position %s already open", symbol)
+	}
+	p := &model.PositionRep{
+		Symbol:     symbol,
+		Side:       side,
+		Size:       size,
+		EntryPrice: entryPrice,
+		Status:     model.PositionStatusOpen,
+		OpenedAt:   at,
+		LastFillAt: at,
+	}
+	if err := s.Repo.InsertPosition(p); err != nil {
+		return err
+	}
+	s.open[symbol] = p
+	log.Printf("[Позиции] Принята позиция с биржи: %s %s %v по %v", side, symbol, size, entryPrice)
+	return nil
+}
+
+// ForceClose закрывает локальную позицию по цене price, когда на бирже её уже нет.
+func (s *Service) ForceClose(symbol string, price float64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos, ok := s.open[symbol]
+	if !ok {
+		return nil
+	}
+	return s.close(pos, pos.Size, price, 0, model.CloseReasonReconcile, "", at)
+}
+
+// OpenPositions возвращает открытые позиции символа (пустой symbol — все)
+// с нереализованным PnL по текущей цене.
+func (s *Service) OpenPositions(symbol string) []OpenPosition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []OpenPosition
+	for sym, p := range s.open {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		op := OpenPosition{PositionRep: *p}
+		if s.Prices != nil {
+			if price, ok := s.Prices.LastPrice(sym); ok {
+				op.MarkPrice = price
+				op.UnrealizedPnL = p.UnrealizedPnL(price)
+			}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// UnrealizedPnL возвращает суммарный нереализованный PnL открытых позиций.
+func (s *Service) UnrealizedPnL() float64 {
+	var total float64
+	for _, p := range s.OpenPositions("") {
+		total += p.UnrealizedPnL
+	}
+	return total
+}
+
+// ClosedTrades возвращает сделки, закрытые в [from, to); пустой symbol — по всем символам.
+func (s *Service) ClosedTrades(symbol string, from, to time.Time) ([]*model.ClosedTradeRep, error) {
+	return s.Repo.FindClosedTrades(symbol, from, to)
+}
+
+// ClosedPnL сводит закрытые в [from, to) сделки; пустой symbol — по всем символам.
+func (s *Service) ClosedPnL(symbol string, from, to time.Time) (model.PnLSummary, error) {
+	trades, err := s.ClosedTrades(symbol, from, to)
+	if err != nil {
+		return model.PnLSummary{}, fmt.Errorf("closed pnl: %w", err)
+	}
+	var sum model.PnLSummary
+	for _, t := range trades {
+		sum.Trades++
+		if t.PnL > 0 {
+			sum.Wins++
+		} else {
+			sum.Losses++
+		}
+		sum.GrossPnL += t.GrossPnL
+		sum.Fees += t.Fees
+		sum.NetPnL += t.PnL
+	}
+	return sum, nil
+}
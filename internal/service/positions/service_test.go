@@ -0,0 +1,121 @@
+package positions
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/thrasher-corp/gocryptotrader/types"
+	"testing"
+	"time"
+)
+
+var fillTime = time.UnixMilli(1_700_000_000_123)
+
+func buy(execID string, qty float64, at time.Time) model.ExecutionUpdate {
+	return model.ExecutionUpdate{
+		Symbol:    "BTCUSDT",
+		ExecID:    execID,
+		Side:      "Buy",
+		ExecType:  "Trade",
+		ExecPrice: types.Number(100),
+		ExecQty:   types.Number(qty),
+		ExecTime:  types.Time(at),
+	}
+}
+
+// executionSource отдаёт исполнения не раньше since, как REST Bybit.
+type executionSource []model.ExecutionUpdate
+
+func (s executionSource) GetExecutions(_, symbol string, since time.Time) ([]model.ExecutionUpdate, error) {
+	var out []model.ExecutionUpdate
+	for _, e := range s {
+		if e.Symbol == symbol && !e.ExecTime.Time().Before(since) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// failingRepository отклоняет следующие failWrites записей позиций.
+type failingRepository struct {
+	repository.PositionRepository
+	failWrites int
+}
+
+func (r *failingRepository) InsertPosition(p *model.PositionRep) error {
+	if r.failWrites > 0 {
+		r.failWrites--
+		return errors.New("database is locked")
+	}
+	return r.PositionRepository.InsertPosition(p)
+}
+
+func size(t *testing.T, s *Service) float64 {
+	t.Helper()
+	pos, ok := s.Position("BTCUSDT")
+	if !ok {
+		t.Fatal("no BTCUSDT position")
+	}
+	return pos.Size
+}
+
+func TestSyncAppliesExecutionsInTheLastMillisecond(t *testing.T) {
+	repo := repository.NewMemoryPositionRepository()
+	first := NewService(repo, nil)
+	if err := first.Apply(buy("a", 1, fillTime)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// После перезапуска REST отдаёт уже учтённое исполнение и пропущенное в ту же миллисекунду.
+	restarted := NewService(repo, nil)
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	api := executionSource{buy("a", 1, fillTime), buy("b", 2, fillTime), buy("c", 4, fillTime.Add(time.Millisecond))}
+	if err := restarted.Sync(api, "linear", "BTCUSDT"); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := size(t, restarted); got != 7 {
+		t.Fatalf("size after Sync = %v, want 7", got)
+	}
+	if err := restarted.Sync(api, "linear", "BTCUSDT"); err != nil {
+		t.Fatalf("repeated Sync: %v", err)
+	}
+	if got := size(t, restarted); got != 7 {
+		t.Fatalf("size after repeated Sync = %v, want 7", got)
+	}
+}
+
+func TestApplyRetriesFailedWrite(t *testing.T) {
+	repo := &failingRepository{PositionRepository: repository.NewMemoryPositionRepository(), failWrites: 1}
+	s := NewService(repo, nil)
+	if err := s.Apply(buy("a", 1, fillTime)); err == nil {
+		t.Fatal("Apply succeeded with a failing repository")
+	}
+	if err := s.Apply(buy("a", 1, fillTime)); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := size(t, s); got != 1 {
+		t.Fatalf("size = %v, want 1", got)
+	}
+}
+
+func TestSeenExecutionsEvictOldestFirst(t *testing.T) {
+	s := NewService(repository.NewMemoryPositionRepository(), nil)
+	for i := 0; i <= maxSeenExecutions; i++ {
+		s.markSeen(fmt.Sprintf("exec-%d", i))
+	}
+	if _, ok := s.seen["exec-0"]; ok {
+		t.Fatal("oldest execution was not evicted")
+	}
+	if len(s.seen) != maxSeenExecutions {
+		t.Fatalf("seen holds %d executions, want %d", len(s.seen), maxSeenExecutions)
+	}
+	if err := s.Apply(buy(fmt.Sprintf("exec-%d", maxSeenExecutions), 1, fillTime)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if _, ok := s.Position("BTCUSDT"); ok {
+		t.Fatal("recent execution was applied again")
+	}
+}
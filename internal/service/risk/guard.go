@@ -0,0 +1,421 @@
+package risk
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/notify"
+	"bybit-bot/internal/service/positions"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// streakLookback — период закрытых сделок, по которому восстанавливается серия убытков при запуске.
+const streakLookback = 7 * 24 * time.Hour
+
+var (
+	// ErrHalted — торговля остановлена риск-контролем.
+	ErrHalted = errors.New("trading halted")
+	// ErrLimit — ордер превышает ограничение аккаунта.
+	ErrLimit = errors.New("risk limit exceeded")
+)
+
+// EquityHistory — история баланса аккаунта (repository.WalletRepository).
+type EquityHistory interface {
+	GetLatestWalletInfo(coin string) (*model.WalletInfoRep, error)
+	GetPeakWalletBalance(coin string, since time.Time) (float64, error)
+}
+
+// Flattener снимает ордера и закрывает позиции на бирже (client.ByBit).
+type Flattener interface {
+	ListOpenOrders(category, symbol string) ([]model.OrderUpdate, error)
+	CancelOrder(category, symbol, orderID string) error
+	ClosePosition(category, symbol, side string, qty float64) error
+}
+
+// Guard проверяет ограничения аккаунта перед каждым ордером и передаёт его Next.
+// Дневной убыток, просадка от пика и серия убытков останавливают торговлю: состояние
+// сохраняется в State и переживает перезапуск. Дневной убыток снимает остановку в начале
+// следующих суток UTC, серия — по истечении Cooldown, просадка — только через Resume.
+// Число позиций, стоимость позиций и частота ордеров отклоняют отдельный ордер; активные
+// входные ордера из Orders учитываются в числе позиций и стоимости наравне с позициями.
+type Guard struct {
+	Next      interfaces.Executor
+	Positions *positions.Service
+	Wallet    EquityHistory
+	State     repository.RiskStateRepository
+	Orders    repository.OrderRepository
+	Flatten   Flattener       // необязателен: без него FlattenOnHalt не действует
+	Notifier  notify.Notifier // необязателен: без него события только логируются
+	Category  string
+	Symbols   []string
+	Limits    config.GuardConfig
+
+	mu         sync.Mutex
+	state      model.RiskState
+	orders     []time.Time
+	lossStreak int
+	now        func() time.Time
+}
+
+func NewGuard(next interfaces.Executor, positionService *positions.Service, wallet EquityHistory,
+	state repository.RiskStateRepository, orders repository.OrderRepository, category string, symbols []string,
+	limits config.GuardConfig) *Guard {
+	return &Guard{
+		Next:      next,
+		Positions: positionService,
+		Wallet:    wallet,
+		State:     state,
+		Orders:    orders,
+		Category:  category,
+		Symbols:   symbols,
+		Limits:    limits,
+		now:       time.Now,
+	}
+}
+
+// Load восстанавливает сохранённое состояние остановки и текущую серию убыточных сделок.
+func (g *Guard) Load() error {
+	saved, err := g.State.LoadRiskState()
+	if err != nil {
+		return err
+	}
+	now := g.now()
+	trades, err := g.Positions.ClosedTrades("", now.Add(-streakLookback), now)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if saved != nil {
+		g.state = *saved
+	}
+	g.lossStreak = 0
+	for i := len(trades) - 1; i >= 0 && trades[i].PnL < 0; i-- {
+		g.lossStreak++
+	}
+	if g.state.Halted {
+		log.Printf("[Риск] Торговля остановлена с %s: %s", formatTime(g.state.HaltedAt), g.state.Reason)
+	}
+	log.Printf("[Риск] Убыточных сделок подряд: %d", g.lossStreak)
+	return nil
+}
+
+// Run периодически проверяет дневной убыток и просадку, чтобы остановить торговлю
+// и закрыть позиции, не дожидаясь следующего ордера.
+func (g *Guard) Run(ctx context.Context) error {
+	if g.Limits.CheckInterval.Duration <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(g.Limits.CheckInterval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			open := g.Positions.OpenPositions("")
+			g.mu.Lock()
+			_ = g.checkHalt(open)
+			g.mu.Unlock()
+		}
+	}
+}
+
+// PlaceLimitOrder размещает ордер через Next, если он не нарушает ограничений.
+func (g *Guard) PlaceLimitOrder(symbol, side string, price, qty, sl, tp float64) (string, error) {
+	if err := g.Allow(symbol, price*qty); err != nil {
+		log.Printf("[Риск] Ордер %s %s %v @ %v отклонён: %v", side, symbol, qty, price, err)
+		return "", err
+	}
+	orderID, err := g.Next.PlaceLimitOrder(symbol, side, price, qty, sl, tp)
+	if err != nil {
+		return orderID, err
+	}
+	g.mu.Lock()
+	g.orders = append(g.orders, g.now())
+	g.mu.Unlock()
+	return orderID, nil
+}
+
+func (g *Guard) CountOpenOrders(category, symbol string) (int, error) {
+	return g.Next.CountOpenOrders(category, symbol)
+}
+
+// Allow проверяет, можно ли открыть по символу ордер стоимостью notional.
+func (g *Guard) Allow(symbol string, notional float64) error {
+	// Позиции читаются до g.mu: OnTrade вызывается под блокировкой positions.Service.
+	open := g.Positions.OpenPositions("")
+	pending, err := g.pendingEntries()
+	if err != nil {
+		return fmt.Errorf("active orders: %w", err)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkHalt(open); err != nil {
+		return err
+	}
+
+	l := g.Limits
+	var symbolExposure, totalExposure float64
+	occupied := make(map[string]bool) // символы с позицией или активным входным ордером
+	for _, p := range open {
+		price := p.MarkPrice
+		if price <= 0 {
+			price = p.EntryPrice
+		}
+		exposure := p.Size * price
+		totalExposure += exposure
+		if p.Symbol == symbol {
+			symbolExposure += exposure
+		}
+		occupied[p.Symbol] = true
+	}
+	for _, o := range pending {
+		exposure := o.Quantity * o.Price
+		totalExposure += exposure
+		if o.Symbol == symbol {
+			symbolExposure += exposure
+		}
+		occupied[o.Symbol] = true
+	}
+	if l.MaxOpenPositions > 0 && !occupied[symbol] && len(occupied) >= l.MaxOpenPositions {
+		return fmt.Errorf("%w: open positions and orders %d of %d", ErrLimit, len(occupied), l.MaxOpenPositions)
+	}
+	if l.MaxSymbolNotional > 0 && symbolExposure+notional > l.MaxSymbolNotional {
+		return fmt.Errorf("%w: %s notional %.2f + %.2f > %.2f USDT", ErrLimit, symbol, symbolExposure, notional, l.MaxSymbolNotional)
+	}
+	if l.MaxTotalNotional > 0 && totalExposure+notional > l.MaxTotalNotional {
+		return fmt.Errorf("%w: total notional %.2f + %.2f > %.2f USDT", ErrLimit, totalExposure, notional, l.MaxTotalNotional)
+	}
+	if l.MaxOrdersPerHour > 0 {
+		cutoff := g.now().Add(-time.Hour)
+		recent := g.orders[:0]
+		for _, at := range g.orders {
+			if at.After(cutoff) {
+				recent = append(recent, at)
+			}
+		}
+		g.orders = recent
+		if len(recent) >= l.MaxOrdersPerHour {
+			return fmt.Errorf("%w: %d orders in the last hour", ErrLimit, len(recent))
+		}
+	}
+	return nil
+}
+
+// pendingEntries возвращает активные входные ордера по символам Symbols. В Orders хранятся только
+// ордера бота без reduce-only. Частично исполненный ордер учитывается целиком: его исполненная
+// часть уже входит в позицию, но оценка сверху безопаснее.
+func (g *Guard) pendingEntries() ([]*model.Order, error) {
+	var pending []*model.Order
+	for _, symbol := range g.Symbols {
+		orders, err := g.Orders.FindOrdersBySymbol(symbol)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			if o.Status == model.OrderStatusOpen || o.Status == model.OrderStatusPartiallyFilled {
+				pending = append(pending, o)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// OnTrade учитывает закрытую сделку в серии убытков (positions.Service.OnClose).
+func (g *Guard) OnTrade(t model.ClosedTradeRep) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if t.PnL >= 0 {
+		g.lossStreak = 0
+		return
+	}
+	g.lossStreak++
+	n := g.Limits.MaxConsecutiveLosses
+	if n == 0 || g.lossStreak < n || g.state.Halted {
+		return
+	}
+	until := g.now().Add(g.Limits.Cooldown.Duration)
+	g.lossStreak = 0
+	g.halt(fmt.Sprintf("%d убыточных сделок подряд, пауза %s", n, g.Limits.Cooldown.Duration), &until, false)
+}
+
+// Halt останавливает торговлю до ручного возобновления.
+func (g *Guard) Halt(reason string, flatten bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.halt(reason, nil, flatten)
+}
+
+// Resume снимает остановку торговли.
+func (g *Guard) Resume() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.state.Halted {
+		return nil
+	}
+	reason := g.state.Reason
+	g.state = model.RiskState{}
+	if err := g.State.SaveRiskState(&g.state); err != nil {
+		return err
+	}
+	g.notify(fmt.Sprintf("Торговля возобновлена (остановка: %s)", reason))
+	return nil
+}
+
+// Halted сообщает, остановлена ли торговля, и причину.
+func (g *Guard) Halted() (bool, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.state.Halted, g.state.Reason
+}
+
+// checkHalt снимает истёкшую остановку, проверяет дневной убыток и просадку
+// и возвращает ErrHalted, если торговля остановлена. Вызывается под g.mu.
+func (g *Guard) checkHalt(open []positions.OpenPosition) error {
+	now := g.now()
+	if g.state.Halted && g.state.Until != nil && !now.Before(*g.state.Until) {
+		reason := g.state.Reason
+		g.state = model.RiskState{}
+		g.persist()
+		g.notify(fmt.Sprintf("Торговля возобновлена автоматически (остановка: %s)", reason))
+	}
+	if !g.state.Halted {
+		if reason, until := g.lossLimitReason(now, open); reason != "" {
+			g.halt(reason, until, g.Limits.FlattenOnHalt)
+		}
+	}
+	if g.state.Halted {
+		return fmt.Errorf("%w: %s", ErrHalted, g.state.Reason)
+	}
+	return nil
+}
+
+// lossLimitReason возвращает причину остановки по дневному убытку или просадке и время
+// автоматического возобновления. Ошибки источников данных логируются и не останавливают торговлю.
+func (g *Guard) lossLimitReason(now time.Time, open []positions.OpenPosition) (string, *time.Time) {
+	l := g.Limits
+	if l.MaxDailyLoss > 0 {
+		dayStart := now.UTC().Truncate(24 * time.Hour)
+		sum, err := g.Positions.ClosedPnL("", dayStart, now)
+		if err != nil {
+			log.Printf("[Риск] Ошибка расчёта дневного убытка: %v", err)
+		} else if -sum.NetPnL >= l.MaxDailyLoss {
+			until := dayStart.Add(24 * time.Hour)
+			return fmt.Sprintf("дневной убыток %.2f USDT достиг лимита %.2f USDT", -sum.NetPnL, l.MaxDailyLoss), &until
+		}
+	}
+	if l.MaxDrawdown > 0 {
+		drawdown, equity, peak, err := g.drawdown(now, open)
+		if err != nil {
+			log.Printf("[Риск] Ошибка расчёта просадки: %v", err)
+		} else if drawdown >= l.MaxDrawdown {
+			return fmt.Sprintf("просадка %.2f%% (капитал %.2f, пик %.2f USDT) достигла лимита %.2f%%",
+				drawdown*100, equity, peak, l.MaxDrawdown*100), nil
+		}
+	}
+	return "", nil
+}
+
+// drawdown рассчитывает просадку капитала (баланс с нереализованным PnL) от пика баланса.
+func (g *Guard) drawdown(now time.Time, open []positions.OpenPosition) (drawdown, equity, peak float64, err error) {
+	info, err := g.Wallet.GetLatestWalletInfo("USDT")
+	if err != nil || info == nil {
+		return 0, 0, 0, err
+	}
+	var since time.Time
+	if w := g.Limits.DrawdownWindow.Duration; w > 0 {
+		since = now.Add(-w)
+	}
+	peak, err = g.Wallet.GetPeakWalletBalance("USDT", since)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	equity = info.WalletBalance
+	for _, p := range open {
+		equity += p.UnrealizedPnL
+	}
+	peak = math.Max(peak, info.WalletBalance)
+	if peak <= 0 {
+		return 0, equity, peak, nil
+	}
+	return math.Max(0, (peak-equity)/peak), equity, peak, nil
+}
+
+// halt сохраняет остановку, уведомляет и при flatten закрывает позиции. Вызывается под g.mu.
+func (g *Guard) halt(reason string, until *time.Time, flatten bool) {
+	now := g.now()
+	g.state = model.RiskState{Halted: true, Reason: reason, HaltedAt: &now, Until: until}
+	g.persist()
+	msg := "Торговля остановлена: " + reason
+	if until != nil {
+		msg += ", до " + until.Format(time.RFC3339)
+	} else {
+		msg += ", до ручного возобновления"
+	}
+	g.notify(msg)
+	if flatten && g.Flatten != nil {
+		// Закрытие идёт вне блокировки: обработчики исполнений обращаются к Guard через Positions.
+		go g.flattenAll()
+	}
+}
+
+func (g *Guard) persist() {
+	if err := g.State.SaveRiskState(&g.state); err != nil {
+		log.Printf("[Риск] Ошибка сохранения состояния: %v", err)
+	}
+}
+
+func (g *Guard) notify(text string) {
+	if g.Notifier != nil {
+		g.Notifier.Notify(text)
+		return
+	}
+	log.Printf("[Риск] %s", text)
+}
+
+// flattenAll снимает входные ордера и закрывает открытые позиции рыночными reduce-only ордерами.
+func (g *Guard) flattenAll() {
+	for _, symbol := range g.Symbols {
+		orders, err := g.Flatten.ListOpenOrders(g.Category, symbol)
+		if err != nil {
+			log.Printf("[Риск] Ошибка получения ордеров %s: %v", symbol, err)
+		}
+		for _, o := range orders {
+			if o.StopOrderType != "" || o.ReduceOnly {
+				continue
+			}
+			if err := g.Flatten.CancelOrder(g.Category, symbol, o.OrderID); err != nil {
+				log.Printf("[Риск] Ошибка отмены ордера %s: %v", o.OrderID, err)
+			}
+		}
+	}
+	closed := 0
+	for _, p := range g.Positions.OpenPositions("") {
+		side := "Sell"
+		if p.Side == "short" {
+			side = "Buy"
+		}
+		if err := g.Flatten.ClosePosition(g.Category, p.Symbol, side, p.Size); err != nil {
+			log.Printf("[Риск] Ошибка закрытия позиции %s: %v", p.Symbol, err)
+			continue
+		}
+		closed++
+	}
+	g.notify(fmt.Sprintf("Позиции закрыты: %d", closed))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,61 @@
+package risk
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/positions"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestGuard возвращает Guard с позицией ETHUSDT стоимостью 1000 USDT и активным входным
+// ордером BTCUSDT на 500 USDT; исполненный и отменённый ордера не учитываются.
+func newTestGuard(t *testing.T, limits config.GuardConfig) *Guard {
+	t.Helper()
+	positionService := positions.NewService(repository.NewMemoryPositionRepository(), nil)
+	if err := positionService.Adopt("ETHUSDT", "long", 1, 1000, time.Now()); err != nil {
+		t.Fatalf("adopt position: %v", err)
+	}
+	orders := repository.NewMemoryOrderRepository()
+	for _, o := range []*model.Order{
+		{OrderID: "open", Symbol: "BTCUSDT", Side: "Buy", Price: 50000, Quantity: 0.01, Status: model.OrderStatusOpen},
+		{OrderID: "filled", Symbol: "BTCUSDT", Side: "Buy", Price: 50000, Quantity: 1, Status: model.OrderStatusFilled},
+		{OrderID: "cancelled", Symbol: "SOLUSDT", Side: "Buy", Price: 100, Quantity: 10, Status: model.OrderStatusCancelled},
+	} {
+		if err := orders.InsertOrder(o); err != nil {
+			t.Fatalf("insert order: %v", err)
+		}
+	}
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+	return NewGuard(nil, positionService, nil, repository.NewMemoryRiskStateRepository(), orders, "linear", symbols, limits)
+}
+
+func TestAllowCountsActiveOrders(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   config.GuardConfig
+		symbol   string
+		notional float64
+		allowed  bool
+	}{
+		{"order occupies a position slot", config.GuardConfig{MaxOpenPositions: 2}, "SOLUSDT", 100, false},
+		{"symbol with an order has a slot", config.GuardConfig{MaxOpenPositions: 2}, "BTCUSDT", 100, true},
+		{"order adds to symbol notional", config.GuardConfig{MaxSymbolNotional: 550}, "BTCUSDT", 100, false},
+		{"symbol notional within limit", config.GuardConfig{MaxSymbolNotional: 600}, "BTCUSDT", 100, true},
+		{"order adds to total notional", config.GuardConfig{MaxTotalNotional: 1550}, "SOLUSDT", 100, false},
+		{"total notional within limit", config.GuardConfig{MaxTotalNotional: 1600}, "SOLUSDT", 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestGuard(t, tt.limits).Allow(tt.symbol, tt.notional)
+			if tt.allowed && err != nil {
+				t.Fatalf("Allow = %v, want nil", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrLimit) {
+				t.Fatalf("Allow = %v, want ErrLimit", err)
+			}
+		})
+	}
+}
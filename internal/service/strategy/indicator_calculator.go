@@ -1,189 +1,283 @@
 package strategy
 
 import (
-	"bybit-bot/internal/client"
+	"bybit-bot/internal/indicator"
+	"bybit-bot/internal/interfaces"
 	"bybit-bot/internal/model"
 	"bybit-bot/internal/service/event"
-	"errors"
 	"fmt"
-	"github.com/markcheno/go-talib"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
+// DefaultIndicatorHistory — свечей истории для прогрева нового индикатора (максимум одного ответа Bybit).
+const DefaultIndicatorHistory = 1000
+
+// IndicatorCalculator ведёт инкрементальные индикаторы по закрытым свечам интервала Interval.
+// Индикатор создаётся при первом запросе и прогревается историей из Exchange, а дальше
+// обновляется закрытыми свечами из WSListener за O(1) без повторных запросов истории.
+// Если поток пропустил свечу, индикаторы символа сбрасываются и при следующем запросе
+// прогреваются заново, поэтому значения всегда совпадают с talib по непрерывной истории.
 type IndicatorCalculator struct {
-	Cache          map[string]map[string]interface{}
-	Bybit          *client.ByBit // передаем указатель
-	cacheMutex     sync.RWMutex
-	CacheDuration  time.Duration
-	indicatorMutex sync.Mutex
-	WSListener     *event.WSListener
+	Exchange   interfaces.Exchange
+	WSListener *event.WSListener
+	Interval   string // интервал свечей (trading.interval); пусто — model.DefaultSignalInterval
+	History    int    // свечей истории для прогрева; 0 — DefaultIndicatorHistory
+
+	mu      sync.Mutex
+	symbols map[string]map[string]*trackedIndicator
 }
 
-// EMA - Exponential Moving Average
-func (ic *IndicatorCalculator) EMA(symbol string, period int) (float64, error) {
-	cacheKey := fmt.Sprintf("ema_%d", period)
-	if val, err := ic.getFromCache(symbol, cacheKey); err == nil {
-		return val.(float64), nil
-	}
+// trackedIndicator — индикатор и время начала последней учтённой свечи (мс).
+type trackedIndicator struct {
+	indicator.Indicator
+	last int64
+}
 
-	klines, err := ic.getKLines(symbol, period*2)
-	if err != nil {
-		return 0, err
+// NewIndicatorCalculator создаёт калькулятор свечей interval и, если ws задан, подписывает его
+// на закрытые свечи. Топик event.KlineTopic(interval, symbol) подписывает вызывающий.
+func NewIndicatorCalculator(exchange interfaces.Exchange, ws *event.WSListener, interval string) *IndicatorCalculator {
+	ic := &IndicatorCalculator{
+		Exchange:   exchange,
+		WSListener: ws,
+		Interval:   interval,
+		symbols:    make(map[string]map[string]*trackedIndicator),
 	}
-
-	closes := make([]float64, len(klines))
-	for i, k := range klines {
-		closes[i] = k.Close
+	if ws != nil {
+		ws.OnClosedKline(ic.OnKline)
 	}
+	return ic
+}
 
-	emaValues := talib.Ema(closes, period)
-	if len(emaValues) == 0 {
-		return 0, errors.New("empty EMA result")
+func (ic *IndicatorCalculator) interval() string {
+	if ic.Interval == "" {
+		return model.DefaultSignalInterval
 	}
+	return ic.Interval
+}
 
-	result := emaValues[len(emaValues)-1]
-	ic.setCache(symbol, cacheKey, result)
-	return result, nil
+// step возвращает длительность свечи интервала в миллисекундах.
+func (ic *IndicatorCalculator) step() int64 {
+	step, _ := model.KlineIntervalDuration(ic.interval())
+	return step.Milliseconds()
 }
 
-// ATR - Average True Range
-func (ic *IndicatorCalculator) ATR(symbol string, period int) (float64, error) {
-	cacheKey := fmt.Sprintf("atr_%d", period)
-	if val, err := ic.getFromCache(symbol, cacheKey); err == nil {
-		return val.(float64), nil
+// OnKline учитывает закрытую свечу символа k.Symbol во всех его индикаторах.
+// Незакрытые, уже учтённые свечи и свечи других интервалов пропускаются.
+func (ic *IndicatorCalculator) OnKline(k model.KlineData) {
+	if !k.Confirm || k.Interval != ic.interval() {
+		return
 	}
-
-	klines, err := ic.getKLines(symbol, period*3)
-	if err != nil {
-		return 0, err
+	indicatorStep := ic.step()
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for key, t := range ic.symbols[k.Symbol] {
+		switch {
+		case k.Start <= t.last:
+		case t.last != 0 && k.Start > t.last+indicatorStep:
+			log.Printf("[Индикаторы] Пропуск свечей %s после %d: %s будет прогрет заново", k.Symbol, t.last, key)
+			delete(ic.symbols[k.Symbol], key)
+		default:
+			t.Update(k)
+			t.last = k.Start
+		}
 	}
+}
 
-	highs := make([]float64, len(klines))
-	lows := make([]float64, len(klines))
-	closes := make([]float64, len(klines))
-
-	for i, k := range klines {
-		highs[i] = k.High
-		lows[i] = k.Low
-		closes[i] = k.Close
-	}
+// EMA - Exponential Moving Average
+func (ic *IndicatorCalculator) EMA(symbol string, period int) (float64, error) {
+	var value float64
+	err := ic.read(symbol, fmt.Sprintf("ema_%d", period),
+		func() indicator.Indicator { return indicator.NewEMA(period) },
+		func(ind indicator.Indicator) { value = ind.(*indicator.EMA).Value() })
+	return value, err
+}
 
-	atrValues := talib.Atr(highs, lows, closes, period)
-	if len(atrValues) == 0 {
-		return 0, errors.New("empty ATR result")
-	}
+// SMA - Simple Moving Average
+func (ic *IndicatorCalculator) SMA(symbol string, period int) (float64, error) {
+	var value float64
+	err := ic.read(symbol, fmt.Sprintf("sma_%d", period),
+		func() indicator.Indicator { return indicator.NewSMA(period) },
+		func(ind indicator.Indicator) { value = ind.(*indicator.SMA).Value() })
+	return value, err
+}
 
-	result := atrValues[len(atrValues)-1]
-	ic.setCache(symbol, cacheKey, result)
-	return result, nil
+// ATR - Average True Range
+func (ic *IndicatorCalculator) ATR(symbol string, period int) (float64, error) {
+	var value float64
+	err := ic.read(symbol, fmt.Sprintf("atr_%d", period),
+		func() indicator.Indicator { return indicator.NewATR(period) },
+		func(ind indicator.Indicator) { value = ind.(*indicator.ATR).Value() })
+	return value, err
 }
 
 // RSI - Relative Strength Index
 func (ic *IndicatorCalculator) RSI(symbol string, period int) (float64, error) {
-	cacheKey := fmt.Sprintf("rsi_%d", period)
-	if val, err := ic.getFromCache(symbol, cacheKey); err == nil {
-		return val.(float64), nil
-	}
+	var value float64
+	err := ic.read(symbol, fmt.Sprintf("rsi_%d", period),
+		func() indicator.Indicator { return indicator.NewRSI(period) },
+		func(ind indicator.Indicator) { value = ind.(*indicator.RSI).Value() })
+	return value, err
+}
 
-	klines, err := ic.getKLines(symbol, period*2)
-	if err != nil {
-		return 0, err
-	}
+// MACD - Moving Average Convergence Divergence
+func (ic *IndicatorCalculator) MACD(symbol string, fastPeriod, slowPeriod, signalPeriod int) (float64, float64, float64, error) {
+	var macd, signal, hist float64
+	err := ic.read(symbol, fmt.Sprintf("macd_%d_%d_%d", fastPeriod, slowPeriod, signalPeriod),
+		func() indicator.Indicator { return indicator.NewMACD(fastPeriod, slowPeriod, signalPeriod) },
+		func(ind indicator.Indicator) { macd, signal, hist = ind.(*indicator.MACD).Values() })
+	return macd, signal, hist, err
+}
 
-	closes := make([]float64, len(klines))
-	for i, k := range klines {
-		closes[i] = k.Close
-	}
+// Bollinger - Bollinger Bands (верхняя, средняя и нижняя полосы)
+func (ic *IndicatorCalculator) Bollinger(symbol string, period int, devUp, devDown float64) (float64, float64, float64, error) {
+	var upper, middle, lower float64
+	err := ic.read(symbol, fmt.Sprintf("bbands_%d_%g_%g", period, devUp, devDown),
+		func() indicator.Indicator { return indicator.NewBollinger(period, devUp, devDown) },
+		func(ind indicator.Indicator) { upper, middle, lower = ind.(*indicator.Bollinger).Values() })
+	return upper, middle, lower, err
+}
 
-	rsiValues := talib.Rsi(closes, period)
-	if len(rsiValues) == 0 {
-		return 0, errors.New("empty RSI result")
-	}
+// VWAP - Volume Weighted Average Price с начала суток UTC
+func (ic *IndicatorCalculator) VWAP(symbol string) (float64, error) {
+	var value float64
+	err := ic.read(symbol, "vwap_day",
+		func() indicator.Indicator { return indicator.NewVWAP(24 * time.Hour) },
+		func(ind indicator.Indicator) { value = ind.(*indicator.VWAP).Value() })
+	return value, err
+}
 
-	result := rsiValues[len(rsiValues)-1]
-	ic.setCache(symbol, cacheKey, result)
-	return result, nil
+// OBV - On Balance Volume с начала истории прогрева
+func (ic *IndicatorCalculator) OBV(symbol string) (float64, error) {
+	var value float64
+	err := ic.read(symbol, "obv",
+		func() indicator.Indicator { return indicator.NewOBV() },
+		func(ind indicator.Indicator) { value = ind.(*indicator.OBV).Value() })
+	return value, err
 }
 
-// MACD - Moving Average Convergence Divergence
-func (ic *IndicatorCalculator) MACD(symbol string, fastPeriod, slowPeriod, signalPeriod int) (float64, float64, float64, error) {
-	cacheKey := fmt.Sprintf("macd_%d_%d_%d", fastPeriod, slowPeriod, signalPeriod)
-	if val, err := ic.getFromCache(symbol, cacheKey); err == nil {
-		values := val.([]float64)
-		return values[0], values[1], values[2], nil
-	}
+// read передаёт в get прогретый индикатор key символа, создавая его через create при первом запросе.
+func (ic *IndicatorCalculator) read(symbol, key string, create func() indicator.Indicator, get func(indicator.Indicator)) error {
+	return ic.readAt(symbol, key, 0, create, get)
+}
 
-	klines, err := ic.getKLines(symbol, slowPeriod*3)
+// readAt — read, требующий, чтобы последней индикатор учёл свечу, начавшуюся в момент start (мс);
+// при start = 0 подходит любая последняя свеча.
+func (ic *IndicatorCalculator) readAt(symbol, key string, start int64, create func() indicator.Indicator, get func(indicator.Indicator)) error {
+	t, err := ic.tracked(symbol, key, create)
 	if err != nil {
-		return 0, 0, 0, err
+		return err
 	}
-
-	closes := make([]float64, len(klines))
-	for i, k := range klines {
-		closes[i] = k.Close
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if !t.Ready() {
+		return fmt.Errorf("%s %s: warm-up %d of %d klines", symbol, key, t.Count(), t.WarmUp())
 	}
-
-	macd, signal, hist := talib.Macd(closes, fastPeriod, slowPeriod, signalPeriod)
-	if len(macd) == 0 {
-		return 0, 0, 0, errors.New("empty MACD result")
-	}
-
-	result := []float64{
-		macd[len(macd)-1],
-		signal[len(signal)-1],
-		hist[len(hist)-1],
+	if start != 0 && t.last != start {
+		return fmt.Errorf("%s %s: last kline %d, want %d", symbol, key, t.last, start)
 	}
-
-	ic.setCache(symbol, cacheKey, result)
-	return result[0], result[1], result[2], nil
+	get(t.Indicator)
+	return nil
 }
 
-// Вспомогательные методы
-func (ic *IndicatorCalculator) getKLines(symbol string, limit int) ([]model.KlineData, error) {
-	klines, err := ic.Bybit.GetKlines(symbol, "1", uint64(limit))
+// tracked возвращает индикатор key символа, создавая и прогревая его через create при первом запросе.
+func (ic *IndicatorCalculator) tracked(symbol, key string, create func() indicator.Indicator) (*trackedIndicator, error) {
+	if symbol == "" {
+		return nil, fmt.Errorf("%s: empty symbol", key)
+	}
+	ic.mu.Lock()
+	t, ok := ic.symbols[symbol][key]
+	ic.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+	// История запрашивается без блокировки, чтобы не задерживать поток свечей.
+	warmed, err := ic.warmUp(symbol, create())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get klines: %v", err)
+		return nil, err
 	}
-
-	if len(klines) < limit/2 { // Минимум половина запрошенных данных
-		return nil, fmt.Errorf("not enough klines (%d < %d)", len(klines), limit/2)
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if t, ok = ic.symbols[symbol][key]; ok {
+		return t, nil
 	}
-
-	return klines, nil
+	if ic.symbols == nil {
+		ic.symbols = make(map[string]map[string]*trackedIndicator)
+	}
+	if ic.symbols[symbol] == nil {
+		ic.symbols[symbol] = make(map[string]*trackedIndicator)
+	}
+	ic.symbols[symbol][key] = warmed
+	return warmed, nil
 }
 
-func (ic *IndicatorCalculator) getFromCache(symbol, key string) (interface{}, error) {
-	ic.cacheMutex.RLock()
-	defer ic.cacheMutex.RUnlock()
-
-	if symbolCache, ok := ic.Cache[symbol]; ok {
-		if cached, ok := symbolCache[key]; ok {
-			if cacheTime, ok := symbolCache[key+"_time"]; ok {
-				if time.Since(cacheTime.(time.Time)) < ic.CacheDuration {
-					return cached, nil
-				}
-			}
+// signalIndicators возвращает индикаторы SignalDetector по свече символа, начавшейся в момент
+// start (мс). Ошибка означает, что индикатор ещё не прогрет или последней учёл другую свечу
+// (например, поток ещё не доставил её): тогда детектор считает индикаторы по свечам сам.
+// Средний объём детектор всегда считает по свечам.
+func (ic *IndicatorCalculator) signalIndicators(symbol string, p model.SignalParams, start int64) (signalIndicators, error) {
+	var (
+		out signalIndicators
+		err error
+	)
+	read := func(key string, create func() indicator.Indicator, get func(indicator.Indicator)) {
+		if err == nil {
+			err = ic.readAt(symbol, key, start, create, get)
 		}
 	}
-	return nil, errors.New("not in cache")
+	read(fmt.Sprintf("ema_%d", p.EMAFast),
+		func() indicator.Indicator { return indicator.NewEMA(p.EMAFast) },
+		func(ind indicator.Indicator) { out.emaFast = ind.(*indicator.EMA).Value() })
+	read(fmt.Sprintf("ema_%d", p.EMASlow),
+		func() indicator.Indicator { return indicator.NewEMA(p.EMASlow) },
+		func(ind indicator.Indicator) { out.emaSlow = ind.(*indicator.EMA).Value() })
+	read(fmt.Sprintf("rsi_%d", p.RSIPeriod),
+		func() indicator.Indicator { return indicator.NewRSI(p.RSIPeriod) },
+		func(ind indicator.Indicator) {
+			out.rsi, out.prevRSI = ind.(*indicator.RSI).Value(), ind.(*indicator.RSI).Prev()
+		})
+	read(fmt.Sprintf("atr_%d", p.ATRPeriod),
+		func() indicator.Indicator { return indicator.NewATR(p.ATRPeriod) },
+		func(ind indicator.Indicator) { out.atr = ind.(*indicator.ATR).Value() })
+	read(fmt.Sprintf("sma_%d", p.SMAFast),
+		func() indicator.Indicator { return indicator.NewSMA(p.SMAFast) },
+		func(ind indicator.Indicator) { out.smaFast = ind.(*indicator.SMA).Value() })
+	read(fmt.Sprintf("sma_%d", p.SMASlow),
+		func() indicator.Indicator { return indicator.NewSMA(p.SMASlow) },
+		func(ind indicator.Indicator) { out.smaSlow = ind.(*indicator.SMA).Value() })
+	return out, err
 }
 
-func (ic *IndicatorCalculator) setCache(symbol, key string, value interface{}) {
-	ic.cacheMutex.Lock()
-	defer ic.cacheMutex.Unlock()
-
-	if _, ok := ic.Cache[symbol]; !ok {
-		ic.Cache[symbol] = make(map[string]interface{})
+// warmUp прогоняет ind по закрытым свечам истории символа.
+func (ic *IndicatorCalculator) warmUp(symbol string, ind indicator.Indicator) (*trackedIndicator, error) {
+	history := ic.History
+	if history <= 0 {
+		history = DefaultIndicatorHistory
 	}
-
-	ic.Cache[symbol][key] = value
-	ic.Cache[symbol][key+"_time"] = time.Now()
+	klines, err := ic.Exchange.GetKlines(symbol, ic.interval(), uint64(max(history, ind.WarmUp()+1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+	// Порядок свечей в ответе зависит от биржи; незакрытой считается свеча, чей интервал ещё не истёк.
+	sort.Slice(klines, func(i, j int) bool { return klines[i].Start < klines[j].Start })
+	now := time.Now().UnixMilli()
+	indicatorStep := ic.step()
+	t := &trackedIndicator{Indicator: ind}
+	for _, k := range klines {
+		if k.Start+indicatorStep > now || k.Start <= t.last {
+			continue
+		}
+		ind.Update(k)
+		t.last = k.Start
+	}
+	return t, nil
 }
 
-// ClearCache очищает кеш для конкретного символа
+// ClearCache сбрасывает индикаторы символа: при следующем запросе они прогреваются заново.
 func (ic *IndicatorCalculator) ClearCache(symbol string) {
-	ic.cacheMutex.Lock()
-	defer ic.cacheMutex.Unlock()
-	delete(ic.Cache, symbol)
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	delete(ic.symbols, symbol)
 }
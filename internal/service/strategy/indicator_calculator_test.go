@@ -0,0 +1,199 @@
+package strategy
+
+import (
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/service/marketdata"
+	"github.com/markcheno/go-talib"
+	"testing"
+	"time"
+)
+
+// historyExchange отдаёт из klines последние limit свечей от новых к старым, как Bybit.
+type historyExchange struct {
+	interfaces.Exchange
+	klines   []model.KlineData
+	interval string // интервал последнего запроса
+}
+
+func (e *historyExchange) GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error) {
+	e.interval = interval
+	from := max(len(e.klines)-int(limit), 0)
+	out := make([]model.KlineData, 0, len(e.klines)-from)
+	for i := len(e.klines) - 1; i >= from; i-- {
+		out = append(out, e.klines[i])
+	}
+	return out, nil
+}
+
+// restExchange ведёт себя как ответ Bybit: последние limit свечей от новых к старым без символа,
+// самая новая ещё формируется (Confirm=false), Start в миллисекундах.
+type restExchange struct {
+	interfaces.Exchange
+	klines  []model.KlineData // по возрастанию, последняя — формирующаяся
+	symbols []string          // символы запросов
+}
+
+func (e *restExchange) GetKlines(symbol, interval string, limit uint64) ([]model.KlineData, error) {
+	e.symbols = append(e.symbols, symbol)
+	from := max(len(e.klines)-int(limit), 0)
+	out := make([]model.KlineData, 0, len(e.klines)-from)
+	for i := len(e.klines) - 1; i >= from; i-- {
+		k := e.klines[i]
+		k.Symbol = ""
+		k.Confirm = i < len(e.klines)-1
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// fiveMinuteKlines возвращает n закрытых пятиминутных свечей, последняя из которых закрылась до now.
+func fiveMinuteKlines(n int) []model.KlineData {
+	step := 5 * time.Minute
+	base := time.Now().Truncate(step).Add(-time.Duration(n) * step)
+	klines := testKlines(n, func(i int) float64 { return 10 + float64(i%7) })
+	for i := range klines {
+		klines[i].Start = base.Add(time.Duration(i) * step).UnixMilli()
+		klines[i].Interval = "5"
+		klines[i].Confirm = true
+	}
+	return klines
+}
+
+// inSeconds возвращает копию свечей со Start в секундах, как их отдаёт interfaces.Service.
+func inSeconds(klines []model.KlineData) []model.KlineData {
+	out := make([]model.KlineData, len(klines))
+	for i, k := range klines {
+		k.Start /= 1000
+		out[i] = k
+	}
+	return out
+}
+
+func TestSignalDetectorUsesIndicatorCalculator(t *testing.T) {
+	klines := fiveMinuteKlines(400)
+	exchange := &historyExchange{klines: klines[:300]}
+	ic := NewIndicatorCalculator(exchange, nil, "5")
+	ic.History = 250
+	sd := NewSignalDetector()
+	sd.Indicators = ic
+	p := sd.params()
+
+	// Первый запрос прогревает индикаторы историей klines[50:300].
+	if _, err := ic.signalIndicators("BTCUSDT", p, klines[299].Start); err != nil {
+		t.Fatalf("warm-up: %v", err)
+	}
+	if exchange.interval != "5" {
+		t.Fatalf("history requested for interval %q, want 5", exchange.interval)
+	}
+	for _, k := range klines[300:] {
+		other := k
+		other.Interval = "1"
+		other.Close *= 2
+		ic.OnKline(other) // свечи другого интервала не учитываются
+		ic.OnKline(k)
+	}
+
+	closes := make([]float64, 0, 350)
+	highs := make([]float64, 0, 350)
+	lows := make([]float64, 0, 350)
+	for _, k := range klines[50:] {
+		closes = append(closes, k.Close)
+		highs = append(highs, k.High)
+		lows = append(lows, k.Low)
+	}
+	last := len(closes) - 1
+	rsi := talib.Rsi(closes, p.RSIPeriod)
+	want := signalIndicators{
+		emaFast:   talib.Ema(closes, p.EMAFast)[last],
+		emaSlow:   talib.Ema(closes, p.EMASlow)[last],
+		rsi:       rsi[last],
+		prevRSI:   rsi[last-1],
+		atr:       talib.Atr(highs, lows, closes, p.ATRPeriod)[last],
+		smaFast:   talib.Sma(closes, p.SMAFast)[last],
+		smaSlow:   talib.Sma(closes, p.SMASlow)[last],
+		avgVolume: sd.calculateAverageVolume(klines[len(klines)-p.VolumeWindow-1 : len(klines)-1]),
+	}
+	if got := sd.indicators(inSeconds(klines), p); got != want {
+		t.Fatalf("indicators = %+v, want calculator values %+v", got, want)
+	}
+
+	// Для свечи, которая не последняя у калькулятора, индикаторы пересчитываются по klines.
+	sd.Indicators = nil
+	stale := sd.indicators(inSeconds(klines[:399]), p)
+	sd.Indicators = ic
+	if got := sd.indicators(inSeconds(klines[:399]), p); got != stale {
+		t.Fatalf("indicators for a kline the calculator has passed = %+v, want the klines recompute %+v", got, stale)
+	}
+}
+
+func TestIndicatorCalculatorResetsAfterGap(t *testing.T) {
+	klines := fiveMinuteKlines(60)
+	exchange := &historyExchange{klines: klines[:40]}
+	ic := NewIndicatorCalculator(exchange, nil, "5")
+
+	if _, err := ic.SMA("BTCUSDT", 10); err != nil {
+		t.Fatalf("SMA: %v", err)
+	}
+	ic.OnKline(klines[40])
+	ic.OnKline(klines[42]) // пропущена свеча 41: индикатор сбрасывается
+
+	exchange.klines = klines[:43]
+	got, err := ic.SMA("BTCUSDT", 10)
+	if err != nil {
+		t.Fatalf("SMA after gap: %v", err)
+	}
+	closes := make([]float64, 43)
+	for i, k := range klines[:43] {
+		closes[i] = k.Close
+	}
+	if want := talib.Sma(closes, 10)[42]; got != want {
+		t.Fatalf("SMA after gap = %v, want %v from fresh history", got, want)
+	}
+}
+
+func TestSignalDetectorUsesCalculatorThroughMarketData(t *testing.T) {
+	closed := fiveMinuteKlines(400)
+	forming := closed[len(closed)-1]
+	forming.Start += (5 * time.Minute).Milliseconds()
+	forming.Close *= 2
+	exchange := &restExchange{klines: append(append([]model.KlineData(nil), closed...), forming)}
+
+	ic := NewIndicatorCalculator(exchange, nil, "5")
+	ic.History = 250
+	sd := NewSignalDetector()
+	sd.Indicators = ic
+	p := sd.params()
+	md := &marketdata.ByBitMarketData{Client: exchange}
+
+	klines, ok := md.GetRecentKlines("BTCUSDT", "5", 300)
+	if !ok {
+		t.Fatal("GetRecentKlines failed")
+	}
+	last := klines[len(klines)-1]
+	if last.Start*1000 != closed[len(closed)-1].Start || last.Symbol != "BTCUSDT" || !last.Confirm {
+		t.Fatalf("last kline %+v, want the last closed candle of BTCUSDT", last)
+	}
+
+	got := sd.indicators(klines, p)
+	for _, symbol := range exchange.symbols {
+		if symbol != "BTCUSDT" {
+			t.Fatalf("history requested for symbol %q", symbol)
+		}
+	}
+	if len(exchange.symbols) < 2 {
+		t.Fatalf("calculator did not warm up from history: %d requests", len(exchange.symbols))
+	}
+
+	// История калькулятора — 249 закрытых свечей из 250 последних: формирующаяся не учитывается.
+	var closes []float64
+	for _, k := range closed[151:] {
+		closes = append(closes, k.Close)
+	}
+	if want := talib.Ema(closes, p.EMASlow)[len(closes)-1]; got.emaSlow != want {
+		t.Fatalf("EMA%d = %v, want calculator value %v", p.EMASlow, got.emaSlow, want)
+	}
+	if want := talib.Sma(closes, p.SMAFast)[len(closes)-1]; got.smaFast != want {
+		t.Fatalf("SMA%d = %v, want %v", p.SMAFast, got.smaFast, want)
+	}
+}
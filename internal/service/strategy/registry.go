@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory создаёт новый экземпляр стратегии.
+type Factory func(deps Dependencies) Strategy
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register добавляет стратегию в реестр. Обычно вызывается из init() файла стратегии.
+// Повторная регистрация того же имени — ошибка программиста, поэтому вызывает panic.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("strategy: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("strategy: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New создаёт стратегию по имени.
+func New(name string, deps Dependencies) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (available: %v)", name, Names())
+	}
+	return factory(deps), nil
+}
+
+// Names возвращает отсортированный список зарегистрированных стратегий.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
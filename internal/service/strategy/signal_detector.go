@@ -5,139 +5,277 @@ import (
 	"github.com/markcheno/go-talib"
 	"log"
 	"math"
+	"time"
 )
 
+// SignalDetector проверяет условия входа. Нулевое значение использует model.DefaultSignalParams.
 type SignalDetector struct {
-	volumeWindow      int
-	volumeSpikeFactor float64
-	lookbackPeriod    int
+	Params model.SignalParams
+	// Indicators — необязателен: инкрементальные индикаторы по всей истории свечей того же интервала.
+	// Без него, а также пока калькулятор не учёл последнюю свечу, индикаторы пересчитываются по klines.
+	Indicators *IndicatorCalculator
 }
 
 func NewSignalDetector() *SignalDetector {
-	return &SignalDetector{
-		volumeWindow:      15,
-		volumeSpikeFactor: 1.5,
-		lookbackPeriod:    5,
+	return NewSignalDetectorWithParams(model.DefaultSignalParams())
+}
+
+func NewSignalDetectorWithParams(params model.SignalParams) *SignalDetector {
+	return &SignalDetector{Params: params}
+}
+
+func (sd *SignalDetector) params() model.SignalParams {
+	if sd.Params == (model.SignalParams{}) {
+		return model.DefaultSignalParams()
+	}
+	return sd.Params
+}
+
+// atrWindow — окно ATR для SHORT: на 6 свечей длиннее периода (20 свечей при периоде 14).
+func atrWindow(p model.SignalParams) int {
+	return p.ATRPeriod + 6
+}
+
+// signalIndicators — индикаторы последней свечи, общие для проверок LONG и SHORT одной оценки.
+// Нулевое значение означает, что для индикатора не хватило свечей.
+type signalIndicators struct {
+	emaFast   float64
+	emaSlow   float64
+	rsi       float64
+	prevRSI   float64 // RSI предыдущей свечи для проверки LONG
+	avgVolume float64 // средний объём VolumeWindow свечей перед последней
+	atr       float64
+	smaFast   float64
+	smaSlow   float64
+}
+
+// indicators считает индикаторы по klines один раз для обеих проверок.
+func (sd *SignalDetector) indicators(klines []model.KlineData, p model.SignalParams) signalIndicators {
+	n := len(klines)
+	// Калькулятор ведёт только закрытые свечи: формирующаяся свеча или свеча без символа
+	// считаются по klines, не запрашивая историю.
+	if current := lastKline(klines); sd.Indicators != nil && current.Confirm && current.Symbol != "" {
+		// Свечи стратегий приходят из interfaces.Service со Start в секундах, калькулятор хранит миллисекунды.
+		ind, err := sd.Indicators.signalIndicators(current.Symbol, p, current.Start*1000)
+		if err == nil {
+			if n > p.VolumeWindow {
+				ind.avgVolume = sd.calculateAverageVolume(klines[n-p.VolumeWindow-1 : n-1])
+			}
+			return ind
+		}
+		log.Printf("[Сигнал] Индикаторы калькулятора недоступны, расчёт по %d свечам: %v", n, err)
+	}
+
+	var ind signalIndicators
+	closes := sd.getClosingPrices(klines)
+	if n >= p.EMAFast {
+		ind.emaFast = talib.Ema(closes, p.EMAFast)[n-1]
+	}
+	if n >= p.EMASlow {
+		ind.emaSlow = talib.Ema(closes, p.EMASlow)[n-1]
+	}
+	if n > p.RSIPeriod+1 {
+		rsi := talib.Rsi(closes, p.RSIPeriod)
+		ind.rsi, ind.prevRSI = rsi[n-1], rsi[n-2]
 	}
+	if n > p.VolumeWindow {
+		ind.avgVolume = sd.calculateAverageVolume(klines[n-p.VolumeWindow-1 : n-1])
+	}
+	if n >= atrWindow(p) {
+		ind.atr = sd.getATR(klines[n-atrWindow(p):], p.ATRPeriod)
+	}
+	if n >= p.SMAFast {
+		ind.smaFast = lastValue(talib.Sma(closes[n-p.SMAFast:], p.SMAFast))
+	}
+	if n >= p.SMASlow {
+		ind.smaSlow = lastValue(talib.Sma(closes[n-p.SMASlow:], p.SMASlow))
+	}
+	return ind
 }
 
-func (sd *SignalDetector) CheckLongSignal(klines []model.KlineData) bool {
+// newResult создаёт результат проверки стороны side и заполняет все индикаторы ind,
+// чтобы записи журнала были сравнимы независимо от проверки, на которой сигнал отклонён.
+func newResult(side string, klines []model.KlineData, ind signalIndicators) model.SignalResult {
+	res := model.SignalResult{
+		Side:    side,
+		EMAFast: ind.emaFast,
+		EMASlow: ind.emaSlow,
+		RSI:     ind.rsi,
+		ATR:     ind.atr,
+		SMAFast: ind.smaFast,
+		SMASlow: ind.smaSlow,
+	}
 	n := len(klines)
-	if n < 200 {
-		return false
+	if n == 0 {
+		return res
 	}
+	current := klines[n-1]
+	res.Symbol = current.Symbol
+	res.Price = current.Close
+	if current.Start > 0 {
+		// Свечи стратегий приходят из interfaces.Service со Start в секундах.
+		res.KlineStart = time.Unix(current.Start, 0).UTC()
+	}
+	// Отношение объёмов только для журнала: при нулевом среднем оно не определено.
+	if ind.avgVolume > 0 {
+		res.VolumeRatio = current.Volume / ind.avgVolume
+	}
+	return res
+}
 
-	closes := make([]float64, n)
-	for i := range klines {
-		closes[i] = klines[i].Close
+func lastKline(klines []model.KlineData) model.KlineData {
+	if len(klines) == 0 {
+		return model.KlineData{}
 	}
+	return klines[len(klines)-1]
+}
+
+func lastValue(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
+
+// CheckSignals проверяет условия входа в LONG и SHORT по последней свече klines,
+// считая индикаторы один раз для обеих сторон.
+func (sd *SignalDetector) CheckSignals(klines []model.KlineData) (long, short model.SignalResult) {
+	p := sd.params()
+	ind := sd.indicators(klines, p)
+	return sd.checkLong(klines, p, ind), sd.checkShort(klines, p, ind)
+}
 
-	ema50 := talib.Ema(closes, 50)
-	ema200 := talib.Ema(closes, 200)
-	rsi := talib.Rsi(closes, 14)
+// CheckLongSignal проверяет условия входа в LONG по последней свече klines.
+func (sd *SignalDetector) CheckLongSignal(klines []model.KlineData) model.SignalResult {
+	p := sd.params()
+	return sd.checkLong(klines, p, sd.indicators(klines, p))
+}
+
+// CheckShortSignal проверяет условия входа в SHORT по последней свече klines.
+func (sd *SignalDetector) CheckShortSignal(klines []model.KlineData) model.SignalResult {
+	p := sd.params()
+	return sd.checkShort(klines, p, sd.indicators(klines, p))
+}
+
+func (sd *SignalDetector) checkLong(klines []model.KlineData, p model.SignalParams, ind signalIndicators) model.SignalResult {
+	res := newResult("long", klines, ind)
+	n := len(klines)
+	if n < p.EMASlow || n < p.LongLookback+1 || n < p.RSIPeriod+2 {
+		res.Fail(model.SignalCheckData)
+		return res
+	}
+	res.Pass(model.SignalCheckData)
 
-	latest := n - 1
-	current := klines[latest]
+	current := klines[n-1]
 	price := current.Close
 
-	if ema50[latest] <= ema200[latest] {
-		log.Printf("[Сигнал] EMA50 <= EMA200 (%.2f <= %.2f) — тренд не подтвержден", ema50[latest], ema200[latest])
-		return false
+	if res.EMAFast <= res.EMASlow {
+		log.Printf("[Сигнал] EMA%d <= EMA%d (%.2f <= %.2f) — тренд не подтвержден", p.EMAFast, p.EMASlow, res.EMAFast, res.EMASlow)
+		res.Fail(model.SignalCheckEMATrend)
+		return res
 	}
+	res.Pass(model.SignalCheckEMATrend)
 
-	sd.lookbackPeriod = 7
-	emaDiff := math.Abs(price - ema50[latest])
-	if price > ema50[latest] || emaDiff > ema50[latest]*0.015 {
-		log.Printf("[Сигнал] Цена не на адекватном откате к EMA50 (%.2f > %.2f)", price, ema50[latest])
-		return false
+	emaDiff := math.Abs(price - res.EMAFast)
+	if price > res.EMAFast || emaDiff > res.EMAFast*p.PullbackPercent {
+		log.Printf("[Сигнал] Цена не на адекватном откате к EMA%d (%.2f > %.2f)", p.EMAFast, price, res.EMAFast)
+		res.Fail(model.SignalCheckPullback)
+		return res
 	}
+	res.Pass(model.SignalCheckPullback)
 
-	if rsi[latest] > rsi[latest-1] && rsi[latest] < 50 {
-		log.Printf("[Сигнал] RSI вне диапазона: %.2f", rsi[latest])
-		return false
+	if res.RSI > ind.prevRSI && res.RSI < 50 {
+		log.Printf("[Сигнал] RSI вне диапазона: %.2f", res.RSI)
+		res.Fail(model.SignalCheckRSI)
+		return res
 	}
+	res.Pass(model.SignalCheckRSI)
 
-	if !sd.isLocalLowest(current.Low, klines[n-sd.lookbackPeriod-1:n-1]) {
+	if !sd.isLocalLowest(current.Low, klines[n-p.LongLookback-1:n-1]) {
 		log.Printf("[Сигнал] Не локальный минимум: %.2f", current.Low)
-		return false
+		res.Fail(model.SignalCheckLocalLow)
+		return res
 	}
 	log.Printf("[Сигнал] Локальный минимум подтвержден: %.2f", current.Low)
+	res.Pass(model.SignalCheckLocalLow)
 
 	if current.Close <= current.Open {
 		log.Printf("[Сигнал] Не бычья свеча: open=%.2f, close=%.2f", current.Open, current.Close)
-		return false
+		res.Fail(model.SignalCheckBullishCandle)
+		return res
 	}
 	log.Printf("[Сигнал] Бычья свеча подтверждена: open=%.2f, close=%.2f", current.Open, current.Close)
+	res.Pass(model.SignalCheckBullishCandle)
 
 	log.Printf("[Сигнал] ✅ LONG сигнал подтвержден")
-	return true
+	return res
 }
 
-func (sd *SignalDetector) CheckShortSignal(klines []model.KlineData) bool {
+func (sd *SignalDetector) checkShort(klines []model.KlineData, p model.SignalParams, ind signalIndicators) model.SignalResult {
+	res := newResult("short", klines, ind)
+	required := max(p.VolumeWindow+1, p.ShortLookback+1, p.SMASlow, p.SMAFast, atrWindow(p))
 	n := len(klines)
-	if n < sd.volumeWindow+1 {
+	if n < required {
 		log.Printf("[Сигнал] Недостаточно данных для SHORT. Имеется: %d, требуется: %d",
-			n, sd.volumeWindow+1)
-		return false
+			n, required)
+		res.Fail(model.SignalCheckData)
+		return res
 	}
+	res.Pass(model.SignalCheckData)
 
 	current := klines[n-1]
 	log.Printf("[Сигнал] Анализ SHORT для %s: Открытие=%.2f Макс=%.2f Мин=%.2f Закрытие=%.2f Объем=%.2f",
 		current.Symbol, current.Open, current.High, current.Low, current.Close, current.Volume)
 
-	avgVolume := sd.calculateAverageVolume(klines[n-sd.volumeWindow-1 : n-1])
-	volumeRatio := current.Volume / avgVolume
-
-	if volumeRatio < sd.volumeSpikeFactor {
+	volumeRatio := current.Volume / ind.avgVolume
+	if volumeRatio < p.VolumeSpikeFactor {
 		log.Printf("[Сигнал] Объем недостаточен: %.2f < %.2f (требуется x%.1f)",
-			current.Volume, avgVolume, sd.volumeSpikeFactor)
-		return false
+			current.Volume, ind.avgVolume, p.VolumeSpikeFactor)
+		res.Fail(model.SignalCheckVolume)
+		return res
 	}
 	log.Printf("[Сигнал] Объем ОК: %.2f > %.2f (x%.1f)",
-		current.Volume, avgVolume, volumeRatio)
+		current.Volume, ind.avgVolume, volumeRatio)
+	res.Pass(model.SignalCheckVolume)
 
-	if !sd.isLocalHighest(current.High, klines[n-sd.lookbackPeriod-1:n-1]) {
+	if !sd.isLocalHighest(current.High, klines[n-p.ShortLookback-1:n-1]) {
 		log.Printf("[Сигнал] Не является локальным максимумом (макс=%.2f)", current.High)
-		return false
+		res.Fail(model.SignalCheckLocalHigh)
+		return res
 	}
 	log.Printf("[Сигнал] Локальный максимум подтвержден (макс=%.2f)", current.High)
+	res.Pass(model.SignalCheckLocalHigh)
 
 	if current.Close >= current.Open {
 		log.Printf("[Сигнал] Не медвежья свеча (открытие=%.2f, закрытие=%.2f)",
 			current.Open, current.Close)
-		return false
+		res.Fail(model.SignalCheckBearishCandle)
+		return res
 	}
 	log.Printf("[Сигнал] Медвежья свеча подтверждена (открытие=%.2f, закрытие=%.2f)",
 		current.Open, current.Close)
+	res.Pass(model.SignalCheckBearishCandle)
 
-	closes := sd.getClosingPrices(klines[n-50 : n])
-	sma20 := talib.Sma(closes[30:], 20)
-	sma50 := talib.Sma(closes, 50)
-
-	if len(sma20) == 0 || len(sma50) == 0 {
-		log.Printf("[Сигнал] Ошибка расчета SMA20/SMA50")
-		return false
-	}
-	lastSma20 := sma20[len(sma20)-1]
-	lastSma50 := sma50[len(sma50)-1]
-
-	if !(current.Close < lastSma20 && lastSma20 < lastSma50) {
-		log.Printf("[Сигнал] Тренд не подтверждён (Close=%.2f, SMA20=%.2f, SMA50=%.2f)",
-			current.Close, lastSma20, lastSma50)
-		return false
+	if !(current.Close < res.SMAFast && res.SMAFast < res.SMASlow) {
+		log.Printf("[Сигнал] Тренд не подтверждён (Close=%.2f, SMA%d=%.2f, SMA%d=%.2f)",
+			current.Close, p.SMAFast, res.SMAFast, p.SMASlow, res.SMASlow)
+		res.Fail(model.SignalCheckSMATrend)
+		return res
 	}
-	log.Printf("[Сигнал] Тренд подтвержден (Close=%.2f < SMA20=%.2f < SMA50=%.2f)",
-		current.Close, lastSma20, lastSma50)
+	log.Printf("[Сигнал] Тренд подтвержден (Close=%.2f < SMA%d=%.2f < SMA%d=%.2f)",
+		current.Close, p.SMAFast, res.SMAFast, p.SMASlow, res.SMASlow)
+	res.Pass(model.SignalCheckSMATrend)
 
-	atr := sd.getATR(klines[n-20:], 14)
-	if atr < 0.5 {
-		log.Printf("[Сигнал] Низкая волатильность (ATR=%.2f)", atr)
-		return false
+	if res.ATR < p.MinATR {
+		log.Printf("[Сигнал] Низкая волатильность (ATR=%.2f)", res.ATR)
+		res.Fail(model.SignalCheckMinATR)
+		return res
 	}
-	log.Printf("[Сигнал] ATR подтверждён: %.2f", atr)
+	log.Printf("[Сигнал] ATR подтверждён: %.2f", res.ATR)
+	res.Pass(model.SignalCheckMinATR)
 
 	log.Printf("[Сигнал] СИЛЬНЫЙ СИГНАЛ НА ПРОДАЖУ")
-	return true
+	return res
 }
 
 func (sd *SignalDetector) isLocalLowest(currentLow float64, previousKlines []model.KlineData) bool {
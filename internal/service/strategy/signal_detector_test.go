@@ -0,0 +1,58 @@
+package strategy
+
+import (
+	"bybit-bot/internal/model"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// testKlines возвращает n свечей с колеблющейся ценой и объёмом volume(i).
+func testKlines(n int, volume func(i int) float64) []model.KlineData {
+	klines := make([]model.KlineData, n)
+	for i := range klines {
+		price := 100 + 5*math.Sin(float64(i)/3) + float64(i)/10
+		klines[i] = model.KlineData{
+			Symbol: "BTCUSDT",
+			Start:  int64(1_700_000_000 + 60*i),
+			Open:   price - 0.5,
+			High:   price + 1,
+			Low:    price - 1,
+			Close:  price,
+			Volume: volume(i),
+		}
+	}
+	return klines
+}
+
+func TestCheckSignalsMatchesSeparateChecks(t *testing.T) {
+	sd := NewSignalDetector()
+	klines := testKlines(60, func(i int) float64 { return 10 + float64(i%7) })
+	for n := 1; n <= len(klines); n++ {
+		long, short := sd.CheckSignals(klines[:n])
+		if want := sd.CheckLongSignal(klines[:n]); !reflect.DeepEqual(long, want) {
+			t.Fatalf("n=%d: long %+v, want %+v", n, long, want)
+		}
+		if want := sd.CheckShortSignal(klines[:n]); !reflect.DeepEqual(short, want) {
+			t.Fatalf("n=%d: short %+v, want %+v", n, short, want)
+		}
+	}
+}
+
+func TestShortVolumeCheckWithZeroAverage(t *testing.T) {
+	sd := NewSignalDetector()
+	klines := testKlines(60, func(i int) float64 {
+		if i == 59 {
+			return 5
+		}
+		return 0
+	})
+	_, short := sd.CheckSignals(klines)
+	if !slices.Contains(short.Passed, model.SignalCheckVolume) {
+		t.Fatalf("volume check failed on a spike after zero volume: %+v", short)
+	}
+	if short.VolumeRatio != 0 {
+		t.Fatalf("VolumeRatio = %v, want 0 when the average volume is zero", short.VolumeRatio)
+	}
+}
@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"bybit-bot/internal/config"
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"time"
+)
+
+// Strategy — торговая стратегия, работающая с одним символом.
+// Экземпляр создаётся фабрикой из реестра отдельно для каждого символа.
+// Стратегия получает рыночные события от engine.Engine и выражает решения
+// через намерения (model.OrderIntent), не обращаясь к бирже напрямую.
+type Strategy interface {
+	Name() string
+	// Init вызывается один раз перед запуском.
+	Init(symbol, category string) error
+	// OnKline получает каждую новую закрытую свечу.
+	OnKline(kline model.KlineData)
+	// OnOrderbook получает актуальный ордербук.
+	OnOrderbook(orderBook *model.OrderbookData)
+	// OnFill получает исполнения ордеров по символу стратегии.
+	OnFill(execution model.ExecutionUpdate)
+	// OnTick вызывается периодически: здесь стратегия принимает решение о входе.
+	OnTick(now time.Time)
+}
+
+// Dependencies — порты, через которые стратегия видит рынок и отправляет намерения.
+// В реальной торговле их реализуют клиент Bybit и WSListener, в бэктесте — engine.SimExchange.
+type Dependencies struct {
+	MarketData interfaces.Service
+	Orderbook  interfaces.OrderbookSource
+	Trading    interfaces.Executor
+	Orders     interfaces.IntentHandler
+	Params     config.StrategyConfig
+	Interval   string               // интервал свечей сигналов (trading.interval или backtest.interval); пусто — model.DefaultSignalInterval
+	Indicators *IndicatorCalculator // необязателен: индикаторы по свечам Interval, общие для всех символов
+
+	Signals      SignalJournal // необязателен: журнал проверок сигналов
+	SignalSource string        // источник записей журнала: model.SignalSourceLive, SignalSourcePaper или SignalSourceBacktest
+}
+
+// SignalJournal сохраняет результаты проверок сигналов (repository.SignalRepository).
+type SignalJournal interface {
+	InsertSignal(s *model.SignalResult) error
+}
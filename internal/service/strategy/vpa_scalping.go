@@ -1,69 +1,149 @@
 package strategy
 
 import (
-	"bybit-bot/internal/client"
 	"bybit-bot/internal/interfaces"
-	"bybit-bot/internal/repository"
-	"bybit-bot/internal/service/account"
+	"bybit-bot/internal/model"
 	"bybit-bot/internal/service/event"
 	"bybit-bot/internal/service/exchange"
 	"bybit-bot/internal/utils"
 	"log"
 	"math"
+	"strings"
 	"time"
 )
 
 type VPAScalping struct {
-	OrderRepository  repository.OrderRepository
-	WalletRepository *repository.WalletRepository
-	Formatter        *utils.Formatter
-	BalanceService   *account.BalanceService
-	PriceCalculator  *exchange.PriceCalculator
-	MarketData       interfaces.Service
-	Bybit            *client.ByBit
-	WSListener       *event.WSListener
-	SignalDetector   *SignalDetector
-	Trading          interfaces.Executor
+	MarketData     interfaces.Service
+	Orderbook      interfaces.OrderbookSource
+	Trading        interfaces.Executor
+	Orders         interfaces.IntentHandler
+	SignalDetector *SignalDetector
+	Interval       string // интервал свечей сигналов
+	SLTP           model.SLTPParams
+	Signals        SignalJournal // необязателен: журнал проверок сигналов
+	SignalSource   string
+
+	MaxDataAge           time.Duration // максимальный возраст данных WebSocket
+	MaxMidPriceDeviation float64       // допустимое отклонение midPrice от цены входа
+
+	symbol   string
+	category string
+	now      time.Time // время последнего тика: реальное или время симуляции
+}
+
+func init() {
+	Register(model.VPAScalpingStrategyName, func(deps Dependencies) Strategy {
+		detector := NewSignalDetectorWithParams(deps.Params.Signal)
+		detector.Indicators = deps.Indicators
+		return &VPAScalping{
+			MarketData:     deps.MarketData,
+			Orderbook:      deps.Orderbook,
+			Trading:        deps.Trading,
+			Orders:         deps.Orders,
+			SignalDetector: detector,
+			Interval:       deps.Interval,
+			SLTP:           deps.Params.SLTP,
+			Signals:        deps.Signals,
+			SignalSource:   deps.SignalSource,
+
+			MaxDataAge:           deps.Params.MaxDataAge.Duration,
+			MaxMidPriceDeviation: deps.Params.MaxMidPriceDeviation,
+		}
+	})
 }
 
 // Параметры стратегии
 const (
-	VolumeWindow   = 120 // число свечей для расчёта среднего объёма
-	LookbackPeriod = 100 // число предыдущих свечей для оценки локального минимума/максимума
+	VolumeWindow   = 120              // число свечей для расчёта среднего объёма
+	LookbackPeriod = 100              // число предыдущих свечей для оценки локального минимума/максимума
+	MaxWSDataAge   = 30 * time.Second // максимальный возраст данных WebSocket по умолчанию
+	MaxMidPriceDev = 0.002            // допустимое отклонение midPrice по умолчанию
 )
 
+func (s *VPAScalping) Name() string {
+	return model.VPAScalpingStrategyName
+}
+
+func (s *VPAScalping) Init(symbol, category string) error {
+	s.symbol = symbol
+	s.category = category
+	if s.MaxDataAge <= 0 {
+		s.MaxDataAge = MaxWSDataAge
+	}
+	if s.MaxMidPriceDeviation <= 0 {
+		s.MaxMidPriceDeviation = MaxMidPriceDev
+	}
+	if s.Interval == "" {
+		s.Interval = model.DefaultSignalInterval
+	}
+	if s.SLTP == (model.SLTPParams{}) {
+		s.SLTP = model.DefaultSLTPParams()
+	}
+	return nil
+}
+
+// OnKline не используется: стратегия сама запрашивает свечи интервала Interval при каждом тике.
+func (s *VPAScalping) OnKline(model.KlineData) {}
+
+// OnOrderbook не используется: ордербук читается из источника непосредственно перед входом.
+func (s *VPAScalping) OnOrderbook(*model.OrderbookData) {}
+
+func (s *VPAScalping) OnFill(execution model.ExecutionUpdate) {
+	log.Printf("[%s] Исполнение ордера %s: %s %v по %v",
+		s.symbol, execution.OrderID, execution.Side, execution.ExecQty, execution.ExecPrice)
+}
+
+func (s *VPAScalping) OnTick(now time.Time) {
+	s.now = now
+	s.Make(s.symbol, s.category)
+}
+
 func (s *VPAScalping) Make(symbol, category string) {
 	/*if !s.IsTradingTime() {
 		log.Printf("Вне торгового времени: пропускаем %s", symbol)
 		return
 	}*/
-	openOrders, err := s.Bybit.GetOpenOrders(category, symbol)
+	if !s.Orderbook.IsHealthy(s.MaxDataAge) {
+		log.Printf("Пропускаем %s: данные WebSocket устарели или соединение потеряно", symbol)
+		return
+	}
+	openCount, err := s.Trading.CountOpenOrders(category, symbol)
 	if err != nil {
 		log.Printf("Ошибка получения открытых ордеров для %s: %v", symbol, err)
 		return
 	}
 
-	if openOrders != nil && len(openOrders.Orders) > 0 {
-		log.Printf("Пропускаем %s: ошибка=%v, открытых ордеров=%d", symbol, err, len(openOrders.Orders))
+	if openCount > 0 {
+		log.Printf("Пропускаем %s: открытых ордеров=%d", symbol, openCount)
 		return
 	}
 
-	klines, ok := s.MarketData.GetRecentKlines(symbol, "30", VolumeWindow+LookbackPeriod)
+	required := max(VolumeWindow+LookbackPeriod, s.SignalDetector.params().EMASlow)
+	klines, ok := s.MarketData.GetRecentKlines(symbol, s.Interval, required)
 	if !ok {
 		log.Printf("Недостаточно данных свечей для %s", symbol)
 		return
 	}
 
-	orderBook, ok := s.WSListener.GetOrderbookByTopic("orderbook.50.BTCUSDT")
+	orderBook, ok := s.Orderbook.GetOrderbookByTopic(event.OrderbookTopic(symbol))
 	if !ok || len(orderBook.Bids) == 0 || len(orderBook.Asks) == 0 {
 		log.Printf("Недостаточно данных ордербука для %s", symbol)
 		return
 	}
-	isLong := s.SignalDetector.CheckLongSignal(klines)
-	log.Printf("isLong %s", isLong)
-	isShort := s.SignalDetector.CheckShortSignal(klines)
-	log.Printf("isShort %s", isShort)
-	if !isLong && !isShort {
+	now := s.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if orderBook.IsStaleAt(now, s.MaxDataAge) {
+		log.Printf("Ордербук %s устарел: последнее обновление %s", symbol, orderBook.UpdatedAt().Format(time.RFC3339))
+		return
+	}
+	long, short := s.SignalDetector.CheckSignals(klines)
+	log.Printf("isLong %v", long.OK())
+	log.Printf("isShort %v", short.OK())
+	// Обе проверки попадают в журнал при любом исходе; ордер привязывается к сработавшей.
+	defer s.journal(symbol, now, &long, &short)
+	if !long.OK() && !short.OK() {
 		log.Printf("Нет сигнала для %s", symbol)
 		return
 	}
@@ -80,45 +160,52 @@ func (s *VPAScalping) Make(symbol, category string) {
 
 	entryPrice = currentCandle.Close
 	delta := math.Abs(midPrice-entryPrice) / entryPrice
-	if delta > 0.002 {
+	if delta > s.MaxMidPriceDeviation {
 		log.Printf("Отклонение midPrice слишком велико (%.4f), пропуск", delta)
 		return
 	}
 
-	if isLong {
-		stopLoss, takeProfit = exchange.CalculateSLTP("long", entryPrice, klines)
-		log.Printf("LONG сигнал для %s: Entry=%.2f, StopLoss=%.2f, TakeProfit=%.2f",
-			symbol, entryPrice, stopLoss, takeProfit)
-	} else {
-		stopLoss, takeProfit = exchange.CalculateSLTP("short", entryPrice, klines)
-		log.Printf("SHORT сигнал для %s: Entry=%.2f, StopLoss=%.2f, TakeProfit=%.2f",
-			symbol, entryPrice, stopLoss, takeProfit)
+	signal := &long
+	if !long.OK() {
+		signal = &short
 	}
-
-	_, buyPriceF, sellPriceF, stopLossBuyF, stopLossSellF, takeProfitBuyF, takeProfitSellF, ok := s.BalanceService.CheckAndFormatPrices(
-		category, symbol, entryPrice, entryPrice, stopLoss, stopLoss, takeProfit, takeProfit,
-	)
-	if !ok {
-		return
+	side := signal.Side
+	levels := exchange.PlanSLTP(side, entryPrice, klines, s.SLTP)
+	stopLoss, takeProfit = levels.StopLoss, levels.TakeProfit
+	log.Printf("%s сигнал для %s: Entry=%.2f, StopLoss=%.2f, TakeProfit=%.2f (%s)",
+		strings.ToUpper(side), symbol, entryPrice, stopLoss, takeProfit, levels.Rationale)
+
+	intent := model.OrderIntent{
+		Strategy:   s.Name(),
+		Symbol:     symbol,
+		Category:   category,
+		Side:       side,
+		EntryPrice: entryPrice,
+		StopLoss:   stopLoss,
+		TakeProfit: takeProfit,
+		ATR:        exchange.LastATR(klines, s.SignalDetector.params().ATRPeriod),
+		Reason:     levels.Rationale,
+		CreatedAt:  now,
+	}
+	orderID, err := s.Orders.Submit(intent)
+	if err != nil {
+		log.Printf("Не удалось разместить %s ордер: %v", strings.ToUpper(side), err)
 	}
+	signal.OrderID = orderID
+}
 
-	quantity := s.PriceCalculator.CalculateQuantity(symbol, entryPrice)
-	log.Printf("Рассчитанное количество для %s: %v", symbol, quantity)
-	if !s.BalanceService.CheckBalance(entryPrice, quantity, entryPrice, quantity) {
+// journal сохраняет результаты проверок сигналов в Signals, если журнал задан.
+func (s *VPAScalping) journal(symbol string, now time.Time, results ...*model.SignalResult) {
+	if s.Signals == nil {
 		return
 	}
-
-	if isLong {
-		err := s.Trading.PlaceLimitOrder(symbol, "buy", buyPriceF, quantity, stopLossBuyF, takeProfitBuyF)
-		if err != nil {
-			log.Printf("Не удалось разместить LONG ордер: %v", err)
-			return
-		}
-	} else if isShort {
-		err := s.Trading.PlaceLimitOrder(symbol, "sell", sellPriceF, quantity, stopLossSellF, takeProfitSellF)
-		if err != nil {
-			log.Printf("Не удалось разместить SHORT ордер: %v", err)
-			return
+	for _, res := range results {
+		res.Source = s.SignalSource
+		res.Strategy = s.Name()
+		res.Symbol = symbol
+		res.EvaluatedAt = now
+		if err := s.Signals.InsertSignal(res); err != nil {
+			log.Printf("Ошибка сохранения %s сигнала %s: %v", strings.ToUpper(res.Side), symbol, err)
 		}
 	}
 }
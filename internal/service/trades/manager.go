@@ -0,0 +1,290 @@
+package trades
+
+import (
+	"bybit-bot/internal/engine"
+	"bybit-bot/internal/interfaces"
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"bybit-bot/internal/service/exchange"
+	"bybit-bot/internal/service/positions"
+	"bybit-bot/internal/utils"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TradingStop изменяет TP/SL открытой позиции (client.ByBit). Выставленные частичные TP
+// видны в ListOpenOrders условными ордерами со stopOrderType PartialTakeProfit.
+type TradingStop interface {
+	SetStopLoss(category, symbol string, stopLoss float64) error
+	SetPartialTakeProfit(category, symbol string, price, qty float64) error
+	ListOpenOrders(category, symbol string) ([]model.OrderUpdate, error)
+	CancelOrder(category, symbol, orderID string) error
+}
+
+// stopOrderPartialTakeProfit — stopOrderType частичного TP в ордерах Bybit.
+const stopOrderPartialTakeProfit = "PartialTakeProfit"
+
+// PositionSource отдаёт открытую позицию символа (positions.Service).
+type PositionSource interface {
+	Position(symbol string) (model.PositionRep, bool)
+}
+
+// Manager сопровождает открытые позиции по правилам model.ManageParams: переносит SL
+// в безубыток, подтягивает трейлинг-стоп по цене из WSListener и выставляет частичные TP
+// ордерами режима Partial. Начальный SL берётся из исполненного ордера входа; позиции,
+// открытые без SL (например, принятые сверкой), не сопровождаются.
+type Manager struct {
+	API        TradingStop
+	Positions  PositionSource
+	Orders     repository.OrderRepository
+	Prices     positions.PriceSource
+	MarketData interfaces.Service // необязателен: без него трейлинг по ATR не двигает стоп
+	Limits     interfaces.LimitsProvider
+	Formatter  *utils.Formatter
+	Category   string
+	Symbols    []string
+	Params     model.ManageParams
+	Interval   time.Duration
+	// KlineInterval — интервал свечей для ATR трейлинга.
+	KlineInterval string
+
+	mu    sync.Mutex
+	plans map[string]*managedPlan
+}
+
+type managedPlan struct {
+	plan     *engine.TradePlan
+	openedAt time.Time
+}
+
+// NewManager создаёт менеджер сопровождения позиций.
+func NewManager(api TradingStop, source PositionSource, orders repository.OrderRepository, prices positions.PriceSource,
+	limits interfaces.LimitsProvider, category string, symbols []string, params model.ManageParams, interval time.Duration) *Manager {
+	return &Manager{
+		API:           api,
+		Positions:     source,
+		Orders:        orders,
+		Prices:        prices,
+		Limits:        limits,
+		Formatter:     &utils.Formatter{},
+		Category:      category,
+		Symbols:       symbols,
+		Params:        params,
+		Interval:      interval,
+		KlineInterval: model.DefaultSignalInterval,
+		plans:         make(map[string]*managedPlan),
+	}
+}
+
+// Run проверяет позиции с периодом Interval до отмены ctx.
+func (m *Manager) Run(ctx context.Context) error {
+	if !m.Params.Enabled() || m.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			for _, symbol := range m.Symbols {
+				if err := m.manage(symbol); err != nil {
+					log.Printf("[Сопровождение] %s: %v", symbol, err)
+				}
+			}
+		}
+	}
+}
+
+// manage обновляет план позиции символа и переносит стоп на бирже, если план его сдвинул.
+func (m *Manager) manage(symbol string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pos, ok := m.Positions.Position(symbol)
+	if !ok {
+		delete(m.plans, symbol)
+		return nil
+	}
+	managed, ok := m.plans[symbol]
+	if !ok || !managed.openedAt.Equal(pos.OpenedAt) {
+		plan, err := m.start(symbol, pos)
+		if err != nil || plan == nil {
+			return err
+		}
+		managed = &managedPlan{plan: plan, openedAt: pos.OpenedAt}
+		m.plans[symbol] = managed
+	}
+
+	price, ok := m.Prices.LastPrice(symbol)
+	if !ok {
+		return nil
+	}
+	// План запоминает перенос только после того, как биржа приняла стоп: иначе
+	// безубыток или трейлинг будут предложены снова на следующей проверке.
+	move, moved := managed.plan.Propose(price, price, m.atr(symbol))
+	if !moved {
+		managed.plan.Commit(move)
+		return nil
+	}
+	limits, err := m.Limits.GetTradeLimitsViaInstruments(m.Category, symbol)
+	if err != nil {
+		return fmt.Errorf("limits: %w", err)
+	}
+	stop := m.Formatter.FormatPrice(limits, move.Stop)
+	if err := m.API.SetStopLoss(m.Category, symbol, stop); err != nil {
+		return err
+	}
+	managed.plan.Commit(move)
+	log.Printf("[Сопровождение] %s %s: SL перенесён на %v (%s)", pos.Side, symbol, stop, move.Reason)
+	return nil
+}
+
+// start создаёт план для новой позиции и выставляет частичные TP, которых ещё нет на бирже
+// (например, после перезапуска). Если выставить все уровни не удалось, выставленные
+// в этот раз TP снимаются, и следующая проверка повторяет попытку целиком.
+// Возвращает nil без ошибки, если начальный SL позиции неизвестен.
+func (m *Manager) start(symbol string, pos model.PositionRep) (*engine.TradePlan, error) {
+	entry, err := m.entryOrder(symbol, pos.Side)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || entry.StopLoss <= 0 {
+		return nil, nil
+	}
+	limits, err := m.Limits.GetTradeLimitsViaInstruments(m.Category, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("limits: %w", err)
+	}
+	step := limits.StepSize
+	if step <= 0 {
+		step = limits.MinQuantity
+	}
+	plan := engine.NewTradePlan(pos.Side, pos.EntryPrice, entry.StopLoss, pos.Size, step, m.Params)
+	log.Printf("[Сопровождение] %s %s: вход %v, SL %v, 1R = %v", pos.Side, symbol, pos.EntryPrice, entry.StopLoss, plan.Risk())
+
+	existing, err := m.partialTakeProfits(symbol)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, o := range existing {
+		known[o.OrderID] = true
+	}
+	var placed []float64
+	for _, level := range plan.PartialTakeProfits() {
+		price := m.Formatter.FormatPrice(limits, level.Price)
+		if hasPartialAt(existing, price, limits.TickSize) {
+			log.Printf("[Сопровождение] %s %s: частичный TP по %v уже выставлен", pos.Side, symbol, price)
+			continue
+		}
+		if err := m.API.SetPartialTakeProfit(m.Category, symbol, price, level.Qty); err != nil {
+			m.cancelPartials(symbol, known, placed, limits.TickSize)
+			return nil, err
+		}
+		placed = append(placed, price)
+		log.Printf("[Сопровождение] %s %s: частичный TP %v по %v (+%.2fR)", pos.Side, symbol, level.Qty, price, level.R)
+	}
+	return plan, nil
+}
+
+// partialTakeProfits возвращает частичные TP символа, выставленные на бирже.
+func (m *Manager) partialTakeProfits(symbol string) ([]model.OrderUpdate, error) {
+	orders, err := m.API.ListOpenOrders(m.Category, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("open orders: %w", err)
+	}
+	var partials []model.OrderUpdate
+	for _, o := range orders {
+		if o.StopOrderType == stopOrderPartialTakeProfit {
+			partials = append(partials, o)
+		}
+	}
+	return partials, nil
+}
+
+// cancelPartials снимает частичные TP по ценам placed, кроме известных до выставления (known).
+func (m *Manager) cancelPartials(symbol string, known map[string]bool, placed []float64, tick float64) {
+	if len(placed) == 0 {
+		return
+	}
+	partials, err := m.partialTakeProfits(symbol)
+	if err != nil {
+		log.Printf("[Сопровождение] %s: не удалось снять частичные TP: %v", symbol, err)
+		return
+	}
+	for _, o := range partials {
+		if known[o.OrderID] || !hasPrice(placed, o.Price.Float64(), tick) {
+			continue
+		}
+		if err := m.API.CancelOrder(m.Category, symbol, o.OrderID); err != nil {
+			log.Printf("[Сопровождение] %s: не удалось снять частичный TP %s: %v", symbol, o.OrderID, err)
+			continue
+		}
+		log.Printf("[Сопровождение] %s: частичный TP %s по %v снят", symbol, o.OrderID, o.Price.Float64())
+	}
+}
+
+func hasPartialAt(partials []model.OrderUpdate, price, tick float64) bool {
+	for _, o := range partials {
+		if samePrice(o.Price.Float64(), price, tick) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrice(prices []float64, price, tick float64) bool {
+	for _, p := range prices {
+		if samePrice(p, price, tick) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePrice сравнивает цены с точностью до половины шага цены.
+func samePrice(a, b, tick float64) bool {
+	return math.Abs(a-b) <= math.Max(tick/2, 1e-12)
+}
+
+// entryOrder возвращает последний исполненный ордер в сторону позиции.
+func (m *Manager) entryOrder(symbol, side string) (*model.Order, error) {
+	orders, err := m.Orders.FindOrdersBySymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	orderSide := "buy"
+	if side == "short" {
+		orderSide = "sell"
+	}
+	var entry *model.Order
+	for _, o := range orders {
+		if o.Status != model.OrderStatusFilled || !strings.EqualFold(o.Side, orderSide) {
+			continue
+		}
+		if entry == nil || o.UpdatedAt.After(entry.UpdatedAt) {
+			entry = o
+		}
+	}
+	return entry, nil
+}
+
+// atr возвращает ATR для трейлинга; 0, если он не нужен или свечей недостаточно.
+func (m *Manager) atr(symbol string) float64 {
+	if m.Params.Trail != model.TrailATR || m.MarketData == nil {
+		return 0
+	}
+	period := m.Params.TrailATRPeriod
+	klines, ok := m.MarketData.GetRecentKlines(symbol, m.KlineInterval, 3*period)
+	if !ok {
+		return 0
+	}
+	return exchange.LastATR(klines, period)
+}
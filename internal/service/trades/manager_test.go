@@ -0,0 +1,169 @@
+package trades
+
+import (
+	"bybit-bot/internal/model"
+	"bybit-bot/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/thrasher-corp/gocryptotrader/types"
+	"testing"
+	"time"
+)
+
+var errRejected = errors.New("rejected")
+
+// fakeTradingStop хранит частичные TP как условные ордера, как Bybit, и отказывает
+// в вызовах, пока счётчики failStop и failPartialAfter не исчерпаны.
+type fakeTradingStop struct {
+	partials         []model.OrderUpdate
+	stops            []float64
+	failStop         int // сколько следующих SetStopLoss отклонить
+	failPartialAfter int // после скольких успешных SetPartialTakeProfit отказать; < 0 — не отказывать
+	placedPartials   int
+	nextID           int
+}
+
+func (f *fakeTradingStop) SetStopLoss(_, _ string, stopLoss float64) error {
+	if f.failStop > 0 {
+		f.failStop--
+		return errRejected
+	}
+	f.stops = append(f.stops, stopLoss)
+	return nil
+}
+
+func (f *fakeTradingStop) SetPartialTakeProfit(_, symbol string, price, qty float64) error {
+	if f.failPartialAfter >= 0 && f.placedPartials >= f.failPartialAfter {
+		f.failPartialAfter = -1
+		return errRejected
+	}
+	f.placedPartials++
+	f.nextID++
+	f.partials = append(f.partials, model.OrderUpdate{
+		Symbol:        symbol,
+		OrderID:       fmt.Sprintf("tp-%d", f.nextID),
+		Price:         types.Number(price),
+		Qty:           types.Number(qty),
+		StopOrderType: stopOrderPartialTakeProfit,
+	})
+	return nil
+}
+
+func (f *fakeTradingStop) ListOpenOrders(_, _ string) ([]model.OrderUpdate, error) {
+	return append([]model.OrderUpdate{{OrderID: "entry", Price: 105}}, f.partials...), nil
+}
+
+func (f *fakeTradingStop) CancelOrder(_, _, orderID string) error {
+	for i, o := range f.partials {
+		if o.OrderID == orderID {
+			f.partials = append(f.partials[:i], f.partials[i+1:]...)
+			return nil
+		}
+	}
+	return errRejected
+}
+
+func (f *fakeTradingStop) GetTradeLimitsViaInstruments(_, _ string) (model.TradeLimits, error) {
+	return model.TradeLimits{MinQuantity: 0.01, StepSize: 0.01, TickSize: 0.1}, nil
+}
+
+type fixedPosition struct{ pos model.PositionRep }
+
+func (p *fixedPosition) Position(string) (model.PositionRep, bool) { return p.pos, true }
+
+type fixedPrice struct{ price float64 }
+
+func (p *fixedPrice) LastPrice(string) (float64, bool) { return p.price, true }
+
+// newTestManager возвращает менеджер для long BTCUSDT: вход 100, SL 90 (1R = 10),
+// частичные TP по 1R и 2R и безубыток после +1R.
+func newTestManager(t *testing.T, api *fakeTradingStop) (*Manager, *fixedPrice) {
+	t.Helper()
+	orders := repository.NewMemoryOrderRepository()
+	if err := orders.InsertOrder(&model.Order{
+		OrderID: "entry-1", Symbol: "BTCUSDT", Side: "Buy", Status: model.OrderStatusFilled,
+		Price: 100, Quantity: 1, StopLoss: 90, UpdatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("insert entry order: %v", err)
+	}
+	position := &fixedPosition{pos: model.PositionRep{Symbol: "BTCUSDT", Side: "long", Size: 1, EntryPrice: 100, OpenedAt: time.Unix(1_700_000_000, 0)}}
+	price := &fixedPrice{price: 100}
+	params := model.ManageParams{
+		BreakEvenR:  1,
+		TakeProfits: []model.PartialTakeProfit{{R: 1, Fraction: 0.3}, {R: 2, Fraction: 0.3}},
+	}
+	m := NewManager(api, position, orders, price, api, "linear", []string{"BTCUSDT"}, params, time.Second)
+	return m, price
+}
+
+func partialPrices(api *fakeTradingStop) []float64 {
+	var prices []float64
+	for _, o := range api.partials {
+		prices = append(prices, o.Price.Float64())
+	}
+	return prices
+}
+
+func TestManagerDoesNotDuplicatePartialsAfterRestart(t *testing.T) {
+	api := &fakeTradingStop{failPartialAfter: -1}
+	first, _ := newTestManager(t, api)
+	if err := first.manage("BTCUSDT"); err != nil {
+		t.Fatalf("manage: %v", err)
+	}
+	if got := partialPrices(api); len(got) != 2 || got[0] != 110 || got[1] != 120 {
+		t.Fatalf("partials after start = %v, want [110 120]", got)
+	}
+
+	// Новый менеджер без плана в памяти, как после перезапуска бота.
+	restarted, _ := newTestManager(t, api)
+	if err := restarted.manage("BTCUSDT"); err != nil {
+		t.Fatalf("manage after restart: %v", err)
+	}
+	if got := partialPrices(api); len(got) != 2 {
+		t.Fatalf("partials after restart = %v, want the original two", got)
+	}
+}
+
+func TestManagerRollsBackPartialsOnFailure(t *testing.T) {
+	api := &fakeTradingStop{failPartialAfter: 1}
+	m, _ := newTestManager(t, api)
+	if err := m.manage("BTCUSDT"); !errors.Is(err, errRejected) {
+		t.Fatalf("manage = %v, want the placement error", err)
+	}
+	if got := partialPrices(api); len(got) != 0 {
+		t.Fatalf("partials after failed start = %v, want them rolled back", got)
+	}
+	if err := m.manage("BTCUSDT"); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := partialPrices(api); len(got) != 2 {
+		t.Fatalf("partials after retry = %v, want 2", got)
+	}
+}
+
+func TestManagerRetriesRejectedStop(t *testing.T) {
+	api := &fakeTradingStop{failPartialAfter: -1, failStop: 1}
+	m, price := newTestManager(t, api)
+	if err := m.manage("BTCUSDT"); err != nil {
+		t.Fatalf("manage: %v", err)
+	}
+
+	price.price = 111 // +1.1R: пора переносить стоп в безубыток
+	if err := m.manage("BTCUSDT"); !errors.Is(err, errRejected) {
+		t.Fatalf("manage = %v, want the rejected stop", err)
+	}
+	if len(api.stops) != 0 || m.plans["BTCUSDT"].plan.BreakEven() {
+		t.Fatalf("plan committed a rejected stop: stops %v, breakeven %v", api.stops, m.plans["BTCUSDT"].plan.BreakEven())
+	}
+
+	price.price = 105 // цена откатилась, но безубыток уже заслужен
+	if err := m.manage("BTCUSDT"); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(api.stops) != 1 || api.stops[0] != 100 {
+		t.Fatalf("stops = %v, want break-even at 100", api.stops)
+	}
+	if plan := m.plans["BTCUSDT"].plan; !plan.BreakEven() || plan.Stop != 100 {
+		t.Fatalf("plan stop %v, breakeven %v after the accepted stop", plan.Stop, plan.BreakEven())
+	}
+}
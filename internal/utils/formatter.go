@@ -51,6 +51,21 @@ func (m *Formatter) FormatQuantity(limit model.TradeLimits, quantity float64) fl
 	return quantity
 }
 
+// FloorToStep округляет количество вниз до кратного step с точностью шага,
+// чтобы объём не превысил рассчитанный риск.
+func (m *Formatter) FloorToStep(quantity, step float64) float64 {
+	if step <= 0 {
+		return quantity
+	}
+	split := strings.Split(strconv.FormatFloat(step, 'f', -1, 64), ".")
+	precision := 0
+	if len(split) > 1 {
+		precision = len(split[1])
+	}
+	steps := math.Floor(quantity/step + 1e-9)
+	return m.ToFixed(steps*step, precision)
+}
+
 func (m *Formatter) ComparePercentage(first float64, second float64) model.Percent {
 	return model.Percent(second * 100.00 / first)
 }